@@ -7,6 +7,7 @@ require (
 	github.com/bitrise-io/go-steputils v0.0.0-20210824140209-e19983be529f
 	github.com/bitrise-io/go-utils v0.0.0-20210824130242-27933dca637a
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
+	github.com/ryanuber/go-glob v1.0.0
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/text v0.3.8
 )
@@ -14,7 +15,6 @@ require (
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/ryanuber/go-glob v1.0.0 // indirect
 	github.com/stretchr/objx v0.3.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
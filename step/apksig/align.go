@@ -0,0 +1,133 @@
+package apksig
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// entryAlignment is the alignment of the data of uncompressed entries, as zipalign does.
+	entryAlignment = 4
+	// nativeLibraryAlignment is the alignment of uncompressed native libraries, so they can be mapped
+	// directly from the APK on devices with 16 KB memory pages.
+	nativeLibraryAlignment = 16384
+
+	localFileHeaderSize = 30
+	dataDescriptorFlag  = 0x8
+
+	// alignmentExtraID is the ID of the extra field padding the local file header, as written by apksigner.
+	alignmentExtraID     = 0xd935
+	alignmentExtraMinLen = 6
+)
+
+// signatureEntryTime is the modification time of the signature entries, a fixed time keeps the output reproducible.
+var signatureEntryTime = time.Date(1981, time.January, 1, 1, 1, 2, 0, time.UTC)
+
+// entry is a new ZIP entry written after the entries of the source APK.
+type entry struct {
+	name    string
+	content []byte
+}
+
+// countingWriter counts the bytes written, to know the offsets of the ZIP entries.
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// writeAlignedZIP copies the entries of the source APK without recompressing them, except its signature files,
+// and writes the new entries after them. The data of the uncompressed entries is aligned.
+func writeAlignedZIP(w io.Writer, source *zip.Reader, entries []entry) error {
+	counter := &countingWriter{w: w}
+	zipWriter := zip.NewWriter(counter)
+
+	for _, file := range source.File {
+		if isSignatureEntry(file.Name) {
+			continue
+		}
+
+		header := file.FileHeader
+		// The sizes are known, writing them to the local header makes the entry offsets predictable.
+		header.Flags &^= dataDescriptorFlag
+		if header.Method == zip.Store && !strings.HasSuffix(header.Name, "/") {
+			if err := zipWriter.Flush(); err != nil {
+				return err
+			}
+			dataOffset := counter.count + localFileHeaderSize + int64(len(header.Name))
+			header.Extra = alignedExtra(header.Extra, dataOffset, entryAlignmentOf(header.Name))
+		}
+
+		raw, err := file.OpenRaw()
+		if err != nil {
+			return err
+		}
+		writer, err := zipWriter.CreateRaw(&header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, raw); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: signatureEntryTime})
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(e.content); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+func entryAlignmentOf(name string) int64 {
+	if strings.HasPrefix(name, "lib/") && strings.HasSuffix(name, ".so") {
+		return nativeLibraryAlignment
+	}
+	return entryAlignment
+}
+
+// alignedExtra returns the extra field of the local file header, padded so that the entry data starting
+// after the header's name (at dataOffset without the extra field) is aligned. Previous padding is removed.
+func alignedExtra(extra []byte, dataOffset, alignment int64) []byte {
+	var kept []byte
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			break
+		}
+		if id != alignmentExtraID && id != 0 {
+			kept = append(kept, extra[:4+size]...)
+		}
+		extra = extra[4+size:]
+	}
+
+	offset := dataOffset + int64(len(kept))
+	padding := (alignment - offset%alignment) % alignment
+	if padding == 0 {
+		return kept
+	}
+	for padding < alignmentExtraMinLen {
+		padding += alignment
+	}
+
+	field := make([]byte, padding)
+	binary.LittleEndian.PutUint16(field, alignmentExtraID)
+	binary.LittleEndian.PutUint16(field[2:], uint16(padding-4))
+	binary.LittleEndian.PutUint16(field[4:], uint16(alignment))
+
+	return append(kept, field...)
+}
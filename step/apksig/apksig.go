@@ -0,0 +1,125 @@
+// Package apksig inspects the signatures of APKs: the v1 (JAR) signature and the v2, v3 and v3.1 APK Signature Scheme
+// blocks. The digests and the signatures are verified with the Go standard library, without apksigner.
+package apksig
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Scheme is an APK signature scheme.
+type Scheme string
+
+// Signature schemes.
+const (
+	SchemeV1  Scheme = "v1"
+	SchemeV2  Scheme = "v2"
+	SchemeV3  Scheme = "v3"
+	SchemeV31 Scheme = "v3.1"
+)
+
+// Report is the result of the APK signature verification.
+type Report struct {
+	// Schemes are the verified signature schemes, in ascending order.
+	Schemes []Scheme
+	// CertSHA256 are the SHA-256 fingerprints (lowercase hex) of the signer certificates.
+	CertSHA256 []string
+}
+
+// Signed returns whether the APK has at least one verified signature.
+func (r Report) Signed() bool {
+	return len(r.Schemes) > 0
+}
+
+// SchemeNames returns the schemes separated by comma, for example: v1,v2,v3.
+func (r Report) SchemeNames() string {
+	var names []string
+	for _, scheme := range r.Schemes {
+		names = append(names, string(scheme))
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (r *Report) addCertificate(der []byte) {
+	sum := sha256.Sum256(der)
+	fingerprint := hex.EncodeToString(sum[:])
+	for _, existing := range r.CertSHA256 {
+		if existing == fingerprint {
+			return
+		}
+	}
+	r.CertSHA256 = append(r.CertSHA256, fingerprint)
+}
+
+// Verify verifies the signatures of the APK. An APK without signatures results in an empty report,
+// an error is returned if a signature is present but invalid.
+func Verify(pth string) (Report, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return Report{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Report{}, err
+	}
+
+	zipReader, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to open APK: %s", err)
+	}
+
+	var report Report
+
+	v1Certs, err := verifyJARSignature(zipReader)
+	if err != nil {
+		return Report{}, fmt.Errorf("invalid v1 signature: %s", err)
+	}
+	if len(v1Certs) > 0 {
+		report.Schemes = append(report.Schemes, SchemeV1)
+		for _, cert := range v1Certs {
+			report.addCertificate(cert)
+		}
+	}
+
+	layout, err := readLayout(f, info.Size())
+	if err != nil {
+		return Report{}, err
+	}
+	if layout.block == nil {
+		return report, nil
+	}
+
+	digester := newContentDigester(f, layout)
+	for _, scheme := range []struct {
+		scheme Scheme
+		id     uint32
+	}{
+		{SchemeV2, blockIDV2},
+		{SchemeV3, blockIDV3},
+		{SchemeV31, blockIDV31},
+	} {
+		value, ok := layout.block.pairs[scheme.id]
+		if !ok {
+			continue
+		}
+
+		certs, err := verifySchemeBlock(value, scheme.scheme != SchemeV2, digester)
+		if err != nil {
+			return Report{}, fmt.Errorf("invalid %s signature: %s", scheme.scheme, err)
+		}
+
+		report.Schemes = append(report.Schemes, scheme.scheme)
+		for _, cert := range certs {
+			report.addCertificate(cert)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,307 @@
+package apksig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testEntries = []ziptest.Entry{
+	{Name: "AndroidManifest.xml", Content: []byte("manifest")},
+	{Name: "classes.dex", Content: bytes.Repeat([]byte("dex"), 1000)},
+	{Name: "res/raw/data.bin", Content: bytes.Repeat([]byte{1, 2, 3}, 500000)},
+}
+
+func TestVerify_Unsigned(t *testing.T) {
+	pth := writeAPK(t, ziptest.Zip(t, testEntries))
+
+	report, err := Verify(pth)
+
+	assert.NoError(t, err)
+	assert.False(t, report.Signed())
+	assert.Empty(t, report.CertSHA256)
+}
+
+func TestVerify_V2(t *testing.T) {
+	key, cert := newTestSigner(t)
+	pth := writeAPK(t, addSchemeBlocks(t, ziptest.Zip(t, testEntries), key, cert, blockIDV2))
+
+	report, err := Verify(pth)
+
+	assert.NoError(t, err)
+	assert.True(t, report.Signed())
+	assert.Equal(t, []Scheme{SchemeV2}, report.Schemes)
+	assert.Equal(t, []string{fingerprint(cert)}, report.CertSHA256)
+}
+
+func TestVerify_AllSchemes(t *testing.T) {
+	key, cert := newTestSigner(t)
+	entries := append(testEntries, jarSignature(t, testEntries, key, cert)...)
+	pth := writeAPK(t, addSchemeBlocks(t, ziptest.Zip(t, entries), key, cert, blockIDV2, blockIDV3, blockIDV31))
+
+	report, err := Verify(pth)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Scheme{SchemeV1, SchemeV2, SchemeV3, SchemeV31}, report.Schemes)
+	assert.Equal(t, "v1,v2,v3,v3.1", report.SchemeNames())
+	assert.Equal(t, []string{fingerprint(cert)}, report.CertSHA256)
+}
+
+func TestVerify_ModifiedContent(t *testing.T) {
+	key, cert := newTestSigner(t)
+	apk := addSchemeBlocks(t, ziptest.Zip(t, testEntries), key, cert, blockIDV2)
+	index := bytes.Index(apk, []byte("manifest"))
+	apk[index] = 'M'
+
+	_, err := Verify(writeAPK(t, apk))
+
+	assert.EqualError(t, err, "invalid v2 signature: content digest (algorithm 0x0103) does not match")
+}
+
+func TestVerify_ModifiedJAREntry(t *testing.T) {
+	key, cert := newTestSigner(t)
+	signature := jarSignature(t, testEntries, key, cert)
+	modified := append([]ziptest.Entry{{Name: "AndroidManifest.xml", Content: []byte("modified")}}, testEntries[1:]...)
+
+	_, err := Verify(writeAPK(t, ziptest.Zip(t, append(modified, signature...))))
+
+	assert.EqualError(t, err, "invalid v1 signature: AndroidManifest.xml: SHA-256-Digest does not match")
+}
+
+func TestVerify_V1AuthenticatedAttributes(t *testing.T) {
+	key, cert := newTestSigner(t)
+	signature := jarSignature(t, testEntries, key, cert)
+	signature[2].Content = jarSignatureBlock(t, signature[1].Content, key, cert, true)
+
+	report, err := Verify(writeAPK(t, ziptest.Zip(t, append(testEntries, signature...))))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Scheme{SchemeV1}, report.Schemes)
+	assert.Equal(t, []string{fingerprint(cert)}, report.CertSHA256)
+}
+
+func TestVerify_TamperedJARSignature(t *testing.T) {
+	key, cert := newTestSigner(t)
+	otherKey, _ := newTestSigner(t)
+
+	tests := map[string]func(signature []ziptest.Entry){
+		"modified signature file": func(signature []ziptest.Entry) {
+			signature[1].Content = append(signature[1].Content, []byte("X-Tampered: true\r\n\r\n")...)
+		},
+		"signed by another key": func(signature []ziptest.Entry) {
+			signature[2].Content = jarSignatureBlock(t, signature[1].Content, otherKey, cert, false)
+		},
+		"modified signed attribute": func(signature []ziptest.Entry) {
+			signature[2].Content = jarSignatureBlock(t, append(signature[1].Content, ' '), key, cert, true)
+		},
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			signature := jarSignature(t, testEntries, key, cert)
+			tamper(signature)
+
+			report, err := Verify(writeAPK(t, ziptest.Zip(t, append(testEntries, signature...))))
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid v1 signature: signature block of META-INF/CERT.SF")
+			assert.Empty(t, report.CertSHA256)
+		})
+	}
+}
+
+func Test_parseManifest(t *testing.T) {
+	sections := parseManifest([]byte("Manifest-Version: 1.0\r\nCreated-By: test\r\n\r\nName: res/a-very-long-na\r\n me.xml\r\nSHA-256-Digest: abc\r\n\r\n"))
+
+	assert.Equal(t, []manifestSection{
+		{"Manifest-Version": "1.0", "Created-By": "test"},
+		{"Name": "res/a-very-long-name.xml", "SHA-256-Digest": "abc"},
+	}, sections)
+}
+
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestSigner(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+
+	return key, cert
+}
+
+func writeAPK(t *testing.T, content []byte) string {
+	pth := filepath.Join(t.TempDir(), "app.apk")
+	if err := os.WriteFile(pth, content, 0o600); err != nil {
+		t.Fatalf("failed to write APK: %s", err)
+	}
+
+	return pth
+}
+
+// jarSignature returns the JAR signature entries of the given entries, signed with SHA-256 and RSA.
+func jarSignature(t *testing.T, entries []ziptest.Entry, key *rsa.PrivateKey, cert []byte) []ziptest.Entry {
+	manifest := "Manifest-Version: 1.0\r\n\r\n"
+	for _, entry := range entries {
+		sum := sha256.Sum256(entry.Content)
+		manifest += fmt.Sprintf("Name: %s\r\nSHA-256-Digest: %s\r\n\r\n", entry.Name, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	manifestSum := sha256.Sum256([]byte(manifest))
+	signatureFile := fmt.Sprintf("Signature-Version: 1.0\r\nSHA-256-Digest-Manifest: %s\r\n\r\n", base64.StdEncoding.EncodeToString(manifestSum[:]))
+
+	return []ziptest.Entry{
+		{Name: manifestPath, Content: []byte(manifest)},
+		{Name: "META-INF/CERT.SF", Content: []byte(signatureFile)},
+		{Name: "META-INF/CERT.RSA", Content: jarSignatureBlock(t, []byte(signatureFile), key, cert, false)},
+	}
+}
+
+// jarSignatureBlock returns the PKCS#7 signature block of the signature file, optionally with authenticated
+// attributes holding the digest of the signature file, like jarsigner creates.
+func jarSignatureBlock(t *testing.T, signatureFile []byte, key *rsa.PrivateKey, cert []byte, withAttributes bool) []byte {
+	certificate, err := x509.ParseCertificate(cert)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	signed := signatureFile
+	var attributes asn1.RawValue
+	if withAttributes {
+		sum := sha256.Sum256(signatureFile)
+		encoded := append(
+			mustMarshal(t, pkcs7Attribute{Type: oidAttributeContentType, Values: asn1.RawValue{FullBytes: mustMarshal(t, []asn1.ObjectIdentifier{oidData})}}),
+			mustMarshal(t, pkcs7Attribute{Type: oidAttributeMessageDigest, Values: asn1.RawValue{FullBytes: mustMarshal(t, [][]byte{sum[:]})}})...,
+		)
+		attributes = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encoded}
+		signed = mustMarshal(t, asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: encoded})
+	}
+
+	hashed := sha256.Sum256(signed)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+
+	signerInfo := mustMarshal(t, pkcs7SignerInfo{
+		Version:                   1,
+		IssuerAndSerialNumber:     pkcs7IssuerAndSerial{Issuer: asn1.RawValue{FullBytes: certificate.RawIssuer}, SerialNumber: certificate.SerialNumber},
+		DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+		AuthenticatedAttributes:   attributes,
+		DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption},
+		EncryptedDigest:           signature,
+	})
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+		ContentInfo:      asn1.RawValue{FullBytes: mustMarshal(t, pkcs7ContentInfo{ContentType: oidData})},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signerInfo},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal signed data: %s", err)
+	}
+
+	return mustMarshal(t, pkcs7ContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+
+	return data
+}
+
+// addSchemeBlocks inserts an APK Signing Block with RSA PKCS#1 SHA-256 signed v2+ scheme blocks into the APK.
+func addSchemeBlocks(t *testing.T, apk []byte, key *rsa.PrivateKey, cert []byte, ids ...uint32) []byte {
+	l, err := readLayout(bytes.NewReader(apk), int64(len(apk)))
+	if err != nil {
+		t.Fatalf("failed to read APK layout: %s", err)
+	}
+	digest, err := newContentDigester(bytes.NewReader(apk), l).digest(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to compute digest: %s", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %s", err)
+	}
+
+	var pairs []byte
+	for _, id := range ids {
+		v3 := id != blockIDV2
+		signedData := lengthPrefixed(lengthPrefixed(uint32Bytes(sigRSAPKCS1SHA256), lengthPrefixed(digest)))
+		signedData = append(signedData, lengthPrefixed(lengthPrefixed(cert))...)
+		if v3 {
+			signedData = append(signedData, uint32Bytes(24)...)
+			signedData = append(signedData, uint32Bytes(0x7fffffff)...)
+		}
+		signedData = append(signedData, lengthPrefixed()...)
+
+		hashed := sha256.Sum256(signedData)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatalf("failed to sign: %s", err)
+		}
+
+		signer := lengthPrefixed(signedData)
+		if v3 {
+			signer = append(signer, uint32Bytes(24)...)
+			signer = append(signer, uint32Bytes(0x7fffffff)...)
+		}
+		signer = append(signer, lengthPrefixed(lengthPrefixed(uint32Bytes(sigRSAPKCS1SHA256), lengthPrefixed(sig)))...)
+		signer = append(signer, lengthPrefixed(publicKey)...)
+
+		value := lengthPrefixed(lengthPrefixed(signer))
+		pair := make([]byte, 12)
+		binary.LittleEndian.PutUint64(pair, uint64(len(value)+4))
+		binary.LittleEndian.PutUint32(pair[8:], id)
+		pairs = append(append(pairs, pair...), value...)
+	}
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(pairs)+blockFooterSize))
+	block := append(append(append(append([]byte{}, size...), pairs...), size...), blockMagic...)
+
+	var signed []byte
+	signed = append(signed, apk[:l.cdOffset]...)
+	signed = append(signed, block...)
+	signed = append(signed, apk[l.cdOffset:l.cdOffset+l.cdSize]...)
+	signed = append(signed, eocdWithCDOffset(l.eocd, l.cdOffset+int64(len(block)))...)
+
+	return signed
+}
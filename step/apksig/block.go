@@ -0,0 +1,210 @@
+package apksig
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// IDs of the APK Signing Block entries.
+const (
+	blockIDV2  = 0x7109871a
+	blockIDV3  = 0xf05368c0
+	blockIDV31 = 0x1b93ad61
+)
+
+const (
+	eocdSignature      = 0x06054b50
+	eocdMinSize        = 22
+	eocdMaxCommentSize = 0xffff
+	eocdCDSizeOffset   = 12
+	eocdCDOffsetOffset = 16
+
+	blockMagic      = "APK Sig Block 42"
+	blockFooterSize = 8 + len(blockMagic)
+)
+
+// layout describes the sections of an APK.
+type layout struct {
+	// entriesEnd is the end of the ZIP entries: the start of the APK Signing Block or the central directory.
+	entriesEnd int64
+	cdOffset   int64
+	cdSize     int64
+	eocd       []byte
+	block      *signingBlock
+}
+
+// signingBlock is the APK Signing Block, the ID-value pairs between the ZIP entries and the central directory.
+type signingBlock struct {
+	offset int64
+	pairs  map[uint32][]byte
+}
+
+func readLayout(r io.ReaderAt, size int64) (layout, error) {
+	eocd, eocdOffset, err := findEOCD(r, size)
+	if err != nil {
+		return layout{}, err
+	}
+
+	cdSize := int64(binary.LittleEndian.Uint32(eocd[eocdCDSizeOffset:]))
+	cdOffset := int64(binary.LittleEndian.Uint32(eocd[eocdCDOffsetOffset:]))
+	if cdOffset+cdSize != eocdOffset {
+		return layout{}, errors.New("ZIP central directory is not followed by the end of central directory record")
+	}
+
+	block, err := readSigningBlock(r, cdOffset)
+	if err != nil {
+		return layout{}, err
+	}
+
+	l := layout{
+		entriesEnd: cdOffset,
+		cdOffset:   cdOffset,
+		cdSize:     cdSize,
+		eocd:       eocd,
+		block:      block,
+	}
+	if block != nil {
+		l.entriesEnd = block.offset
+	}
+
+	return l, nil
+}
+
+// findEOCD returns the ZIP end of central directory record and its offset.
+func findEOCD(r io.ReaderAt, size int64) ([]byte, int64, error) {
+	if size < eocdMinSize {
+		return nil, 0, errors.New("not a ZIP file")
+	}
+
+	tailSize := int64(eocdMinSize + eocdMaxCommentSize)
+	if tailSize > size {
+		tailSize = size
+	}
+	tail := make([]byte, tailSize)
+	if _, err := r.ReadAt(tail, size-tailSize); err != nil {
+		return nil, 0, err
+	}
+
+	for i := len(tail) - eocdMinSize; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(tail[i:]) != eocdSignature {
+			continue
+		}
+		commentSize := int(binary.LittleEndian.Uint16(tail[i+20:]))
+		if i+eocdMinSize+commentSize != len(tail) {
+			continue
+		}
+		return tail[i:], size - tailSize + int64(i), nil
+	}
+
+	return nil, 0, errors.New("ZIP end of central directory record not found")
+}
+
+// readSigningBlock reads the APK Signing Block preceding the central directory, nil is returned if there is none.
+func readSigningBlock(r io.ReaderAt, cdOffset int64) (*signingBlock, error) {
+	if cdOffset < int64(blockFooterSize) {
+		return nil, nil
+	}
+
+	footer := make([]byte, blockFooterSize)
+	if _, err := r.ReadAt(footer, cdOffset-int64(blockFooterSize)); err != nil {
+		return nil, err
+	}
+	if string(footer[8:]) != blockMagic {
+		return nil, nil
+	}
+
+	size := int64(binary.LittleEndian.Uint64(footer))
+	offset := cdOffset - size - 8
+	if size < int64(blockFooterSize) || offset < 0 {
+		return nil, fmt.Errorf("invalid APK Signing Block size: %d", size)
+	}
+
+	content := make([]byte, size+8)
+	if _, err := r.ReadAt(content, offset); err != nil {
+		return nil, err
+	}
+	if int64(binary.LittleEndian.Uint64(content)) != size {
+		return nil, errors.New("APK Signing Block sizes do not match")
+	}
+
+	pairs := map[uint32][]byte{}
+	buf := content[8 : len(content)-blockFooterSize]
+	for len(buf) > 0 {
+		if len(buf) < 8 {
+			return nil, errors.New("truncated APK Signing Block entry")
+		}
+		length := binary.LittleEndian.Uint64(buf)
+		buf = buf[8:]
+		if length < 4 || length > uint64(len(buf)) {
+			return nil, fmt.Errorf("invalid APK Signing Block entry length: %d", length)
+		}
+		pairs[binary.LittleEndian.Uint32(buf)] = buf[4:length]
+		buf = buf[length:]
+	}
+
+	return &signingBlock{offset: offset, pairs: pairs}, nil
+}
+
+// buffer reads the little-endian, length-prefixed structures of the signature scheme blocks.
+type buffer struct {
+	data []byte
+}
+
+func (b *buffer) empty() bool {
+	return len(b.data) == 0
+}
+
+func (b *buffer) uint32() (uint32, error) {
+	if len(b.data) < 4 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	v := binary.LittleEndian.Uint32(b.data)
+	b.data = b.data[4:]
+
+	return v, nil
+}
+
+func (b *buffer) lengthPrefixed() ([]byte, error) {
+	length, err := b.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(length) > uint64(len(b.data)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	v := b.data[:length]
+	b.data = b.data[length:]
+
+	return v, nil
+}
+
+func (b *buffer) sequence() ([][]byte, error) {
+	data, err := b.lengthPrefixed()
+	if err != nil {
+		return nil, err
+	}
+
+	var items [][]byte
+	seq := buffer{data: data}
+	for !seq.empty() {
+		item, err := seq.lengthPrefixed()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// eocdWithCDOffset returns a copy of the end of central directory record pointing to the given central directory offset.
+func eocdWithCDOffset(eocd []byte, cdOffset int64) []byte {
+	modified := bytes.Repeat([]byte{0}, len(eocd))
+	copy(modified, eocd)
+	binary.LittleEndian.PutUint32(modified[eocdCDOffsetOffset:], uint32(cdOffset))
+
+	return modified
+}
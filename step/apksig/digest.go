@@ -0,0 +1,93 @@
+package apksig
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/binary"
+	"hash"
+	"io"
+
+	// Registers the digest algorithms of the signature schemes.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const digestChunkSize = 1024 * 1024
+
+// contentDigester computes the chunked content digests of the APK Signature Scheme v2+ blocks,
+// over the ZIP entries, the central directory and the end of central directory record.
+type contentDigester struct {
+	sections []*io.SectionReader
+	cache    map[crypto.Hash][]byte
+}
+
+func newContentDigester(r io.ReaderAt, l layout) *contentDigester {
+	// The central directory offset of the end of central directory record is treated as pointing to the APK Signing Block.
+	eocd := eocdWithCDOffset(l.eocd, l.entriesEnd)
+
+	return &contentDigester{
+		sections: []*io.SectionReader{
+			io.NewSectionReader(r, 0, l.entriesEnd),
+			io.NewSectionReader(r, l.cdOffset, l.cdSize),
+			io.NewSectionReader(bytes.NewReader(eocd), 0, int64(len(eocd))),
+		},
+		cache: map[crypto.Hash][]byte{},
+	}
+}
+
+func (d *contentDigester) digest(h crypto.Hash) ([]byte, error) {
+	if digest, ok := d.cache[h]; ok {
+		return digest, nil
+	}
+
+	digest, err := chunkedDigest(h.New, d.sections)
+	if err != nil {
+		return nil, err
+	}
+	d.cache[h] = digest
+
+	return digest, nil
+}
+
+// chunkedDigest splits the sections into 1 MiB chunks, and returns the digest of the chunk digests.
+func chunkedDigest(newHash func() hash.Hash, sections []*io.SectionReader) ([]byte, error) {
+	var chunkDigests []byte
+	var chunkCount uint32
+	chunk := make([]byte, digestChunkSize)
+	for _, section := range sections {
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, err := io.ReadFull(section, chunk)
+			if n > 0 {
+				h := newHash()
+				h.Write([]byte{0xa5})
+				writeUint32(h, uint32(n))
+				h.Write(chunk[:n])
+				chunkDigests = h.Sum(chunkDigests)
+				chunkCount++
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	h := newHash()
+	h.Write([]byte{0x5a})
+	writeUint32(h, chunkCount)
+	h.Write(chunkDigests)
+
+	return h.Sum(nil), nil
+}
+
+func writeUint32(w io.Writer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	_, _ = w.Write(b[:])
+}
@@ -0,0 +1,196 @@
+package apksig
+
+import (
+	"archive/zip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// minSDKWithSHA256JAR is the first Android version verifying SHA-256 digests in JAR signatures.
+	minSDKWithSHA256JAR = 18
+
+	createdBy       = "1.0 (Android Build Step)"
+	maxManifestLine = 72
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSHA1          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA1 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+	oidECDSASHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// jarSignatureEntries returns the MANIFEST.MF, the signature file and the signature block of the v1 signature.
+func jarSignatureEntries(zipReader *zip.Reader, signer Signer, minSDK int) ([]entry, error) {
+	digest, digestName := crypto.SHA256, "SHA-256"
+	if _, isRSA := signer.PrivateKey.Public().(*rsa.PublicKey); isRSA && minSDK < minSDKWithSHA256JAR {
+		digest, digestName = crypto.SHA1, "SHA1"
+	}
+	encode := func(data []byte) string {
+		h := digest.New()
+		h.Write(data)
+		return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+
+	var manifest, signatureFile strings.Builder
+	manifest.WriteString(manifestAttribute("Manifest-Version", "1.0"))
+	manifest.WriteString(manifestAttribute("Created-By", createdBy))
+	manifest.WriteString("\r\n")
+
+	var names, sections []string
+	for _, file := range zipReader.File {
+		if isSignatureEntry(file.Name) || strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		h := digest.New()
+		_, err = io.Copy(h, r)
+		_ = r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", file.Name, err)
+		}
+
+		section := manifestAttribute("Name", file.Name) +
+			manifestAttribute(digestName+"-Digest", base64.StdEncoding.EncodeToString(h.Sum(nil))) + "\r\n"
+		manifest.WriteString(section)
+		names = append(names, file.Name)
+		sections = append(sections, section)
+	}
+
+	signatureFile.WriteString(manifestAttribute("Signature-Version", "1.0"))
+	signatureFile.WriteString(manifestAttribute("Created-By", createdBy))
+	signatureFile.WriteString(manifestAttribute(digestName+"-Digest-Manifest", encode([]byte(manifest.String()))))
+	// Prevents stripping the v2 and v3 signatures on devices which support them.
+	signatureFile.WriteString(manifestAttribute("X-Android-APK-Signed", "2, 3"))
+	signatureFile.WriteString("\r\n")
+	for i, name := range names {
+		signatureFile.WriteString(manifestAttribute("Name", name))
+		signatureFile.WriteString(manifestAttribute(digestName+"-Digest", encode([]byte(sections[i]))))
+		signatureFile.WriteString("\r\n")
+	}
+
+	block, blockExt, err := signatureBlock([]byte(signatureFile.String()), signer, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return []entry{
+		{name: manifestPath, content: []byte(manifest.String())},
+		{name: "META-INF/CERT" + signatureFileExt, content: []byte(signatureFile.String())},
+		{name: "META-INF/CERT" + blockExt, content: block},
+	}, nil
+}
+
+// manifestAttribute formats a JAR manifest attribute, lines longer than 72 bytes are continued on the next line.
+func manifestAttribute(name, value string) string {
+	line := name + ": " + value
+	var b strings.Builder
+	for first := true; len(line) > 0; first = false {
+		limit := maxManifestLine
+		if !first {
+			b.WriteString(" ")
+			limit--
+		}
+		if limit > len(line) {
+			limit = len(line)
+		}
+		b.WriteString(line[:limit])
+		b.WriteString("\r\n")
+		line = line[limit:]
+	}
+
+	return b.String()
+}
+
+// signatureBlock returns the PKCS#7 SignedData signature of the signature file, and the extension of its entry.
+func signatureBlock(signatureFile []byte, signer Signer, digest crypto.Hash) ([]byte, string, error) {
+	digestOID, encryptionOID, ext := oidSHA256, oidRSAEncryption, ".RSA"
+	if digest == crypto.SHA1 {
+		digestOID = oidSHA1
+	}
+	switch signer.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+	case *ecdsa.PublicKey:
+		encryptionOID, ext = oidECDSASHA256, ".EC"
+		if digest == crypto.SHA1 {
+			encryptionOID = oidECDSAWithSHA1
+		}
+	default:
+		return nil, "", fmt.Errorf("unsupported key type: %T", signer.PrivateKey.Public())
+	}
+
+	h := digest.New()
+	h.Write(signatureFile)
+	signature, err := signer.PrivateKey.Sign(rand.Reader, h.Sum(nil), digest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	certificate := signer.Certificates[0]
+	digestAlgorithm := pkix.AlgorithmIdentifier{Algorithm: digestOID, Parameters: asn1.NullRawValue}
+	encryptionAlgorithm := pkix.AlgorithmIdentifier{Algorithm: encryptionOID}
+	if encryptionOID.Equal(oidRSAEncryption) {
+		encryptionAlgorithm.Parameters = asn1.NullRawValue
+	}
+
+	signerInfo, err := asn1.Marshal(pkcs7SignerInfo{
+		Version: 1,
+		IssuerAndSerialNumber: pkcs7IssuerAndSerial{
+			Issuer:       asn1.RawValue{FullBytes: certificate.RawIssuer},
+			SerialNumber: certificate.SerialNumber,
+		},
+		DigestAlgorithm:           digestAlgorithm,
+		DigestEncryptionAlgorithm: encryptionAlgorithm,
+		EncryptedDigest:           signature,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	digestAlgorithmDER, err := asn1.Marshal(digestAlgorithm)
+	if err != nil {
+		return nil, "", err
+	}
+	dataContentInfo, err := asn1.Marshal(pkcs7ContentInfo{ContentType: oidData})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var certificates []byte
+	for _, c := range signer.Certificates {
+		certificates = append(certificates, c.Raw...)
+	}
+
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: digestAlgorithmDER},
+		ContentInfo:      asn1.RawValue{FullBytes: dataContentInfo},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certificates},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signerInfo},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	block, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+
+	return block, ext, err
+}
@@ -0,0 +1,191 @@
+package apksig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	// Registers the SHA-384 and SHA-512 digest algorithms of signature blocks.
+	_ "crypto/sha512"
+)
+
+var (
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+type pkcs7SignerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     pkcs7IssuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type pkcs7IssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type pkcs7Attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// pkcs7VerifySignature verifies the first signer of the detached PKCS#7 SignedData signature of the content,
+// like Android does for JAR signatures, and returns the DER encoded signer certificate.
+func pkcs7VerifySignature(data, content []byte) ([]byte, error) {
+	var contentInfo pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &contentInfo); err != nil {
+		return nil, err
+	}
+
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+		return nil, err
+	}
+
+	certificates, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var signerInfo pkcs7SignerInfo
+	if len(signedData.SignerInfos.Bytes) == 0 {
+		return nil, errors.New("no signer")
+	}
+	if _, err := asn1.Unmarshal(signedData.SignerInfos.Bytes, &signerInfo); err != nil {
+		return nil, fmt.Errorf("invalid signer: %s", err)
+	}
+
+	var certificate *x509.Certificate
+	for _, c := range certificates {
+		if bytes.Equal(c.RawIssuer, signerInfo.IssuerAndSerialNumber.Issuer.FullBytes) && c.SerialNumber.Cmp(signerInfo.IssuerAndSerialNumber.SerialNumber) == 0 {
+			certificate = c
+			break
+		}
+	}
+	if certificate == nil {
+		return nil, errors.New("no certificate of the signer")
+	}
+
+	hash, err := pkcs7DigestAlgorithm(signerInfo.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(content)
+	digest := h.Sum(nil)
+
+	// With authenticated attributes the signature covers the DER encoded attributes (as a SET),
+	// which hold the digest of the content.
+	if len(signerInfo.AuthenticatedAttributes.FullBytes) > 0 {
+		if err := verifyAuthenticatedAttributes(signerInfo.AuthenticatedAttributes.Bytes, digest); err != nil {
+			return nil, err
+		}
+		attributes := append([]byte{}, signerInfo.AuthenticatedAttributes.FullBytes...)
+		attributes[0] = asn1.TagSet | 0x20
+		h = hash.New()
+		h.Write(attributes)
+		digest = h.Sum(nil)
+	}
+
+	if err := verifyDigestSignature(certificate.PublicKey, hash, digest, signerInfo.EncryptedDigest); err != nil {
+		return nil, err
+	}
+
+	return certificate.Raw, nil
+}
+
+func pkcs7DigestAlgorithm(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported digest algorithm: %s", oid)
+	}
+}
+
+// verifyAuthenticatedAttributes checks that the authenticated attributes describe data with the given digest.
+func verifyAuthenticatedAttributes(data, digest []byte) error {
+	var contentTypeFound, digestFound bool
+	for rest := data; len(rest) > 0; {
+		var attribute pkcs7Attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attribute); err != nil {
+			return fmt.Errorf("invalid authenticated attribute: %s", err)
+		}
+
+		switch {
+		case attribute.Type.Equal(oidAttributeContentType):
+			var contentType asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attribute.Values.Bytes, &contentType); err != nil || !contentType.Equal(oidData) {
+				return errors.New("invalid content type attribute")
+			}
+			contentTypeFound = true
+		case attribute.Type.Equal(oidAttributeMessageDigest):
+			var messageDigest []byte
+			if _, err := asn1.Unmarshal(attribute.Values.Bytes, &messageDigest); err != nil {
+				return errors.New("invalid message digest attribute")
+			}
+			if !bytes.Equal(messageDigest, digest) {
+				return errors.New("message digest attribute does not match")
+			}
+			digestFound = true
+		}
+	}
+	if !contentTypeFound || !digestFound {
+		return errors.New("missing content type or message digest attribute")
+	}
+
+	return nil
+}
+
+func verifyDigestSignature(publicKey interface{}, hash crypto.Hash, digest, signature []byte) error {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+			return errors.New("signature does not match")
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return errors.New("signature does not match")
+		}
+	default:
+		return fmt.Errorf("unsupported key type: %T", publicKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package apksig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// Signature algorithm IDs of the APK Signature Scheme v2+ blocks.
+const (
+	sigRSAPSSSHA256      = 0x0101
+	sigRSAPSSSHA512      = 0x0102
+	sigRSAPKCS1SHA256    = 0x0103
+	sigRSAPKCS1SHA512    = 0x0104
+	sigECDSASHA256       = 0x0201
+	sigECDSASHA512       = 0x0202
+	sigDSASHA256         = 0x0301
+	sigVerityRSAPKCS1    = 0x0421
+	sigVerityECDSASHA256 = 0x0423
+	sigVerityDSASHA256   = 0x0425
+)
+
+// signatureHash returns the digest algorithm of the signature algorithm.
+// DSA signatures are not supported, as the Go standard library cannot parse DSA certificates' keys reliably.
+func signatureHash(algorithm uint32) (crypto.Hash, bool) {
+	switch algorithm {
+	case sigRSAPSSSHA256, sigRSAPKCS1SHA256, sigECDSASHA256, sigVerityRSAPKCS1, sigVerityECDSASHA256:
+		return crypto.SHA256, true
+	case sigRSAPSSSHA512, sigRSAPKCS1SHA512, sigECDSASHA512:
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// isVerityAlgorithm reports whether the content digest of the algorithm is a verity (Merkle tree) root hash.
+func isVerityAlgorithm(algorithm uint32) bool {
+	return algorithm == sigVerityRSAPKCS1 || algorithm == sigVerityECDSASHA256 || algorithm == sigVerityDSASHA256
+}
+
+type signature struct {
+	algorithm uint32
+	value     []byte
+}
+
+type signer struct {
+	signedData   []byte
+	digests      map[uint32][]byte
+	certificates [][]byte
+	signatures   []signature
+	publicKey    []byte
+}
+
+// verifySchemeBlock verifies the signers of a v2, v3 or v3.1 block, and returns their certificates.
+func verifySchemeBlock(value []byte, v3 bool, digester *contentDigester) ([][]byte, error) {
+	block := buffer{data: value}
+	signerData, err := block.sequence()
+	if err != nil {
+		return nil, fmt.Errorf("malformed signers: %s", err)
+	}
+	if len(signerData) == 0 {
+		return nil, errors.New("no signers")
+	}
+
+	var certificates [][]byte
+	for _, data := range signerData {
+		s, err := parseSigner(data, v3)
+		if err != nil {
+			return nil, fmt.Errorf("malformed signer: %s", err)
+		}
+		if err := s.verify(digester); err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, s.certificates[0])
+	}
+
+	return certificates, nil
+}
+
+func parseSigner(data []byte, v3 bool) (signer, error) {
+	b := buffer{data: data}
+	signedData, err := b.lengthPrefixed()
+	if err != nil {
+		return signer{}, err
+	}
+	if v3 {
+		// minSdkVersion and maxSdkVersion
+		if _, err := b.uint32(); err != nil {
+			return signer{}, err
+		}
+		if _, err := b.uint32(); err != nil {
+			return signer{}, err
+		}
+	}
+	signatureData, err := b.sequence()
+	if err != nil {
+		return signer{}, err
+	}
+	publicKey, err := b.lengthPrefixed()
+	if err != nil {
+		return signer{}, err
+	}
+
+	s := signer{signedData: signedData, digests: map[uint32][]byte{}, publicKey: publicKey}
+	for _, data := range signatureData {
+		sb := buffer{data: data}
+		algorithm, err := sb.uint32()
+		if err != nil {
+			return signer{}, err
+		}
+		value, err := sb.lengthPrefixed()
+		if err != nil {
+			return signer{}, err
+		}
+		s.signatures = append(s.signatures, signature{algorithm: algorithm, value: value})
+	}
+
+	sd := buffer{data: signedData}
+	digestData, err := sd.sequence()
+	if err != nil {
+		return signer{}, err
+	}
+	for _, data := range digestData {
+		db := buffer{data: data}
+		algorithm, err := db.uint32()
+		if err != nil {
+			return signer{}, err
+		}
+		digest, err := db.lengthPrefixed()
+		if err != nil {
+			return signer{}, err
+		}
+		s.digests[algorithm] = digest
+	}
+	if s.certificates, err = sd.sequence(); err != nil {
+		return signer{}, err
+	}
+	if len(s.certificates) == 0 {
+		return signer{}, errors.New("no certificates")
+	}
+
+	return s, nil
+}
+
+func (s signer) verify(digester *contentDigester) error {
+	publicKey, err := x509.ParsePKIXPublicKey(s.publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %s", err)
+	}
+
+	certificate, err := x509.ParseCertificate(s.certificates[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %s", err)
+	}
+	if !bytes.Equal(certificate.RawSubjectPublicKeyInfo, s.publicKey) {
+		return errors.New("public key does not match the certificate")
+	}
+
+	verifiedContent := false
+	for _, sig := range s.signatures {
+		h, ok := signatureHash(sig.algorithm)
+		if !ok {
+			continue
+		}
+
+		if err := verifySignature(publicKey, sig.algorithm, h, s.signedData, sig.value); err != nil {
+			return fmt.Errorf("signature (algorithm 0x%04x) does not verify: %s", sig.algorithm, err)
+		}
+
+		expected, ok := s.digests[sig.algorithm]
+		if !ok {
+			return fmt.Errorf("no content digest for algorithm 0x%04x", sig.algorithm)
+		}
+		if isVerityAlgorithm(sig.algorithm) {
+			// The verity root hash is not recomputed, the content is verified with the other algorithms.
+			continue
+		}
+
+		actual, err := digester.digest(h)
+		if err != nil {
+			return fmt.Errorf("failed to compute content digest: %s", err)
+		}
+		if !bytes.Equal(expected, actual) {
+			return fmt.Errorf("content digest (algorithm 0x%04x) does not match", sig.algorithm)
+		}
+		verifiedContent = true
+	}
+
+	if !verifiedContent {
+		return errors.New("no supported signature algorithm")
+	}
+
+	return nil
+}
+
+func verifySignature(publicKey interface{}, algorithm uint32, h crypto.Hash, data, sig []byte) error {
+	hasher := h.New()
+	hasher.Write(data)
+	hashed := hasher.Sum(nil)
+
+	switch algorithm {
+	case sigRSAPSSSHA256, sigRSAPSSSHA512:
+		key, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("not an RSA key")
+		}
+		return rsa.VerifyPSS(key, h, hashed, sig, &rsa.PSSOptions{SaltLength: h.Size()})
+	case sigRSAPKCS1SHA256, sigRSAPKCS1SHA512, sigVerityRSAPKCS1:
+		key, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("not an RSA key")
+		}
+		return rsa.VerifyPKCS1v15(key, h, hashed, sig)
+	case sigECDSASHA256, sigECDSASHA512, sigVerityECDSASHA256:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("not an ECDSA key")
+		}
+		if !ecdsa.VerifyASN1(key, hashed, sig) {
+			return errors.New("verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signature algorithm: 0x%04x", algorithm)
+	}
+}
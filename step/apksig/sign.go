@@ -0,0 +1,261 @@
+package apksig
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+)
+
+const (
+	// minSDKWithoutV1 is the first Android version verifying the v2 scheme, older versions require the v1 signature.
+	minSDKWithoutV1 = 24
+	// v3MinSDK is the first Android version verifying the v3 scheme.
+	v3MinSDK = 28
+	v3MaxSDK = 0x7fffffff
+
+	// strippingProtectionAttributeID marks v2 signatures of APKs which are also signed with the v3 scheme.
+	strippingProtectionAttributeID = 0xbeeff00d
+)
+
+// Signer is the private key and the certificate chain used to sign APKs.
+type Signer struct {
+	PrivateKey   crypto.Signer
+	Certificates []*x509.Certificate
+}
+
+// Sign signs the APK with the v2 and v3 schemes, and with the v1 (JAR) scheme if the minSdkVersion of the app
+// is lower than 24. Existing signatures are replaced, the alignment of the uncompressed entries is kept.
+func Sign(inputPath, outputPath string, signer Signer) error {
+	if len(signer.Certificates) == 0 {
+		return errors.New("no signer certificate")
+	}
+
+	input, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open APK: %s", err)
+	}
+	defer func() { _ = input.Close() }()
+
+	minSDK := manifestMinSDK(&input.Reader)
+
+	unsigned, err := os.CreateTemp(filepath.Dir(outputPath), "unsigned-*.apk")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = unsigned.Close()
+		_ = os.Remove(unsigned.Name())
+	}()
+
+	var signatureEntries []entry
+	if minSDK < minSDKWithoutV1 {
+		if signatureEntries, err = jarSignatureEntries(&input.Reader, signer, minSDK); err != nil {
+			return fmt.Errorf("failed to create v1 signature: %s", err)
+		}
+	}
+	if err := writeAlignedZIP(unsigned, &input.Reader, signatureEntries); err != nil {
+		return fmt.Errorf("failed to write APK: %s", err)
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = output.Close() }()
+
+	if err := writeSchemeSignatures(output, unsigned, signer); err != nil {
+		_ = os.Remove(outputPath)
+		return fmt.Errorf("failed to create v2 and v3 signatures: %s", err)
+	}
+
+	return output.Close()
+}
+
+// manifestMinSDK returns the minSdkVersion of the app, 1 if it can not be determined.
+func manifestMinSDK(zipReader *zip.Reader) int {
+	for _, file := range zipReader.File {
+		if file.Name != "AndroidManifest.xml" {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return 1
+		}
+		manifest, err := axml.Decode(data)
+		if err != nil {
+			return 1
+		}
+		for _, usesSDK := range manifest.ChildrenByName("uses-sdk") {
+			if attribute, ok := usesSDK.Attr(axml.AndroidNamespace, "minSdkVersion"); ok {
+				if minSDK, ok := attribute.Int(); ok {
+					return minSDK
+				}
+			}
+		}
+		return 1
+	}
+
+	return 1
+}
+
+// writeSchemeSignatures writes the APK with an APK Signing Block holding the v2 and v3 signatures.
+func writeSchemeSignatures(w io.Writer, apk *os.File, signer Signer) error {
+	info, err := apk.Stat()
+	if err != nil {
+		return err
+	}
+
+	l, err := readLayout(apk, info.Size())
+	if err != nil {
+		return err
+	}
+	if l.block != nil {
+		return errors.New("unexpected APK Signing Block")
+	}
+
+	digest, err := newContentDigester(apk, l).digest(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+
+	v2, err := schemeBlock(signer, digest, false)
+	if err != nil {
+		return err
+	}
+	v3, err := schemeBlock(signer, digest, true)
+	if err != nil {
+		return err
+	}
+	block := signingBlockBytes(map[uint32][]byte{blockIDV2: v2, blockIDV3: v3}, []uint32{blockIDV2, blockIDV3})
+
+	if _, err := io.Copy(w, io.NewSectionReader(apk, 0, l.cdOffset)); err != nil {
+		return err
+	}
+	if _, err := w.Write(block); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(apk, l.cdOffset, l.cdSize)); err != nil {
+		return err
+	}
+	_, err = w.Write(eocdWithCDOffset(l.eocd, l.cdOffset+int64(len(block))))
+
+	return err
+}
+
+// signatureAlgorithm returns the v2+ signature algorithm ID of the key.
+func signatureAlgorithm(key crypto.Signer) (uint32, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		return sigRSAPKCS1SHA256, nil
+	case *ecdsa.PublicKey:
+		return sigECDSASHA256, nil
+	default:
+		return 0, fmt.Errorf("unsupported key type: %T", key.Public())
+	}
+}
+
+// schemeBlock returns the v2 or v3 scheme block with a single signer.
+func schemeBlock(signer Signer, digest []byte, v3 bool) ([]byte, error) {
+	algorithm, err := signatureAlgorithm(signer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.PrivateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates [][]byte
+	for _, certificate := range signer.Certificates {
+		certificates = append(certificates, lengthPrefixed(certificate.Raw))
+	}
+
+	var attributes []byte
+	if !v3 {
+		attributes = lengthPrefixed(lengthPrefixed(uint32Bytes(strippingProtectionAttributeID), uint32Bytes(3)))
+	} else {
+		attributes = lengthPrefixed()
+	}
+
+	signedData := lengthPrefixed(lengthPrefixed(uint32Bytes(algorithm), lengthPrefixed(digest)))
+	signedData = append(signedData, lengthPrefixed(certificates...)...)
+	if v3 {
+		signedData = append(signedData, uint32Bytes(v3MinSDK)...)
+		signedData = append(signedData, uint32Bytes(v3MaxSDK)...)
+	}
+	signedData = append(signedData, attributes...)
+
+	hashed := crypto.SHA256.New()
+	hashed.Write(signedData)
+	signature, err := signer.PrivateKey.Sign(rand.Reader, hashed.Sum(nil), crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	s := lengthPrefixed(signedData)
+	if v3 {
+		s = append(s, uint32Bytes(v3MinSDK)...)
+		s = append(s, uint32Bytes(v3MaxSDK)...)
+	}
+	s = append(s, lengthPrefixed(lengthPrefixed(uint32Bytes(algorithm), lengthPrefixed(signature)))...)
+	s = append(s, lengthPrefixed(publicKey)...)
+
+	return lengthPrefixed(lengthPrefixed(s)), nil
+}
+
+// signingBlockBytes encodes the APK Signing Block with the given ID-value pairs.
+func signingBlockBytes(pairs map[uint32][]byte, order []uint32) []byte {
+	var content []byte
+	for _, id := range order {
+		value := pairs[id]
+		content = append(content, uint64Bytes(uint64(len(value)+4))...)
+		content = append(content, uint32Bytes(id)...)
+		content = append(content, value...)
+	}
+
+	size := uint64Bytes(uint64(len(content) + blockFooterSize))
+	block := append(append([]byte{}, size...), content...)
+	block = append(block, size...)
+
+	return append(block, blockMagic...)
+}
+
+func isSignatureEntry(name string) bool {
+	if name == manifestPath {
+		return true
+	}
+	if path := strings.ToUpper(name); strings.HasPrefix(name, metaInfDir) && !strings.Contains(name[len(metaInfDir):], "/") {
+		for _, ext := range append([]string{signatureFileExt}, signatureBlockExts...) {
+			if strings.HasSuffix(path, ext) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func uint32Bytes(v uint32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+}
+
+func uint64Bytes(v uint64) []byte {
+	return append(uint32Bytes(uint32(v)), uint32Bytes(uint32(v>>32))...)
+}
+
+func lengthPrefixed(items ...[]byte) []byte {
+	content := bytes.Join(items, nil)
+	return append(uint32Bytes(uint32(len(content))), content...)
+}
@@ -0,0 +1,160 @@
+package apksig
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		signer  Signer
+		minSDK  int
+		schemes []Scheme
+	}{
+		{name: "RSA, minSdk 16", signer: newSigner(t, rsaKey), minSDK: 16, schemes: []Scheme{SchemeV1, SchemeV2, SchemeV3}},
+		{name: "RSA, minSdk 21", signer: newSigner(t, rsaKey), minSDK: 21, schemes: []Scheme{SchemeV1, SchemeV2, SchemeV3}},
+		{name: "RSA, minSdk 24", signer: newSigner(t, rsaKey), minSDK: 24, schemes: []Scheme{SchemeV2, SchemeV3}},
+		{name: "EC, minSdk 21", signer: newSigner(t, ecKey), minSDK: 21, schemes: []Scheme{SchemeV1, SchemeV2, SchemeV3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := writeAPK(t, ziptest.Zip(t, appEntries(tt.minSDK)))
+			output := filepath.Join(t.TempDir(), "app-signed.apk")
+
+			err := Sign(input, output, tt.signer)
+			if err != nil {
+				t.Fatalf("failed to sign: %s", err)
+			}
+
+			report, err := Verify(output)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.schemes, report.Schemes)
+			assert.Equal(t, []string{fingerprint(tt.signer.Certificates[0].Raw)}, report.CertSHA256)
+			assertAligned(t, output)
+		})
+	}
+}
+
+func TestSign_ReplacesSignature(t *testing.T) {
+	oldKey, oldCert := newTestSigner(t)
+	apk := addSchemeBlocks(t, ziptest.Zip(t, append(appEntries(21), jarSignature(t, appEntries(21), oldKey, oldCert)...)), oldKey, oldCert, blockIDV2)
+	input := writeAPK(t, apk)
+	output := filepath.Join(t.TempDir(), "app-signed.apk")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	signer := newSigner(t, key)
+
+	err = Sign(input, output, signer)
+
+	assert.NoError(t, err)
+	report, err := Verify(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []Scheme{SchemeV1, SchemeV2, SchemeV3}, report.Schemes)
+	assert.Equal(t, []string{fingerprint(signer.Certificates[0].Raw)}, report.CertSHA256)
+}
+
+func Test_manifestAttribute(t *testing.T) {
+	name := "res/" + string(bytes.Repeat([]byte("a"), 80)) + ".xml"
+
+	attribute := manifestAttribute("Name", name)
+
+	assert.Equal(t, "Name: res/"+string(bytes.Repeat([]byte("a"), 62))+"\r\n "+string(bytes.Repeat([]byte("a"), 18))+".xml\r\n", attribute)
+	assert.Equal(t, []manifestSection{{"Name": name}}, parseManifest([]byte(attribute)))
+}
+
+func Test_alignedExtra(t *testing.T) {
+	extra := alignedExtra(nil, 101, 4)
+	assert.Equal(t, 0, (101+len(extra))%4)
+	assert.True(t, len(extra) >= alignmentExtraMinLen)
+
+	extra = alignedExtra(extra, 101, 16384)
+	assert.Equal(t, 0, (101+len(extra))%16384)
+
+	assert.Empty(t, alignedExtra(nil, 100, 4))
+}
+
+func appEntries(minSDK int) []ziptest.Entry {
+	manifest := axmltest.Encode(&axml.Element{
+		Name: "manifest",
+		Children: []*axml.Element{{
+			Name: "uses-sdk",
+			Attributes: []axml.Attribute{
+				{Namespace: axml.AndroidNamespace, Name: "minSdkVersion", ResourceID: 0x0101020c, Type: axml.TypeIntDec, Data: uint32(minSDK)},
+			},
+		}},
+	})
+
+	return []ziptest.Entry{
+		{Name: "AndroidManifest.xml", Content: manifest},
+		{Name: "classes.dex", Content: bytes.Repeat([]byte("dex"), 1000)},
+		{Name: "resources.arsc", Content: []byte("arsc"), Stored: true},
+		{Name: "lib/arm64-v8a/libnative.so", Content: bytes.Repeat([]byte{0x7f}, 5000), Stored: true},
+		{Name: "res/raw/data.bin", Content: []byte("data"), Stored: true},
+	}
+}
+
+func newSigner(t *testing.T, key crypto.Signer) Signer {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Android Build Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	return Signer{PrivateKey: key, Certificates: []*x509.Certificate{certificate}}
+}
+
+func assertAligned(t *testing.T, pth string) {
+	r, err := zip.OpenReader(pth)
+	if err != nil {
+		t.Fatalf("failed to open APK: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, file := range r.File {
+		if file.Method != zip.Store {
+			continue
+		}
+		offset, err := file.DataOffset()
+		if err != nil {
+			t.Fatalf("failed to get data offset: %s", err)
+		}
+		assert.Equal(t, int64(0), offset%entryAlignmentOf(file.Name), file.Name)
+	}
+}
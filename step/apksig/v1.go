@@ -0,0 +1,221 @@
+package apksig
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	// Registers the SHA-1 digest algorithm of legacy JAR signatures.
+	_ "crypto/sha1"
+)
+
+const (
+	manifestPath     = "META-INF/MANIFEST.MF"
+	metaInfDir       = "META-INF/"
+	signatureFileExt = ".SF"
+)
+
+// signatureBlockExts are the extensions of the PKCS#7 signature block files of the JAR signature.
+var signatureBlockExts = []string{".RSA", ".EC", ".DSA"}
+
+// manifestSection is a section of a JAR manifest or signature file: attribute names mapped to values.
+type manifestSection map[string]string
+
+// verifyJARSignature verifies the v1 (JAR) signature of the APK and returns the signer certificates.
+// No certificates and no error are returned if the APK has no JAR signature.
+func verifyJARSignature(zipReader *zip.Reader) ([][]byte, error) {
+	files := map[string]*zip.File{}
+	for _, file := range zipReader.File {
+		files[file.Name] = file
+	}
+
+	var certificates [][]byte
+	var signatureFiles []string
+	for name := range files {
+		if path.Dir(name)+"/" == metaInfDir && strings.HasSuffix(strings.ToUpper(name), signatureFileExt) {
+			signatureFiles = append(signatureFiles, name)
+		}
+	}
+	if len(signatureFiles) == 0 {
+		return nil, nil
+	}
+
+	manifestFile, ok := files[manifestPath]
+	if !ok {
+		return nil, errors.New("signature file without " + manifestPath)
+	}
+	manifest, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyManifestEntries(manifest, files); err != nil {
+		return nil, err
+	}
+
+	for _, name := range signatureFiles {
+		signatureFile, err := readZipFile(files[name])
+		if err != nil {
+			return nil, err
+		}
+		if err := verifySignatureFile(signatureFile, manifest); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+
+		block, err := findSignatureBlock(strings.TrimSuffix(name, path.Ext(name)), files)
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := pkcs7VerifySignature(block, signatureFile)
+		if err != nil {
+			return nil, fmt.Errorf("signature block of %s: %s", name, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	return certificates, nil
+}
+
+func findSignatureBlock(base string, files map[string]*zip.File) ([]byte, error) {
+	for _, ext := range signatureBlockExts {
+		if file, ok := files[base+ext]; ok {
+			return readZipFile(file)
+		}
+	}
+
+	return nil, fmt.Errorf("no signature block file for %s", base+signatureFileExt)
+}
+
+// verifyManifestEntries checks that every APK entry is listed in the manifest with a matching digest.
+func verifyManifestEntries(manifest []byte, files map[string]*zip.File) error {
+	entries := map[string]manifestSection{}
+	for _, section := range parseManifest(manifest)[1:] {
+		if name, ok := section["Name"]; ok {
+			entries[name] = section
+		}
+	}
+
+	for name, file := range files {
+		if strings.HasPrefix(name, metaInfDir) || strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		section, ok := entries[name]
+		if !ok {
+			return fmt.Errorf("%s is not listed in the manifest", name)
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		if err := verifyDigestAttributes(section, "-Digest", content); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// verifySignatureFile checks the manifest digest of the signature file.
+func verifySignatureFile(signatureFile, manifest []byte) error {
+	sections := parseManifest(signatureFile)
+	return verifyDigestAttributes(sections[0], "-Digest-Manifest", manifest)
+}
+
+// verifyDigestAttributes verifies the digest attributes (for example SHA-256-Digest) of the section.
+func verifyDigestAttributes(section manifestSection, suffix string, content []byte) error {
+	verified := false
+	for attribute, value := range section {
+		if !strings.HasSuffix(attribute, suffix) {
+			continue
+		}
+		h, ok := jarDigestHash(strings.TrimSuffix(attribute, suffix))
+		if !ok {
+			continue
+		}
+
+		expected, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("malformed %s attribute", attribute)
+		}
+		hasher := h.New()
+		hasher.Write(content)
+		if !bytes.Equal(expected, hasher.Sum(nil)) {
+			return fmt.Errorf("%s does not match", attribute)
+		}
+		verified = true
+	}
+
+	if !verified {
+		return fmt.Errorf("no supported *%s attribute", suffix)
+	}
+
+	return nil
+}
+
+func jarDigestHash(name string) (crypto.Hash, bool) {
+	switch strings.ToUpper(name) {
+	case "SHA1", "SHA-1":
+		return crypto.SHA1, true
+	case "SHA-256":
+		return crypto.SHA256, true
+	case "SHA-384":
+		return crypto.SHA384, true
+	case "SHA-512":
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// parseManifest parses the sections of a JAR manifest or signature file, the first one is the main section.
+func parseManifest(data []byte) []manifestSection {
+	sections := []manifestSection{{}}
+	current := sections[0]
+	lastAttribute := ""
+	sectionStarted := false
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		switch {
+		case line == "":
+			if sectionStarted {
+				current = manifestSection{}
+				sections = append(sections, current)
+				sectionStarted = false
+			}
+		case strings.HasPrefix(line, " "):
+			current[lastAttribute] += line[1:]
+		default:
+			separator := strings.Index(line, ": ")
+			if separator < 0 {
+				continue
+			}
+			lastAttribute = line[:separator]
+			current[lastAttribute] = line[separator+2:]
+			sectionStarted = true
+		}
+	}
+
+	if len(sections) > 1 && len(sections[len(sections)-1]) == 0 {
+		sections = sections[:len(sections)-1]
+	}
+
+	return sections
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,330 @@
+// Package axml decodes Android binary XML, the compiled format of the AndroidManifest.xml in APKs.
+package axml
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+)
+
+// AndroidNamespace is the namespace of the android: attributes.
+const AndroidNamespace = "http://schemas.android.com/apk/res/android"
+
+// Chunk types.
+const (
+	chunkStringPool   = 0x0001
+	chunkXML          = 0x0003
+	chunkStartElement = 0x0102
+	chunkEndElement   = 0x0103
+	chunkResourceMap  = 0x0180
+
+	stringPoolUTF8Flag = 1 << 8
+	noEntry            = 0xffffffff
+	attributeSize      = 20
+)
+
+// Types of the attribute values.
+const (
+	TypeNull      = 0x00
+	TypeReference = 0x01
+	TypeAttribute = 0x02
+	TypeString    = 0x03
+	TypeFloat     = 0x04
+	TypeIntDec    = 0x10
+	TypeIntHex    = 0x11
+	TypeBoolean   = 0x12
+)
+
+// Element is an XML element.
+type Element struct {
+	Namespace  string
+	Name       string
+	Attributes []Attribute
+	Children   []*Element
+}
+
+// Attribute is an XML attribute with its typed value.
+type Attribute struct {
+	Namespace string
+	Name      string
+	// ResourceID is the ID of the attribute resource (for example 0x0101020c for android:minSdkVersion), 0 if unknown.
+	ResourceID uint32
+	// Value is the string value, or the formatted typed value.
+	Value string
+	Type  uint8
+	Data  uint32
+}
+
+// Attr returns the attribute with the given namespace and name.
+func (e *Element) Attr(namespace, name string) (Attribute, bool) {
+	for _, attribute := range e.Attributes {
+		if attribute.Namespace == namespace && attribute.Name == name {
+			return attribute, true
+		}
+	}
+
+	return Attribute{}, false
+}
+
+// AndroidAttr returns the value of the android: attribute, empty if the attribute is not set.
+func (e *Element) AndroidAttr(name string) string {
+	attribute, _ := e.Attr(AndroidNamespace, name)
+	return attribute.Value
+}
+
+// ChildrenByName returns the child elements with the given name.
+func (e *Element) ChildrenByName(name string) []*Element {
+	var children []*Element
+	for _, child := range e.Children {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// Int returns the integer value of the attribute.
+func (a Attribute) Int() (int, bool) {
+	switch a.Type {
+	case TypeIntDec, TypeIntHex:
+		return int(int32(a.Data)), true
+	case TypeString:
+		i, err := strconv.Atoi(a.Value)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Decode decodes the binary XML document and returns its root element.
+func Decode(data []byte) (*Element, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint16(data) != chunkXML {
+		return nil, errors.New("not a binary XML document")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(data[2:]))
+	size := int(binary.LittleEndian.Uint32(data[4:]))
+	if size > len(data) || headerSize > size {
+		return nil, errors.New("truncated binary XML document")
+	}
+
+	var strings []string
+	var resourceIDs []uint32
+	var root *Element
+	var stack []*Element
+
+	for offset := headerSize; offset+8 <= size; {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		chunkHeaderSize := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if chunkSize < 8 || offset+chunkSize > size {
+			return nil, fmt.Errorf("invalid chunk size at offset %d", offset)
+		}
+		chunk := data[offset : offset+chunkSize]
+
+		switch chunkType {
+		case chunkStringPool:
+			pool, err := decodeStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			strings = pool
+		case chunkResourceMap:
+			for i := chunkHeaderSize; i+4 <= chunkSize; i += 4 {
+				resourceIDs = append(resourceIDs, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case chunkStartElement:
+			element, err := decodeStartElement(chunk, chunkHeaderSize, strings, resourceIDs)
+			if err != nil {
+				return nil, err
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errors.New("multiple root elements")
+				}
+				root = element
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, element)
+			}
+			stack = append(stack, element)
+		case chunkEndElement:
+			if len(stack) == 0 {
+				return nil, errors.New("unbalanced end element")
+			}
+			stack = stack[:len(stack)-1]
+		}
+
+		offset += chunkSize
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+
+	return root, nil
+}
+
+func decodeStartElement(chunk []byte, headerSize int, strings []string, resourceIDs []uint32) (*Element, error) {
+	if len(chunk) < headerSize+20 {
+		return nil, errors.New("truncated start element")
+	}
+	ext := chunk[headerSize:]
+	lookup := func(index uint32) string {
+		if index == noEntry || int(index) >= len(strings) {
+			return ""
+		}
+		return strings[index]
+	}
+
+	element := &Element{
+		Namespace: lookup(binary.LittleEndian.Uint32(ext)),
+		Name:      lookup(binary.LittleEndian.Uint32(ext[4:])),
+	}
+
+	attributeStart := int(binary.LittleEndian.Uint16(ext[8:]))
+	attributeStride := int(binary.LittleEndian.Uint16(ext[10:]))
+	attributeCount := int(binary.LittleEndian.Uint16(ext[12:]))
+	if attributeStride < attributeSize {
+		attributeStride = attributeSize
+	}
+
+	for i := 0; i < attributeCount; i++ {
+		start := attributeStart + i*attributeStride
+		if start+attributeSize > len(ext) {
+			return nil, errors.New("truncated attribute")
+		}
+		raw := ext[start : start+attributeSize]
+
+		nameIndex := binary.LittleEndian.Uint32(raw[4:])
+		attribute := Attribute{
+			Namespace: lookup(binary.LittleEndian.Uint32(raw)),
+			Name:      lookup(nameIndex),
+			Type:      raw[15],
+			Data:      binary.LittleEndian.Uint32(raw[16:]),
+		}
+		if int(nameIndex) < len(resourceIDs) {
+			attribute.ResourceID = resourceIDs[nameIndex]
+		}
+
+		if rawValue := binary.LittleEndian.Uint32(raw[8:]); rawValue != noEntry {
+			attribute.Value = lookup(rawValue)
+		} else {
+			attribute.Value = formatValue(attribute.Type, attribute.Data, lookup)
+		}
+
+		element.Attributes = append(element.Attributes, attribute)
+	}
+
+	return element, nil
+}
+
+func formatValue(valueType uint8, data uint32, lookup func(uint32) string) string {
+	switch valueType {
+	case TypeString:
+		return lookup(data)
+	case TypeIntDec:
+		return strconv.Itoa(int(int32(data)))
+	case TypeIntHex:
+		return fmt.Sprintf("0x%08x", data)
+	case TypeBoolean:
+		return strconv.FormatBool(data != 0)
+	case TypeReference:
+		return fmt.Sprintf("@0x%08x", data)
+	case TypeAttribute:
+		return fmt.Sprintf("?0x%08x", data)
+	case TypeNull:
+		return ""
+	default:
+		return fmt.Sprintf("0x%08x", data)
+	}
+}
+
+func decodeStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errors.New("truncated string pool")
+	}
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if headerSize+count*4 > len(chunk) || stringsStart > len(chunk) {
+		return nil, errors.New("truncated string pool")
+	}
+
+	strings := make([]string, count)
+	for i := range strings {
+		offset := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		if offset >= len(chunk) {
+			return nil, errors.New("invalid string offset")
+		}
+
+		var s string
+		var err error
+		if flags&stringPoolUTF8Flag != 0 {
+			s, err = decodeUTF8String(chunk[offset:])
+		} else {
+			s, err = decodeUTF16String(chunk[offset:])
+		}
+		if err != nil {
+			return nil, err
+		}
+		strings[i] = s
+	}
+
+	return strings, nil
+}
+
+func decodeUTF8String(b []byte) (string, error) {
+	// The UTF-16 length is followed by the UTF-8 length, both are 1 or 2 bytes long.
+	_, n := decodeLength8(b)
+	if n == 0 {
+		return "", errors.New("truncated string")
+	}
+	length, m := decodeLength8(b[n:])
+	if m == 0 || n+m+length > len(b) {
+		return "", errors.New("truncated string")
+	}
+
+	return string(b[n+m : n+m+length]), nil
+}
+
+func decodeLength8(b []byte) (int, int) {
+	if len(b) < 1 {
+		return 0, 0
+	}
+	if b[0]&0x80 == 0 {
+		return int(b[0]), 1
+	}
+	if len(b) < 2 {
+		return 0, 0
+	}
+	return int(b[0]&0x7f)<<8 | int(b[1]), 2
+}
+
+func decodeUTF16String(b []byte) (string, error) {
+	if len(b) < 2 {
+		return "", errors.New("truncated string")
+	}
+	length := int(binary.LittleEndian.Uint16(b))
+	start := 2
+	if length&0x8000 != 0 {
+		if len(b) < 4 {
+			return "", errors.New("truncated string")
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(b[2:]))
+		start = 4
+	}
+	if start+length*2 > len(b) {
+		return "", errors.New("truncated string")
+	}
+
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[start+i*2:])
+	}
+
+	return string(utf16.Decode(units)), nil
+}
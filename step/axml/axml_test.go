@@ -0,0 +1,67 @@
+package axml_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	manifest := &axml.Element{
+		Name: "manifest",
+		Attributes: []axml.Attribute{
+			{Name: "package", Type: axml.TypeString, Value: "io.bitrise.sample"},
+			{Namespace: axml.AndroidNamespace, Name: "versionCode", ResourceID: 0x0101021b, Type: axml.TypeIntDec, Data: 42},
+		},
+		Children: []*axml.Element{
+			{
+				Name: "uses-sdk",
+				Attributes: []axml.Attribute{
+					{Namespace: axml.AndroidNamespace, Name: "minSdkVersion", ResourceID: 0x0101020c, Type: axml.TypeIntDec, Data: 21},
+				},
+			},
+			{
+				Name: "application",
+				Attributes: []axml.Attribute{
+					{Namespace: axml.AndroidNamespace, Name: "debuggable", ResourceID: 0x0101000f, Type: axml.TypeBoolean, Data: 0xffffffff},
+					{Namespace: axml.AndroidNamespace, Name: "icon", ResourceID: 0x01010002, Type: axml.TypeReference, Data: 0x7f0d0000},
+				},
+			},
+		},
+	}
+
+	root, err := axml.Decode(axmltest.Encode(manifest))
+	if err != nil {
+		t.Fatalf("failed to decode: %s", err)
+	}
+
+	assert.Equal(t, "manifest", root.Name)
+	packageName, ok := root.Attr("", "package")
+	assert.True(t, ok)
+	assert.Equal(t, "io.bitrise.sample", packageName.Value)
+	assert.Equal(t, "42", root.AndroidAttr("versionCode"))
+
+	usesSDK := root.ChildrenByName("uses-sdk")
+	if len(usesSDK) != 1 {
+		t.Fatalf("uses-sdk element not found")
+	}
+	minSDK, ok := usesSDK[0].Attr(axml.AndroidNamespace, "minSdkVersion")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(0x0101020c), minSDK.ResourceID)
+	value, ok := minSDK.Int()
+	assert.True(t, ok)
+	assert.Equal(t, 21, value)
+
+	application := root.ChildrenByName("application")[0]
+	assert.Equal(t, "true", application.AndroidAttr("debuggable"))
+	assert.Equal(t, "@0x7f0d0000", application.AndroidAttr("icon"))
+	assert.Equal(t, "", application.AndroidAttr("allowBackup"))
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := axml.Decode([]byte("<manifest/>"))
+
+	assert.Error(t, err)
+}
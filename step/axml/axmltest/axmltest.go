@@ -0,0 +1,149 @@
+// Package axmltest encodes Android binary XML documents for tests.
+package axmltest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+)
+
+const noEntry = 0xffffffff
+
+// Encode encodes the element tree as a binary XML document. String attributes are encoded with their Value,
+// other attributes with their Type and Data.
+func Encode(root *axml.Element) []byte {
+	e := encoder{indices: map[string]uint32{}}
+	e.collectAttributeNames(root)
+	e.collectStrings(root)
+
+	var body bytes.Buffer
+	body.Write(e.stringPool())
+	body.Write(e.resourceMap())
+	e.writeElement(&body, root)
+
+	var doc bytes.Buffer
+	writeChunkHeader(&doc, 0x0003, 8, 8+body.Len())
+	doc.Write(body.Bytes())
+
+	return doc.Bytes()
+}
+
+type encoder struct {
+	strings     []string
+	indices     map[string]uint32
+	resourceIDs []uint32
+}
+
+func (e *encoder) add(s string) {
+	if _, ok := e.indices[s]; ok {
+		return
+	}
+	e.indices[s] = uint32(len(e.strings))
+	e.strings = append(e.strings, s)
+}
+
+// collectAttributeNames adds the attribute names with resource IDs first, as the resource map is indexed by string index.
+func (e *encoder) collectAttributeNames(element *axml.Element) {
+	for _, attribute := range element.Attributes {
+		if attribute.ResourceID != 0 {
+			if _, ok := e.indices[attribute.Name]; !ok {
+				e.add(attribute.Name)
+				e.resourceIDs = append(e.resourceIDs, attribute.ResourceID)
+			}
+		}
+	}
+	for _, child := range element.Children {
+		e.collectAttributeNames(child)
+	}
+}
+
+func (e *encoder) collectStrings(element *axml.Element) {
+	e.add(element.Namespace)
+	e.add(element.Name)
+	for _, attribute := range element.Attributes {
+		e.add(attribute.Namespace)
+		e.add(attribute.Name)
+		if attribute.Type == axml.TypeString {
+			e.add(attribute.Value)
+		}
+	}
+	for _, child := range element.Children {
+		e.collectStrings(child)
+	}
+}
+
+func (e *encoder) index(s string) uint32 {
+	if s == "" {
+		return noEntry
+	}
+	return e.indices[s]
+}
+
+func (e *encoder) stringPool() []byte {
+	var data bytes.Buffer
+	var offsets []uint32
+	for _, s := range e.strings {
+		offsets = append(offsets, uint32(data.Len()))
+		units := utf16.Encode([]rune(s))
+		writeLE(&data, uint16(len(units)), units, uint16(0))
+	}
+	for data.Len()%4 != 0 {
+		data.WriteByte(0)
+	}
+
+	const headerSize = 28
+	stringsStart := headerSize + 4*len(e.strings)
+
+	var chunk bytes.Buffer
+	writeChunkHeader(&chunk, 0x0001, headerSize, stringsStart+data.Len())
+	writeLE(&chunk, uint32(len(e.strings)), uint32(0), uint32(0), uint32(stringsStart), uint32(0), offsets)
+	chunk.Write(data.Bytes())
+
+	return chunk.Bytes()
+}
+
+func (e *encoder) resourceMap() []byte {
+	var chunk bytes.Buffer
+	writeChunkHeader(&chunk, 0x0180, 8, 8+4*len(e.resourceIDs))
+	writeLE(&chunk, e.resourceIDs)
+
+	return chunk.Bytes()
+}
+
+func (e *encoder) writeElement(w *bytes.Buffer, element *axml.Element) {
+	const nodeHeaderSize = 16
+
+	var ext bytes.Buffer
+	writeLE(&ext, e.index(element.Namespace), e.index(element.Name), uint16(20), uint16(20), uint16(len(element.Attributes)), uint16(0), uint16(0), uint16(0))
+	for _, attribute := range element.Attributes {
+		rawValue, data := uint32(noEntry), attribute.Data
+		if attribute.Type == axml.TypeString {
+			rawValue = e.index(attribute.Value)
+			data = rawValue
+		}
+		writeLE(&ext, e.index(attribute.Namespace), e.index(attribute.Name), rawValue, uint16(8), uint8(0), attribute.Type, data)
+	}
+
+	writeChunkHeader(w, 0x0102, nodeHeaderSize, nodeHeaderSize+ext.Len())
+	writeLE(w, uint32(1), uint32(noEntry))
+	w.Write(ext.Bytes())
+
+	for _, child := range element.Children {
+		e.writeElement(w, child)
+	}
+
+	writeChunkHeader(w, 0x0103, nodeHeaderSize, nodeHeaderSize+8)
+	writeLE(w, uint32(1), uint32(noEntry), e.index(element.Namespace), e.index(element.Name))
+}
+
+func writeChunkHeader(w *bytes.Buffer, chunkType uint16, headerSize, size int) {
+	writeLE(w, chunkType, uint16(headerSize), uint32(size))
+}
+
+func writeLE(w *bytes.Buffer, values ...interface{}) {
+	for _, v := range values {
+		_ = binary.Write(w, binary.LittleEndian, v)
+	}
+}
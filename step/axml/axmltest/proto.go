@@ -0,0 +1,47 @@
+package axmltest
+
+import (
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/protobuf"
+)
+
+// EncodeProto encodes the element tree as a proto XML document (aapt2 XmlNode). String attributes are encoded
+// with their Value, integer and boolean attributes as compiled primitives.
+func EncodeProto(root *axml.Element) []byte {
+	var element []byte
+	if root.Namespace != "" {
+		element = protobuf.AppendBytes(element, 2, []byte(root.Namespace))
+	}
+	element = protobuf.AppendBytes(element, 3, []byte(root.Name))
+
+	for _, attribute := range root.Attributes {
+		var encoded []byte
+		if attribute.Namespace != "" {
+			encoded = protobuf.AppendBytes(encoded, 1, []byte(attribute.Namespace))
+		}
+		encoded = protobuf.AppendBytes(encoded, 2, []byte(attribute.Name))
+		if attribute.ResourceID != 0 {
+			encoded = protobuf.AppendVarint(encoded, 5, uint64(attribute.ResourceID))
+		}
+
+		switch attribute.Type {
+		case axml.TypeIntDec, axml.TypeBoolean:
+			field := 6
+			if attribute.Type == axml.TypeBoolean {
+				field = 8
+			}
+			primitive := protobuf.AppendVarint(nil, field, uint64(attribute.Data))
+			encoded = protobuf.AppendBytes(encoded, 6, protobuf.AppendBytes(nil, 7, primitive))
+		default:
+			encoded = protobuf.AppendBytes(encoded, 3, []byte(attribute.Value))
+		}
+
+		element = protobuf.AppendBytes(element, 4, encoded)
+	}
+
+	for _, child := range root.Children {
+		element = protobuf.AppendBytes(element, 5, EncodeProto(child))
+	}
+
+	return protobuf.AppendBytes(nil, 1, element)
+}
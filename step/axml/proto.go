@@ -0,0 +1,123 @@
+package axml
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/protobuf"
+)
+
+// Field numbers of the aapt2 XmlNode, XmlElement, XmlAttribute, Item and Primitive messages (Resources.proto).
+const (
+	xmlNodeElement = 1
+
+	xmlElementNamespaceURI = 2
+	xmlElementName         = 3
+	xmlElementAttribute    = 4
+	xmlElementChild        = 5
+
+	xmlAttributeNamespaceURI = 1
+	xmlAttributeName         = 2
+	xmlAttributeValue        = 3
+	xmlAttributeResourceID   = 5
+	xmlAttributeCompiledItem = 6
+
+	itemPrimitive = 7
+
+	primitiveIntDecimal = 6
+	primitiveBoolean    = 8
+)
+
+// DecodeProto decodes the proto XML document of Android App Bundles (an aapt2 XmlNode message) and returns its root element.
+// The attribute values are the string values of the source XML.
+func DecodeProto(data []byte) (*Element, error) {
+	element, err := decodeXMLNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if element == nil {
+		return nil, errors.New("no root element")
+	}
+	return element, nil
+}
+
+// decodeXMLNode decodes an XmlNode message, text nodes are returned as nil.
+func decodeXMLNode(data []byte) (*Element, error) {
+	fields, err := protobuf.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := protobuf.Messages(fields, xmlNodeElement)
+	if err != nil || len(elements) == 0 {
+		return nil, err
+	}
+	fields = elements[0]
+
+	element := &Element{
+		Namespace: protobuf.String(fields, xmlElementNamespaceURI),
+		Name:      protobuf.String(fields, xmlElementName),
+	}
+
+	attributes, err := protobuf.Messages(fields, xmlElementAttribute)
+	if err != nil {
+		return nil, err
+	}
+	for _, attribute := range attributes {
+		decoded, err := decodeXMLAttribute(attribute)
+		if err != nil {
+			return nil, err
+		}
+		element.Attributes = append(element.Attributes, decoded)
+	}
+
+	for _, field := range fields {
+		if field.Number != xmlElementChild {
+			continue
+		}
+		child, err := decodeXMLNode(field.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			element.Children = append(element.Children, child)
+		}
+	}
+
+	return element, nil
+}
+
+func decodeXMLAttribute(fields []protobuf.Field) (Attribute, error) {
+	attribute := Attribute{
+		Namespace:  protobuf.String(fields, xmlAttributeNamespaceURI),
+		Name:       protobuf.String(fields, xmlAttributeName),
+		ResourceID: uint32(protobuf.Varint(fields, xmlAttributeResourceID)),
+		Value:      protobuf.String(fields, xmlAttributeValue),
+		Type:       TypeString,
+	}
+	if attribute.Value != "" {
+		return attribute, nil
+	}
+
+	// Compiled values without their source string.
+	items, err := protobuf.Messages(fields, xmlAttributeCompiledItem)
+	if err != nil || len(items) == 0 {
+		return attribute, err
+	}
+	primitives, err := protobuf.Messages(items[0], itemPrimitive)
+	if err != nil || len(primitives) == 0 {
+		return attribute, err
+	}
+	for _, field := range primitives[0] {
+		switch field.Number {
+		case primitiveIntDecimal:
+			attribute.Type, attribute.Data = TypeIntDec, uint32(field.Varint)
+			attribute.Value = strconv.Itoa(int(int32(field.Varint)))
+		case primitiveBoolean:
+			attribute.Type, attribute.Data = TypeBoolean, uint32(field.Varint)
+			attribute.Value = strconv.FormatBool(field.Varint != 0)
+		}
+	}
+
+	return attribute, nil
+}
@@ -0,0 +1,68 @@
+package axml_test
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeProto(t *testing.T) {
+	manifest := &axml.Element{
+		Name: "manifest",
+		Attributes: []axml.Attribute{
+			{Name: "package", Type: axml.TypeString, Value: "io.bitrise.sample"},
+			{Namespace: axml.AndroidNamespace, Name: "versionCode", ResourceID: 0x0101021b, Type: axml.TypeString, Value: "42"},
+		},
+		Children: []*axml.Element{
+			{
+				Name: "uses-sdk",
+				Attributes: []axml.Attribute{
+					{Namespace: axml.AndroidNamespace, Name: "minSdkVersion", ResourceID: 0x0101020c, Type: axml.TypeIntDec, Data: 21},
+				},
+			},
+			{
+				Name: "application",
+				Attributes: []axml.Attribute{
+					{Namespace: axml.AndroidNamespace, Name: "debuggable", Type: axml.TypeBoolean, Data: 1},
+					{Namespace: axml.AndroidNamespace, Name: "label", Type: axml.TypeString, Value: "@string/app_name"},
+				},
+			},
+		},
+	}
+
+	root, err := axml.DecodeProto(axmltest.EncodeProto(manifest))
+	if err != nil {
+		t.Fatalf("failed to decode: %s", err)
+	}
+
+	assert.Equal(t, "manifest", root.Name)
+	packageName, ok := root.Attr("", "package")
+	assert.True(t, ok)
+	assert.Equal(t, "io.bitrise.sample", packageName.Value)
+	versionCode, ok := root.Attr(axml.AndroidNamespace, "versionCode")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(0x0101021b), versionCode.ResourceID)
+	value, ok := versionCode.Int()
+	assert.True(t, ok)
+	assert.Equal(t, 42, value)
+
+	minSDK, ok := root.ChildrenByName("uses-sdk")[0].Attr(axml.AndroidNamespace, "minSdkVersion")
+	assert.True(t, ok)
+	value, ok = minSDK.Int()
+	assert.True(t, ok)
+	assert.Equal(t, 21, value)
+
+	application := root.ChildrenByName("application")[0]
+	assert.Equal(t, "true", application.AndroidAttr("debuggable"))
+	assert.Equal(t, "@string/app_name", application.AndroidAttr("label"))
+}
+
+func TestDecodeProto_Invalid(t *testing.T) {
+	_, err := axml.DecodeProto([]byte{0xff})
+	assert.Error(t, err)
+
+	_, err = axml.DecodeProto(nil)
+	assert.EqualError(t, err, "no root element")
+}
@@ -0,0 +1,27 @@
+package axml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_decodeStringPool_UTF8(t *testing.T) {
+	chunk := []byte{
+		0x01, 0x00, 0x1c, 0x00, 0x30, 0x00, 0x00, 0x00, // header: type, header size, size
+		0x02, 0x00, 0x00, 0x00, // string count
+		0x00, 0x00, 0x00, 0x00, // style count
+		0x00, 0x01, 0x00, 0x00, // flags: UTF-8
+		0x24, 0x00, 0x00, 0x00, // strings start
+		0x00, 0x00, 0x00, 0x00, // styles start
+		0x00, 0x00, 0x00, 0x00, // offset of string 0
+		0x06, 0x00, 0x00, 0x00, // offset of string 1
+		0x03, 0x03, 'a', 'p', 'p', 0x00, // "app"
+		0x02, 0x03, 0xc3, 0xa9, 'x', 0x00, // "éx": 2 UTF-16 units, 3 UTF-8 bytes
+	}
+
+	strings, err := decodeStringPool(chunk)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app", "éx"}, strings)
+}
@@ -0,0 +1,102 @@
+// Package buildscript finds and reads the Gradle build configuration files of a project
+// (build scripts, settings scripts and the version catalog) without running Gradle.
+package buildscript
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const maxDepth = 4
+
+var scriptNames = map[string]bool{
+	"build.gradle":        true,
+	"build.gradle.kts":    true,
+	"settings.gradle":     true,
+	"settings.gradle.kts": true,
+}
+
+var skippedDirs = map[string]bool{
+	"build":        true,
+	".gradle":      true,
+	".git":         true,
+	".idea":        true,
+	"node_modules": true,
+}
+
+var commentRegexp = regexp.MustCompile(`(?m)//.*$|(?s)/\*.*?\*/`)
+
+// Script is a Gradle build or settings script.
+type Script struct {
+	Path    string
+	Content string
+}
+
+// IsSettings reports whether the script is a settings script.
+func (s Script) IsSettings() bool {
+	base := filepath.Base(s.Path)
+	return base == "settings.gradle" || base == "settings.gradle.kts"
+}
+
+// Code returns the content of the script without comments.
+func (s Script) Code() string {
+	return commentRegexp.ReplaceAllString(s.Content, "")
+}
+
+// Find returns the build and settings scripts of the project, the root scripts first.
+// Build output, IDE and dependency directories are skipped.
+func Find(projectDir string) ([]Script, error) {
+	var scripts []Script
+	err := walk(projectDir, 0, func(pth string) error {
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+		scripts = append(scripts, Script{Path: pth, Content: string(content)})
+		return nil
+	})
+
+	return scripts, err
+}
+
+func walk(dir string, depth int, fn func(pth string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var subDirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if !skippedDirs[entry.Name()] && depth < maxDepth {
+				subDirs = append(subDirs, filepath.Join(dir, entry.Name()))
+			}
+			continue
+		}
+		if scriptNames[entry.Name()] {
+			if err := fn(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, subDir := range subDirs {
+		if err := walk(subDir, depth+1, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindFirst returns the first submatch of the regexp in the scripts, and the script it was found in.
+func FindFirst(scripts []Script, re *regexp.Regexp) (string, Script, bool) {
+	for _, script := range scripts {
+		if match := re.FindStringSubmatch(script.Code()); match != nil {
+			return match[1], script, true
+		}
+	}
+
+	return "", Script{}, false
+}
@@ -0,0 +1,91 @@
+package buildscript
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, pth, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(pth, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, "settings.gradle"), "include ':app'")
+	writeFile(t, filepath.Join(projectDir, "build.gradle.kts"), "")
+	writeFile(t, filepath.Join(projectDir, "app", "build.gradle"), "")
+	writeFile(t, filepath.Join(projectDir, "app", "build", "intermediates", "build.gradle"), "")
+	writeFile(t, filepath.Join(projectDir, "node_modules", "lib", "build.gradle"), "")
+
+	scripts, err := Find(projectDir)
+
+	assert.NoError(t, err)
+	var paths []string
+	for _, script := range scripts {
+		paths = append(paths, script.Path)
+	}
+	assert.Equal(t, []string{
+		filepath.Join(projectDir, "build.gradle.kts"),
+		filepath.Join(projectDir, "settings.gradle"),
+		filepath.Join(projectDir, "app", "build.gradle"),
+	}, paths)
+	assert.True(t, scripts[1].IsSettings())
+}
+
+func TestFindFirst_IgnoresComments(t *testing.T) {
+	scripts := []Script{
+		{Path: "a", Content: "// compileSdk 30\n/* compileSdk 31 */"},
+		{Path: "b", Content: "android {\n    compileSdk 34\n}"},
+	}
+
+	value, script, ok := FindFirst(scripts, regexp.MustCompile(`compileSdk\s+(\d+)`))
+
+	assert.True(t, ok)
+	assert.Equal(t, "34", value)
+	assert.Equal(t, "b", script.Path)
+}
+
+func TestReadCatalog(t *testing.T) {
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, CatalogRelPath), `
+[versions]
+agp = "8.2.0" # Android Gradle Plugin
+kotlin = "1.9.20"
+
+[libraries]
+agp-plain = "com.android.tools.build:gradle:8.1.0"
+agp-table = { group = "com.android.tools.build", name = "gradle", version.ref = "agp" }
+core-ktx = { module = "androidx.core:core-ktx", version = "1.12.0" }
+
+[plugins]
+android-application = { id = "com.android.application", version.ref = "agp" }
+kotlin-android = "org.jetbrains.kotlin.android:1.9.20"
+`)
+
+	catalog, err := ReadCatalog(projectDir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "8.2.0", catalog.Versions["agp"])
+	assert.Equal(t, "8.2.0", catalog.PluginVersion("com.android.application"))
+	assert.Equal(t, "1.9.20", catalog.PluginVersion("org.jetbrains.kotlin.android"))
+	assert.Equal(t, "1.12.0", catalog.LibraryVersion("androidx.core:core-ktx"))
+	assert.Equal(t, Dependency{ID: "com.android.tools.build:gradle", Version: "8.2.0"}, catalog.Libraries["agp-table"])
+	assert.Equal(t, Dependency{ID: "com.android.tools.build:gradle", Version: "8.1.0"}, catalog.Libraries["agp-plain"])
+}
+
+func TestReadCatalog_Missing(t *testing.T) {
+	catalog, err := ReadCatalog(t.TempDir())
+
+	assert.NoError(t, err)
+	assert.Empty(t, catalog.PluginVersion("com.android.application"))
+}
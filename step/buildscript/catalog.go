@@ -0,0 +1,157 @@
+package buildscript
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CatalogRelPath is the location of the default version catalog relative to the root project.
+const CatalogRelPath = "gradle/libs.versions.toml"
+
+// Catalog is a Gradle version catalog (libs.versions.toml). Only the parts needed to resolve plugin and
+// library versions are parsed.
+type Catalog struct {
+	Versions  map[string]string
+	Plugins   map[string]Dependency
+	Libraries map[string]Dependency
+}
+
+// Dependency is a plugin or library entry of the version catalog.
+// ID is the plugin id or the library's group:name module coordinate.
+type Dependency struct {
+	ID      string
+	Version string
+}
+
+var (
+	sectionRegexp = regexp.MustCompile(`^\[([A-Za-z]+)\]$`)
+	entryRegexp   = regexp.MustCompile(`^([A-Za-z0-9_.\-]+)\s*=\s*(.+)$`)
+	fieldRegexp   = regexp.MustCompile(`([A-Za-z.]+)\s*=\s*"([^"]*)"`)
+)
+
+// ReadCatalog reads the default version catalog of the project. A missing catalog is not an error.
+func ReadCatalog(projectDir string) (Catalog, error) {
+	catalog := Catalog{
+		Versions:  map[string]string{},
+		Plugins:   map[string]Dependency{},
+		Libraries: map[string]Dependency{},
+	}
+
+	f, err := os.Open(filepath.Join(projectDir, CatalogRelPath))
+	if os.IsNotExist(err) {
+		return catalog, nil
+	} else if err != nil {
+		return catalog, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	section := ""
+	plugins := map[string]string{}
+	libraries := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if match := sectionRegexp.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			continue
+		}
+
+		match := entryRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key, value := match[1], strings.TrimSpace(match[2])
+
+		switch section {
+		case "versions":
+			catalog.Versions[key] = unquote(value)
+		case "plugins":
+			plugins[key] = value
+		case "libraries":
+			libraries[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return catalog, err
+	}
+
+	for key, value := range plugins {
+		catalog.Plugins[key] = catalog.parseDependency(value, "id")
+	}
+	for key, value := range libraries {
+		catalog.Libraries[key] = catalog.parseDependency(value, "module")
+	}
+
+	return catalog, nil
+}
+
+// PluginVersion returns the version of the plugin with the given id.
+func (c Catalog) PluginVersion(id string) string {
+	for _, plugin := range c.Plugins {
+		if plugin.ID == id && plugin.Version != "" {
+			return plugin.Version
+		}
+	}
+
+	return ""
+}
+
+// LibraryVersion returns the version of the library with the given group:name coordinate.
+func (c Catalog) LibraryVersion(module string) string {
+	for _, library := range c.Libraries {
+		if library.ID == module && library.Version != "" {
+			return library.Version
+		}
+	}
+
+	return ""
+}
+
+// parseDependency parses either the "id:version" / "group:name:version" string notation
+// or the inline table notation: { id = "...", version.ref = "..." }.
+func (c Catalog) parseDependency(value, idField string) Dependency {
+	if !strings.HasPrefix(value, "{") {
+		notation := unquote(value)
+		idx := strings.LastIndex(notation, ":")
+		if idx == -1 || (idField == "module" && strings.Count(notation, ":") < 2) {
+			return Dependency{ID: notation}
+		}
+		return Dependency{ID: notation[:idx], Version: notation[idx+1:]}
+	}
+
+	fields := map[string]string{}
+	for _, match := range fieldRegexp.FindAllStringSubmatch(value, -1) {
+		fields[match[1]] = match[2]
+	}
+
+	dependency := Dependency{ID: fields[idField]}
+	if dependency.ID == "" && fields["group"] != "" {
+		dependency.ID = fields["group"] + ":" + fields["name"]
+	}
+
+	switch {
+	case fields["version.ref"] != "":
+		dependency.Version = c.Versions[fields["version.ref"]]
+	case fields["version"] != "":
+		dependency.Version = fields["version"]
+	}
+
+	return dependency
+}
+
+func unquote(value string) string {
+	if idx := strings.Index(value, "#"); idx != -1 && !strings.HasPrefix(value, "{") {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return strings.Trim(value, `"'`)
+}
@@ -0,0 +1,300 @@
+// Package bundlesize estimates the download size of an Android App Bundle per device configuration,
+// similar to bundletool get-size total.
+//
+// The estimation sums the compressed size of the install-time modules' entries a device downloads:
+// the ABI specific native libraries, the resource alternatives matching the screen density
+// and the resources of the device language. Feature modules and asset packs delivered on demand
+// or after the install are not included.
+package bundlesize
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+)
+
+const (
+	baseModule       = "base"
+	manifestPath     = "manifest/AndroidManifest.xml"
+	resourceTableRel = "resources.pb"
+)
+
+// Size is the estimated download size of a device configuration, an empty dimension means
+// the bundle is not split by it.
+type Size struct {
+	ABI           string `json:"abi,omitempty"`
+	ScreenDensity string `json:"screen_density,omitempty"`
+	Language      string `json:"language,omitempty"`
+	Bytes         int64  `json:"bytes"`
+}
+
+// Matrix is the estimated download size of every combination of the bundle's ABIs, screen densities and languages.
+type Matrix struct {
+	Modules         []string `json:"modules"`
+	ABIs            []string `json:"abis"`
+	ScreenDensities []string `json:"screen_densities"`
+	Languages       []string `json:"languages"`
+	Min             int64    `json:"min"`
+	Max             int64    `json:"max"`
+	Sizes           []Size   `json:"sizes"`
+}
+
+// splits holds the compressed entry sizes of the install-time modules by split dimension.
+type splits struct {
+	common    int64
+	abis      map[string]int64
+	languages map[string]int64
+	// densities holds the alternatives of the density split resources: resource -> density -> size.
+	densities map[string]map[int]int64
+}
+
+// Estimate computes the download size matrix of the AAB.
+func Estimate(aabPath string) (Matrix, error) {
+	r, err := zip.OpenReader(aabPath)
+	if err != nil {
+		return Matrix{}, fmt.Errorf("failed to open bundle: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	modules, err := installTimeModules(r.File)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	s := splits{abis: map[string]int64{}, languages: map[string]int64{}, densities: map[string]map[int]int64{}}
+	for _, file := range r.File {
+		module, rel := splitModulePath(file.Name)
+		if !modules[module] || strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		if err := s.add(file, module, rel); err != nil {
+			return Matrix{}, fmt.Errorf("failed to process %s: %s", file.Name, err)
+		}
+	}
+
+	matrix := s.matrix()
+	for module := range modules {
+		matrix.Modules = append(matrix.Modules, module)
+	}
+	sort.Strings(matrix.Modules)
+	return matrix, nil
+}
+
+// installTimeModules returns the modules and asset packs of the bundle delivered at install time.
+func installTimeModules(files []*zip.File) (map[string]bool, error) {
+	modules := map[string]bool{}
+	for _, file := range files {
+		module, rel := splitModulePath(file.Name)
+		if module == "" || rel != manifestPath {
+			continue
+		}
+
+		if module == baseModule {
+			modules[module] = true
+			continue
+		}
+
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := axml.DecodeProto(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest of the %s module: %s", module, err)
+		}
+		if installTime(manifest) {
+			modules[module] = true
+		}
+	}
+
+	if !modules[baseModule] {
+		return nil, fmt.Errorf("no %s module found in the bundle", baseModule)
+	}
+	return modules, nil
+}
+
+// splitModulePath returns the module of a bundle entry and the entry path within the module.
+// Bundle level entries (BundleConfig.pb, BUNDLE-METADATA, META-INF) have no module.
+func splitModulePath(name string) (string, string) {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return "", name
+	}
+	module := name[:i]
+	if module == "BUNDLE-METADATA" || module == "META-INF" {
+		return "", name
+	}
+	return module, name[i+1:]
+}
+
+func (s *splits) add(file *zip.File, module, rel string) error {
+	// The targeting metadata (assets.pb, native.pb) is not part of the generated APKs.
+	if !strings.Contains(rel, "/") && path.Ext(rel) == ".pb" && rel != resourceTableRel {
+		return nil
+	}
+
+	size, err := compressedSize(file)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(rel, "/")
+	switch {
+	case parts[0] == "lib" && len(parts) > 2:
+		s.abis[parts[1]] += size
+	case parts[0] == "res" && len(parts) > 2:
+		dir := parseResourceDir(parts[1])
+		if dir.language != "" {
+			s.languages[dir.language] += size
+		} else if dir.density > 0 {
+			key := module + "/res/" + dir.key + "/" + strings.Join(parts[2:], "/")
+			if s.densities[key] == nil {
+				s.densities[key] = map[int]int64{}
+			}
+			s.densities[key][dir.density] += size
+		} else {
+			s.common += size
+		}
+	case parts[0] == "assets" && assetLanguage(rel) != "":
+		s.languages[assetLanguage(rel)] += size
+	case rel == resourceTableRel:
+		data, err := readFile(file)
+		if err != nil {
+			return err
+		}
+		shares, err := localeShares(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse resource table: %s", err)
+		}
+
+		languageSize := int64(0)
+		for language, share := range shares {
+			n := int64(math.Round(share * float64(size)))
+			s.languages[language] += n
+			languageSize += n
+		}
+		s.common += size - languageSize
+	default:
+		s.common += size
+	}
+
+	return nil
+}
+
+func (s splits) matrix() Matrix {
+	matrix := Matrix{
+		ABIs:            sortedKeys(s.abis),
+		ScreenDensities: []string{},
+		Languages:       sortedKeys(s.languages),
+	}
+	if len(s.densities) > 0 {
+		for _, bucket := range densityBuckets {
+			matrix.ScreenDensities = append(matrix.ScreenDensities, bucket.name)
+		}
+	}
+
+	for _, abi := range orAny(matrix.ABIs) {
+		for _, density := range orAny(matrix.ScreenDensities) {
+			densitySize := s.densitySize(density)
+			for _, language := range orAny(matrix.Languages) {
+				size := s.common + s.abis[abi] + densitySize + s.languages[language]
+				matrix.Sizes = append(matrix.Sizes, Size{ABI: abi, ScreenDensity: density, Language: language, Bytes: size})
+
+				if len(matrix.Sizes) == 1 || size < matrix.Min {
+					matrix.Min = size
+				}
+				if size > matrix.Max {
+					matrix.Max = size
+				}
+			}
+		}
+	}
+
+	return matrix
+}
+
+func (s splits) densitySize(name string) int64 {
+	var dpi int
+	for _, bucket := range densityBuckets {
+		if bucket.name == name {
+			dpi = bucket.dpi
+		}
+	}
+	if dpi == 0 {
+		return 0
+	}
+
+	var size int64
+	for _, alternatives := range s.densities {
+		size += alternatives[bestDensity(alternatives, dpi)]
+	}
+	return size
+}
+
+// compressedSize returns the compressed size of the entry, stored entries are compressed to estimate their download size.
+func compressedSize(file *zip.File) (int64, error) {
+	if file.Method != zip.Store {
+		return int64(file.CompressedSize64), nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	counter := &countingWriter{}
+	w, err := flate.NewWriter(counter, flate.DefaultCompression)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func readFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orAny returns a single empty value for a dimension the bundle is not split by.
+func orAny(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
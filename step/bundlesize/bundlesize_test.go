@@ -0,0 +1,185 @@
+package bundlesize
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/protobuf"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseResourceDir(t *testing.T) {
+	tests := []struct {
+		name string
+		want resourceDir
+	}{
+		{"drawable", resourceDir{key: "drawable"}},
+		{"drawable-xhdpi-v4", resourceDir{density: 320, key: "drawable-v4"}},
+		{"mipmap-640dpi", resourceDir{density: 640, key: "mipmap"}},
+		{"drawable-nodpi", resourceDir{key: "drawable-nodpi"}},
+		{"raw-fr", resourceDir{language: "fr", key: "raw-fr"}},
+		{"raw-fr-rCA-hdpi", resourceDir{language: "fr", density: 240, key: "raw-fr-rCA"}},
+		{"raw-mcc310-b+sr+Latn", resourceDir{language: "sr", key: "raw-mcc310-b+sr+Latn"}},
+		{"layout-land", resourceDir{key: "layout-land"}},
+		{"drawable-night-xxhdpi", resourceDir{density: 480, key: "drawable-night"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseResourceDir(tt.name))
+		})
+	}
+}
+
+func Test_bestDensity(t *testing.T) {
+	alternatives := map[int]int64{160: 1, 320: 2, 480: 3}
+
+	assert.Equal(t, 160, bestDensity(alternatives, 120))
+	assert.Equal(t, 160, bestDensity(alternatives, 160))
+	assert.Equal(t, 320, bestDensity(alternatives, 240))
+	assert.Equal(t, 480, bestDensity(alternatives, 640))
+	assert.Equal(t, 320, bestDensity(map[int]int64{320: 1}, 120))
+}
+
+func Test_assetLanguage(t *testing.T) {
+	assert.Equal(t, "de", assetLanguage("assets/voices#lang_de/voice.bin"))
+	assert.Equal(t, "pt", assetLanguage("assets/text#lang_pt-BR/a.txt"))
+	assert.Equal(t, "", assetLanguage("assets/fonts/font.ttf"))
+}
+
+func Test_installTime(t *testing.T) {
+	delivery := func(mode string) *axml.Element {
+		return &axml.Element{Namespace: distNamespace, Name: "delivery", Children: []*axml.Element{{Namespace: distNamespace, Name: mode}}}
+	}
+
+	tests := []struct {
+		name     string
+		manifest *axml.Element
+		want     bool
+	}{
+		{"no module element", manifest(nil), true},
+		{"install-time delivery", manifest(nil, delivery("install-time")), true},
+		{"on-demand delivery", manifest(nil, delivery("on-demand")), false},
+		{"fast-follow delivery", manifest(nil, delivery("fast-follow")), false},
+		{"legacy on-demand", manifest(map[string]string{"onDemand": "true"}), false},
+		{"legacy install-time", manifest(map[string]string{"onDemand": "false"}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, installTime(tt.manifest))
+		})
+	}
+}
+
+func Test_localeShares(t *testing.T) {
+	table := resourceTable(map[string]string{"": "Hello", "fr-CA": "Bonjour", "de": "Hallo"})
+
+	shares, err := localeShares(table)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(shares))
+	assert.True(t, shares["fr"] > shares["de"])
+	assert.True(t, shares["fr"]+shares["de"] < 1)
+
+	_, err = localeShares([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestEstimate(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "app-release.aab")
+	ziptest.Write(t, pth, []ziptest.Entry{
+		{Name: "BundleConfig.pb", Content: []byte{}},
+		{Name: "base/manifest/AndroidManifest.xml", Content: axmltest.EncodeProto(manifest(nil))},
+		{Name: "base/resources.pb", Content: resourceTable(map[string]string{"": "Hello", "de": "Hallo"})},
+		{Name: "base/native.pb", CompressedSize: 10000},
+		{Name: "base/dex/classes.dex", CompressedSize: 5000},
+		{Name: "base/lib/arm64-v8a/libapp.so", CompressedSize: 3000},
+		{Name: "base/lib/x86_64/libapp.so", CompressedSize: 3500},
+		{Name: "base/res/drawable-mdpi/icon.png", CompressedSize: 100},
+		{Name: "base/res/drawable-xhdpi/icon.png", CompressedSize: 400},
+		{Name: "base/res/drawable-fr/flag.png", CompressedSize: 50},
+		{Name: "base/assets/voices#lang_de/voice.bin", CompressedSize: 700},
+		{Name: "ondemand/manifest/AndroidManifest.xml", Content: axmltest.EncodeProto(manifest(map[string]string{"onDemand": "true"}))},
+		{Name: "ondemand/dex/classes.dex", CompressedSize: 90000},
+		{Name: "BUNDLE-METADATA/com.android.tools.build.obfuscation/proguard.map", CompressedSize: 20000},
+	})
+
+	matrix, err := Estimate(pth)
+	if err != nil {
+		t.Fatalf("Estimate() error: %s", err)
+	}
+
+	assert.Equal(t, []string{"base"}, matrix.Modules)
+	assert.Equal(t, []string{"arm64-v8a", "x86_64"}, matrix.ABIs)
+	assert.Equal(t, []string{"ldpi", "mdpi", "tvdpi", "hdpi", "xhdpi", "xxhdpi", "xxxhdpi"}, matrix.ScreenDensities)
+	assert.Equal(t, []string{"de", "fr"}, matrix.Languages)
+	assert.Equal(t, 2*7*2, len(matrix.Sizes))
+
+	sizes := map[Size]int64{}
+	for _, size := range matrix.Sizes {
+		sizes[Size{ABI: size.ABI, ScreenDensity: size.ScreenDensity, Language: size.Language}] = size.Bytes
+	}
+	size := func(abi, density, language string) int64 {
+		return sizes[Size{ABI: abi, ScreenDensity: density, Language: language}]
+	}
+
+	assert.Equal(t, int64(500), size("x86_64", "mdpi", "fr")-size("arm64-v8a", "mdpi", "fr"))
+	assert.Equal(t, int64(300), size("arm64-v8a", "xhdpi", "fr")-size("arm64-v8a", "mdpi", "fr"))
+	assert.Equal(t, size("arm64-v8a", "ldpi", "fr"), size("arm64-v8a", "mdpi", "fr"))
+	assert.True(t, size("arm64-v8a", "mdpi", "de")-size("arm64-v8a", "mdpi", "fr") > 650)
+	assert.True(t, size("arm64-v8a", "mdpi", "fr") > 5000+3000+100+50)
+	assert.Equal(t, size("arm64-v8a", "ldpi", "fr"), matrix.Min)
+	assert.Equal(t, size("x86_64", "xxxhdpi", "de"), matrix.Max)
+}
+
+func TestEstimate_NoSplits(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "app-release.aab")
+	ziptest.Write(t, pth, []ziptest.Entry{
+		{Name: "base/manifest/AndroidManifest.xml", Content: axmltest.EncodeProto(manifest(nil))},
+		{Name: "base/dex/classes.dex", CompressedSize: 5000},
+	})
+
+	matrix, err := Estimate(pth)
+	if err != nil {
+		t.Fatalf("Estimate() error: %s", err)
+	}
+	assert.Equal(t, []string{}, matrix.ABIs)
+	assert.Equal(t, []string{}, matrix.ScreenDensities)
+	assert.Equal(t, []string{}, matrix.Languages)
+	assert.Equal(t, 1, len(matrix.Sizes))
+	assert.Equal(t, matrix.Min, matrix.Max)
+
+	ziptest.Write(t, pth, []ziptest.Entry{{Name: "feature/dex/classes.dex", CompressedSize: 5000}})
+	_, err = Estimate(pth)
+	assert.EqualError(t, err, "no base module found in the bundle")
+}
+
+// manifest returns a manifest with a dist:module element if moduleAttributes or children are provided.
+func manifest(moduleAttributes map[string]string, children ...*axml.Element) *axml.Element {
+	root := &axml.Element{Name: "manifest"}
+	if moduleAttributes == nil && len(children) == 0 {
+		return root
+	}
+
+	module := &axml.Element{Namespace: distNamespace, Name: "module", Children: children}
+	for name, value := range moduleAttributes {
+		module.Attributes = append(module.Attributes, axml.Attribute{Namespace: distNamespace, Name: name, Type: axml.TypeString, Value: value})
+	}
+	root.Children = append(root.Children, module)
+	return root
+}
+
+// resourceTable returns a resource table with a string resource, valued per locale.
+func resourceTable(values map[string]string) []byte {
+	entry := protobuf.AppendBytes(nil, 2, []byte("greeting"))
+	for locale, value := range values {
+		config := protobuf.AppendBytes(nil, configurationLocale, []byte(locale))
+		configValue := protobuf.AppendBytes(protobuf.AppendBytes(nil, configValueConfig, config), 2, []byte(value))
+		entry = protobuf.AppendBytes(entry, entryConfigValue, configValue)
+	}
+
+	typ := protobuf.AppendBytes(protobuf.AppendBytes(nil, 2, []byte("string")), typeEntry, entry)
+	pkg := protobuf.AppendBytes(protobuf.AppendBytes(nil, 2, []byte("com.example")), packageType, typ)
+	return protobuf.AppendBytes(nil, resourceTablePackage, pkg)
+}
@@ -0,0 +1,29 @@
+package bundlesize
+
+import "github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+
+const distNamespace = "http://schemas.android.com/apk/distribution"
+
+// installTime tells whether the module (or asset pack) of the manifest is delivered at install time.
+func installTime(manifest *axml.Element) bool {
+	module := child(manifest, distNamespace, "module")
+	if module == nil {
+		return true
+	}
+
+	if delivery := child(module, distNamespace, "delivery"); delivery != nil {
+		return child(delivery, distNamespace, "install-time") != nil
+	}
+
+	onDemand, _ := module.Attr(distNamespace, "onDemand")
+	return onDemand.Value != "true"
+}
+
+func child(element *axml.Element, namespace, name string) *axml.Element {
+	for _, c := range element.Children {
+		if c.Namespace == namespace && c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
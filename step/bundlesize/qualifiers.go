@@ -0,0 +1,134 @@
+package bundlesize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// densityBuckets are the screen densities bundletool generates density splits for.
+var densityBuckets = []struct {
+	name string
+	dpi  int
+}{
+	{"ldpi", 120},
+	{"mdpi", 160},
+	{"tvdpi", 213},
+	{"hdpi", 240},
+	{"xhdpi", 320},
+	{"xxhdpi", 480},
+	{"xxxhdpi", 640},
+}
+
+var (
+	languageQualifierRegexp = regexp.MustCompile(`^[a-z]{2,3}$`)
+	regionQualifierRegexp   = regexp.MustCompile(`^r([A-Z]{2}|[0-9]{3})$`)
+	densityQualifierRegexp  = regexp.MustCompile(`^([0-9]+)dpi$`)
+	mobileCodeRegexp        = regexp.MustCompile(`^(mcc|mnc)[0-9]+$`)
+)
+
+// resourceDir is a parsed resource directory name, like drawable-fr-xhdpi-v4.
+type resourceDir struct {
+	language string
+	density  int
+	// key is the directory name without the density qualifier, the alternatives of a resource share it.
+	key string
+}
+
+// parseResourceDir parses the language and the density qualifiers of a resource directory name.
+// Resources without density (nodpi, anydpi) are not split by density and have 0 density.
+func parseResourceDir(name string) resourceDir {
+	parts := strings.Split(name, "-")
+	dir := resourceDir{}
+
+	keyParts := parts[:1]
+	localeChecked := false
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+
+		if !localeChecked && !mobileCodeRegexp.MatchString(part) {
+			localeChecked = true
+			if languageQualifierRegexp.MatchString(part) || strings.HasPrefix(part, "b+") {
+				dir.language = localeLanguage(part)
+				keyParts = append(keyParts, part)
+				if i+1 < len(parts) && regionQualifierRegexp.MatchString(parts[i+1]) {
+					i++
+					keyParts = append(keyParts, parts[i])
+				}
+				continue
+			}
+		}
+
+		if dpi := densityDPI(part); dpi > 0 {
+			dir.density = dpi
+			continue
+		}
+		keyParts = append(keyParts, part)
+	}
+
+	dir.key = strings.Join(keyParts, "-")
+	return dir
+}
+
+func densityDPI(qualifier string) int {
+	for _, bucket := range densityBuckets {
+		if bucket.name == qualifier {
+			return bucket.dpi
+		}
+	}
+	if match := densityQualifierRegexp.FindStringSubmatch(qualifier); match != nil {
+		dpi, err := strconv.Atoi(match[1])
+		if err == nil {
+			return dpi
+		}
+	}
+	return 0
+}
+
+// assetLanguage returns the language of a language targeted asset directory (assets/strings#lang_fr/...).
+func assetLanguage(pth string) string {
+	for _, segment := range strings.Split(pth, "/") {
+		if i := strings.Index(segment, "#lang_"); i >= 0 {
+			return localeLanguage(segment[i+len("#lang_"):])
+		}
+	}
+	return ""
+}
+
+// bestDensity selects the resource alternative the device of the requested density uses,
+// following the density matching of the Android resource framework.
+func bestDensity(alternatives map[int]int64, requested int) int {
+	best := 0
+	for density := range alternatives {
+		if best == 0 || betterDensity(density, best, requested) {
+			best = density
+		}
+	}
+	return best
+}
+
+// betterDensity tells whether density a is a better match than density b for the requested density:
+// the closest one, preferring scaling down from a higher density.
+func betterDensity(a, b, requested int) bool {
+	if a == b {
+		return false
+	}
+
+	high, low := a, b
+	aIsHigher := true
+	if low > high {
+		high, low = low, high
+		aIsHigher = false
+	}
+
+	if requested >= high {
+		return aIsHigher
+	}
+	if low >= requested {
+		return !aIsHigher
+	}
+	if (2*low-requested)*high > requested*requested {
+		return !aIsHigher
+	}
+	return aIsHigher
+}
@@ -0,0 +1,85 @@
+package bundlesize
+
+import (
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/protobuf"
+)
+
+// Field numbers of the aapt2 resource table messages (Resources.proto, Configuration.proto).
+const (
+	resourceTablePackage = 2
+	packageType          = 3
+	typeEntry            = 3
+	entryConfigValue     = 6
+	configValueConfig    = 1
+	configurationLocale  = 3
+)
+
+// localeShares returns the share of the resource table size taken by the values of each language.
+func localeShares(data []byte) (map[string]float64, error) {
+	table, err := protobuf.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	languageBytes := map[string]int{}
+	packages, err := protobuf.Messages(table, resourceTablePackage)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		types, err := protobuf.Messages(pkg, packageType)
+		if err != nil {
+			return nil, err
+		}
+		for _, typ := range types {
+			entries, err := protobuf.Messages(typ, typeEntry)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				for _, field := range entry {
+					if field.Number != entryConfigValue {
+						continue
+					}
+
+					language, err := configValueLanguage(field.Bytes)
+					if err != nil {
+						return nil, err
+					}
+					if language != "" {
+						languageBytes[language] += len(field.Bytes)
+					}
+				}
+			}
+		}
+	}
+
+	shares := map[string]float64{}
+	for language, size := range languageBytes {
+		shares[language] = float64(size) / float64(len(data))
+	}
+	return shares, nil
+}
+
+func configValueLanguage(data []byte) (string, error) {
+	configValue, err := protobuf.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	configs, err := protobuf.Messages(configValue, configValueConfig)
+	if err != nil || len(configs) == 0 {
+		return "", err
+	}
+	return localeLanguage(protobuf.String(configs[0], configurationLocale)), nil
+}
+
+// localeLanguage returns the language of a BCP-47 tag (fr-CA) or a resource qualifier locale (fr-rCA, b+sr+Latn).
+func localeLanguage(locale string) string {
+	locale = strings.TrimPrefix(locale, "b+")
+	if i := strings.IndexAny(locale, "-_+"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
@@ -0,0 +1,167 @@
+// Package bundletool generates installable APKs from Android App Bundles with bundletool:
+// a universal APK, or an APK set for a device specification.
+package bundletool
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+const (
+	universalAPKName = "universal.apk"
+
+	debugKeystorePassword = "android"
+	debugKeyAlias         = "androiddebugkey"
+)
+
+// Signing is the keystore used to sign the generated APKs.
+type Signing struct {
+	KeystorePath     string
+	KeystorePassword string
+	KeyAlias         string
+	KeyPassword      string
+}
+
+// DebugSigning returns the debug keystore created by Android Studio and the Android Gradle Plugin,
+// nil if it does not exist.
+func DebugSigning() (*Signing, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	pth := filepath.Join(home, ".android", "debug.keystore")
+	if _, err := os.Stat(pth); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &Signing{
+		KeystorePath:     pth,
+		KeystorePassword: debugKeystorePassword,
+		KeyAlias:         debugKeyAlias,
+		KeyPassword:      debugKeystorePassword,
+	}, nil
+}
+
+// Bundletool runs the bundletool jar.
+type Bundletool struct {
+	jarPath    string
+	cmdFactory command.Factory
+}
+
+// New ...
+func New(jarPath string, cmdFactory command.Factory) Bundletool {
+	return Bundletool{jarPath: jarPath, cmdFactory: cmdFactory}
+}
+
+// BuildUniversalAPK generates a single APK with the code and resources of every device configuration.
+func (b Bundletool) BuildUniversalAPK(aabPath, outputPath string, signing *Signing) error {
+	apksFile, err := os.CreateTemp("", "universal-*.apks")
+	if err != nil {
+		return err
+	}
+	_ = apksFile.Close()
+	defer func() { _ = os.Remove(apksFile.Name()) }()
+
+	if err := b.buildAPKs(aabPath, apksFile.Name(), []string{"--mode=universal"}, signing); err != nil {
+		return err
+	}
+
+	return extractUniversalAPK(apksFile.Name(), outputPath)
+}
+
+// BuildAPKSet generates the APK set (.apks) matching the device specification JSON (as created by bundletool get-device-spec).
+func (b Bundletool) BuildAPKSet(aabPath, deviceSpecPath, outputPath string, signing *Signing) error {
+	return b.buildAPKs(aabPath, outputPath, []string{"--device-spec=" + deviceSpecPath}, signing)
+}
+
+func (b Bundletool) buildAPKs(aabPath, outputPath string, modeArgs []string, signing *Signing) error {
+	signingArgs, cleanup, err := signingArgs(signing)
+	if err != nil {
+		return fmt.Errorf("failed to prepare signing arguments: %s", err)
+	}
+	defer cleanup()
+
+	args := []string{"-jar", b.jarPath, "build-apks", "--bundle=" + aabPath, "--output=" + outputPath, "--overwrite"}
+	args = append(append(args, modeArgs...), signingArgs...)
+
+	cmd := b.cmdFactory.Create("java", args, nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %s\n%s", cmd.PrintableCommandArgs(), err, out)
+	}
+
+	return nil
+}
+
+// signingArgs returns the signing arguments of bundletool. The passwords are written to temporary files,
+// so that they do not appear in the command line.
+func signingArgs(signing *Signing) ([]string, func(), error) {
+	if signing == nil {
+		return nil, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "bundletool-signing")
+	if err != nil {
+		return nil, func() {}, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	keystorePasswordPath := filepath.Join(dir, "keystore-password")
+	keyPasswordPath := filepath.Join(dir, "key-password")
+	if err := os.WriteFile(keystorePasswordPath, []byte(signing.KeystorePassword), 0o600); err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	if err := os.WriteFile(keyPasswordPath, []byte(signing.KeyPassword), 0o600); err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+
+	return []string{
+		"--ks=" + signing.KeystorePath,
+		"--ks-pass=file:" + keystorePasswordPath,
+		"--ks-key-alias=" + signing.KeyAlias,
+		"--key-pass=file:" + keyPasswordPath,
+	}, cleanup, nil
+}
+
+// extractUniversalAPK copies the universal APK of the APK set to the output path.
+func extractUniversalAPK(apksPath, outputPath string) error {
+	r, err := zip.OpenReader(apksPath)
+	if err != nil {
+		return fmt.Errorf("failed to open APK set: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, file := range r.File {
+		if file.Name != universalAPKName {
+			continue
+		}
+
+		in, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = in.Close() }()
+
+		out, err := os.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	}
+
+	return errors.New("no universal APK in the APK set")
+}
@@ -0,0 +1,60 @@
+package bundletool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_signingArgs(t *testing.T) {
+	args, cleanup, err := signingArgs(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+	cleanup()
+
+	args, cleanup, err = signingArgs(&Signing{
+		KeystorePath:     "/keystores/upload.jks",
+		KeystorePassword: "store-secret",
+		KeyAlias:         "upload",
+		KeyPassword:      "key-secret",
+	})
+	if err != nil {
+		t.Fatalf("signingArgs() error: %s", err)
+	}
+
+	assert.Equal(t, 4, len(args))
+	assert.Equal(t, "--ks=/keystores/upload.jks", args[0])
+	assert.Equal(t, "--ks-key-alias=upload", args[2])
+	assert.NotContains(t, strings.Join(args, " "), "secret")
+
+	for arg, want := range map[string]string{args[1]: "store-secret", args[3]: "key-secret"} {
+		pth := strings.SplitN(arg, "=file:", 2)[1]
+		content, err := os.ReadFile(pth)
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+
+	cleanup()
+	_, err = os.Stat(strings.SplitN(args[1], "=file:", 2)[1])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_extractUniversalAPK(t *testing.T) {
+	dir := t.TempDir()
+	apksPath := filepath.Join(dir, "app.apks")
+	ziptest.Write(t, apksPath, ziptest.Files(map[string]string{"toc.pb": "toc", "universal.apk": "apk content"}))
+
+	outputPath := filepath.Join(dir, "app-universal.apk")
+	assert.NoError(t, extractUniversalAPK(apksPath, outputPath))
+	content, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "apk content", string(content))
+
+	splitsPath := filepath.Join(dir, "splits.apks")
+	ziptest.Write(t, splitsPath, ziptest.Files(map[string]string{"toc.pb": "toc", "splits/base-master.apk": "split"}))
+	assert.EqualError(t, extractUniversalAPK(splitsPath, outputPath), "no universal APK in the APK set")
+}
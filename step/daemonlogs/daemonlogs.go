@@ -0,0 +1,148 @@
+// Package daemonlogs collects the Gradle daemon logs and JVM crash reports
+// written during a build, so that "Gradle build daemon disappeared unexpectedly"
+// failures can be investigated after the fact.
+package daemonlogs
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	daemonLogPattern = "daemon-*.out.log"
+	crashLogPattern  = "hs_err_pid*.log"
+
+	// GradleUserHomeEnv overrides the default ~/.gradle location.
+	GradleUserHomeEnv = "GRADLE_USER_HOME"
+)
+
+// GradleUserHome returns the Gradle user home directory, respecting $GRADLE_USER_HOME.
+func GradleUserHome() (string, error) {
+	if home := os.Getenv(GradleUserHomeEnv); home != "" {
+		return home, nil
+	}
+
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userHome, ".gradle"), nil
+}
+
+// Find returns the daemon logs (<gradleUserHome>/daemon/<version>/daemon-*.out.log) and
+// JVM crash reports (hs_err_pid*.log) modified after since.
+// Crash reports are written into the working directory of the crashed JVM, so the daemon
+// directories, the project directory and the temp directory are searched (non-recursively).
+func Find(gradleUserHome, projectDir string, since time.Time) ([]string, error) {
+	daemonDirs, err := filepath.Glob(filepath.Join(gradleUserHome, "daemon", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, dir := range daemonDirs {
+		logs, err := findModifiedAfter(dir, daemonLogPattern, since)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, logs...)
+	}
+
+	crashDirs := append(daemonDirs, projectDir, os.TempDir())
+	for _, dir := range crashDirs {
+		logs, err := findModifiedAfter(dir, crashLogPattern, since)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, logs...)
+	}
+
+	return unique(paths), nil
+}
+
+// Zip writes the given files into a zip archive at dst.
+// Entries are prefixed with the name of their parent directory (the Gradle version for daemon logs)
+// to avoid collisions between daemons of different Gradle versions.
+func Zip(paths []string, dst string) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	w := zip.NewWriter(f)
+	for _, pth := range paths {
+		name := filepath.Base(filepath.Dir(pth)) + "/" + filepath.Base(pth)
+		if err := addFile(w, pth, name); err != nil {
+			return fmt.Errorf("failed to add %s to zip: %w", pth, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func addFile(w *zip.Writer, pth, name string) error {
+	src, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func findModifiedAfter(dir, pattern string, since time.Time) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() || info.ModTime().Before(since) {
+			continue
+		}
+		paths = append(paths, match)
+	}
+
+	return paths, nil
+}
+
+func unique(paths []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, pth := range paths {
+		if seen[pth] {
+			continue
+		}
+		seen[pth] = true
+		result = append(result, pth)
+	}
+	sort.Strings(result)
+
+	return result
+}
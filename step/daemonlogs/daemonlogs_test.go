@@ -0,0 +1,86 @@
+package daemonlogs
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, pth string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(pth, []byte(filepath.Base(pth)), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.Chtimes(pth, modTime, modTime); err != nil {
+		t.Fatalf("set mod time: %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	gradleUserHome := t.TempDir()
+	projectDir := t.TempDir()
+	started := time.Now().Add(-time.Minute)
+	before := started.Add(-time.Hour)
+	after := started.Add(30 * time.Second)
+
+	newDaemonLog := filepath.Join(gradleUserHome, "daemon", "8.4", "daemon-123.out.log")
+	oldDaemonLog := filepath.Join(gradleUserHome, "daemon", "8.4", "daemon-100.out.log")
+	otherVersionLog := filepath.Join(gradleUserHome, "daemon", "7.6", "daemon-456.out.log")
+	registry := filepath.Join(gradleUserHome, "daemon", "8.4", "registry.bin")
+	daemonCrash := filepath.Join(gradleUserHome, "daemon", "8.4", "hs_err_pid123.log")
+	projectCrash := filepath.Join(projectDir, "hs_err_pid789.log")
+	oldProjectCrash := filepath.Join(projectDir, "hs_err_pid1.log")
+
+	writeFile(t, newDaemonLog, after)
+	writeFile(t, oldDaemonLog, before)
+	writeFile(t, otherVersionLog, after)
+	writeFile(t, registry, after)
+	writeFile(t, daemonCrash, after)
+	writeFile(t, projectCrash, after)
+	writeFile(t, oldProjectCrash, before)
+
+	got, err := Find(gradleUserHome, projectDir, started)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{newDaemonLog, otherVersionLog, daemonCrash, projectCrash}, got)
+}
+
+func TestFind_NoDaemonDir(t *testing.T) {
+	got, err := Find(t.TempDir(), t.TempDir(), time.Now())
+
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestZip(t *testing.T) {
+	dir := t.TempDir()
+	log84 := filepath.Join(dir, "8.4", "daemon-1.out.log")
+	log76 := filepath.Join(dir, "7.6", "daemon-1.out.log")
+	writeFile(t, log84, time.Now())
+	writeFile(t, log76, time.Now())
+
+	dst := filepath.Join(dir, "logs.zip")
+	err := Zip([]string{log84, log76}, dst)
+	assert.NoError(t, err)
+
+	r, err := zip.OpenReader(dst)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"8.4/daemon-1.out.log", "7.6/daemon-1.out.log"}, names)
+}
@@ -0,0 +1,196 @@
+// Package dex parses the header and the id tables of the DEX files of APKs.
+package dex
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	headerSize   = 0x70
+	endianTag    = 0x12345678
+	typeIDSize   = 4
+	stringIDSize = 4
+	classDefSize = 32
+
+	// shortNameLength is the longest class name counted as short, R8 names classes a, b, ..., aa, ab, ...
+	shortNameLength = 2
+	// MinifiedRatio is the ratio of short class names from which the DEX files are considered minified. The classes
+	// kept by rules (for example components referenced in the manifest) keep their names in minified apps,
+	// but apps without minification have hardly any short class names.
+	MinifiedRatio = 0.3
+)
+
+var dexRegexp = regexp.MustCompile(`^classes[0-9]*\.dex$`)
+
+// File is the summary of a DEX file.
+type File struct {
+	Name string
+	// Methods and Fields are the number of referenced methods and fields, the 64K limit applies to these.
+	Methods int
+	Fields  int
+	// Classes is the number of classes defined in the file.
+	Classes int
+	// NamedClasses is the number of classes considered by the obfuscation heuristic: R classes and anonymous
+	// classes are skipped as they have short names regardless of minification.
+	NamedClasses int
+	// ShortNamedClasses is the number of named classes with a simple name of at most 2 characters.
+	ShortNamedClasses int
+}
+
+// ShortNameRatio returns the ratio of the short class names among the named classes.
+func ShortNameRatio(files []File) float64 {
+	var named, short int
+	for _, file := range files {
+		named += file.NamedClasses
+		short += file.ShortNamedClasses
+	}
+	if named == 0 {
+		return 0
+	}
+	return float64(short) / float64(named)
+}
+
+// Minified tells whether the DEX files look minified, based on the ratio of short class names.
+func Minified(files []File) bool {
+	return ShortNameRatio(files) >= MinifiedRatio
+}
+
+// Inspect parses the classes*.dex files of the APK.
+func Inspect(apkPath string) ([]File, error) {
+	r, err := zip.OpenReader(apkPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open APK: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	var files []File
+	for _, file := range r.File {
+		if !dexRegexp.MatchString(file.Name) {
+			continue
+		}
+
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", file.Name, err)
+		}
+		dexFile, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", file.Name, err)
+		}
+		dexFile.Name = file.Name
+		files = append(files, dexFile)
+	}
+
+	return files, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	return io.ReadAll(rc)
+}
+
+// Parse parses the DEX file header and the class names of the class definitions.
+func Parse(data []byte) (File, error) {
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte("dex\n")) || data[7] != 0 {
+		return File{}, errors.New("not a DEX file")
+	}
+	if binary.LittleEndian.Uint32(data[40:]) != endianTag {
+		return File{}, errors.New("unsupported endianness")
+	}
+
+	u32 := func(offset int) int { return int(binary.LittleEndian.Uint32(data[offset:])) }
+	stringIDs, stringIDsOffset := u32(56), u32(60)
+	typeIDs, typeIDsOffset := u32(64), u32(68)
+	classDefs, classDefsOffset := u32(96), u32(100)
+
+	for _, table := range []struct {
+		count, offset, size int
+	}{
+		{stringIDs, stringIDsOffset, stringIDSize},
+		{typeIDs, typeIDsOffset, typeIDSize},
+		{classDefs, classDefsOffset, classDefSize},
+	} {
+		if table.offset < 0 || table.count < 0 || table.offset+table.count*table.size > len(data) {
+			return File{}, errors.New("truncated id table")
+		}
+	}
+
+	f := File{Fields: u32(80), Methods: u32(88), Classes: classDefs}
+	for i := 0; i < classDefs; i++ {
+		typeIndex := u32(classDefsOffset + i*classDefSize)
+		if typeIndex >= typeIDs {
+			return File{}, fmt.Errorf("invalid class type index: %d", typeIndex)
+		}
+		stringIndex := u32(typeIDsOffset + typeIndex*typeIDSize)
+		if stringIndex >= stringIDs {
+			return File{}, fmt.Errorf("invalid type descriptor index: %d", stringIndex)
+		}
+		descriptor, err := readString(data, u32(stringIDsOffset+stringIndex*stringIDSize))
+		if err != nil {
+			return File{}, err
+		}
+
+		name, ok := simpleName(descriptor)
+		if !ok {
+			continue
+		}
+		f.NamedClasses++
+		if len(name) <= shortNameLength {
+			f.ShortNamedClasses++
+		}
+	}
+
+	return f, nil
+}
+
+// readString reads the MUTF-8 string data item at the offset: the ULEB128 UTF-16 length followed by
+// the null-terminated string.
+func readString(data []byte, offset int) (string, error) {
+	if offset < 0 || offset >= len(data) {
+		return "", fmt.Errorf("invalid string offset: %d", offset)
+	}
+	_, n := binary.Uvarint(data[offset:])
+	if n <= 0 {
+		return "", fmt.Errorf("invalid string length at offset %d", offset)
+	}
+	start := offset + n
+	end := bytes.IndexByte(data[start:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at offset %d", offset)
+	}
+
+	return string(data[start : start+end]), nil
+}
+
+// simpleName returns the simple name of the class type descriptor (for example b for La/b;, Inner for
+// Lcom/example/Outer$Inner;). R classes and anonymous classes are skipped.
+func simpleName(descriptor string) (string, bool) {
+	if !strings.HasPrefix(descriptor, "L") || !strings.HasSuffix(descriptor, ";") {
+		return "", false
+	}
+	name := descriptor[1 : len(descriptor)-1]
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	parts := strings.Split(name, "$")
+	if parts[0] == "R" {
+		return "", false
+	}
+	name = parts[len(parts)-1]
+	if name == "" || strings.Trim(name, "0123456789") == "" {
+		return "", false
+	}
+
+	return name, true
+}
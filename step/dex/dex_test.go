@@ -0,0 +1,123 @@
+package dex
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse(dexFile(1200, 300, []string{
+		"Lcom/example/MainActivity;",
+		"Lcom/example/MainActivity$1;",
+		"Lcom/example/R;",
+		"Lcom/example/R$id;",
+		"La/b;",
+		"La/ab;",
+		"La/b$c;",
+		"Lcom/example/Io;",
+	}))
+	if err != nil {
+		t.Fatalf("Parse() error: %s", err)
+	}
+
+	assert.Equal(t, 1200, f.Methods)
+	assert.Equal(t, 300, f.Fields)
+	assert.Equal(t, 8, f.Classes)
+	assert.Equal(t, 5, f.NamedClasses)
+	assert.Equal(t, 4, f.ShortNamedClasses)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("PK\x03\x04"))
+	assert.Error(t, err)
+
+	data := dexFile(1, 1, []string{"La;"})
+	binary.LittleEndian.PutUint32(data[100:], uint32(len(data)))
+	_, err = Parse(data)
+	assert.Error(t, err)
+}
+
+func TestInspect(t *testing.T) {
+	apkPath := filepath.Join(t.TempDir(), "app-release.apk")
+	ziptest.Write(t, apkPath, []ziptest.Entry{
+		{Name: "classes.dex", Content: dexFile(10, 5, []string{"La;", "Lb;", "Lcom/example/MainActivity;"})},
+		{Name: "classes2.dex", Content: dexFile(20, 8, []string{"Lc;"})},
+		{Name: "assets/classes3.dex", Content: []byte("not inspected")},
+	})
+
+	files, err := Inspect(apkPath)
+	if err != nil {
+		t.Fatalf("Inspect() error: %s", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 DEX files, got: %v", files)
+	}
+	assert.ElementsMatch(t, []string{"classes.dex", "classes2.dex"}, []string{files[0].Name, files[1].Name})
+	assert.Equal(t, 0.75, ShortNameRatio(files))
+	assert.True(t, Minified(files))
+}
+
+func TestMinified(t *testing.T) {
+	assert.False(t, Minified(nil))
+	assert.False(t, Minified([]File{{NamedClasses: 100, ShortNamedClasses: 2}}))
+	assert.True(t, Minified([]File{{NamedClasses: 100, ShortNamedClasses: 2}, {NamedClasses: 100, ShortNamedClasses: 90}}))
+}
+
+func TestSimpleName(t *testing.T) {
+	tests := map[string]string{
+		"Lcom/example/MainActivity;":        "MainActivity",
+		"La;":                               "a",
+		"Lcom/example/Outer$Inner;":         "Inner",
+		"Lcom/example/Outer$1;":             "",
+		"Lcom/example/R$string;":            "",
+		"Lcom/example/Resources;":           "Resources",
+		"I":                                 "",
+		"Lcom/example/-$$Lambda$Main$Ab12;": "Ab12",
+	}
+	for descriptor, want := range tests {
+		name, ok := simpleName(descriptor)
+		assert.Equal(t, want, name, descriptor)
+		assert.Equal(t, want != "", ok, descriptor)
+	}
+}
+
+// dexFile returns a DEX file with the given id table sizes and class definitions: a string and a type id
+// for each class descriptor.
+func dexFile(methods, fields int, descriptors []string) []byte {
+	count := len(descriptors)
+	stringIDsOffset := headerSize
+	typeIDsOffset := stringIDsOffset + count*stringIDSize
+	classDefsOffset := typeIDsOffset + count*typeIDSize
+	stringDataOffset := classDefsOffset + count*classDefSize
+
+	data := make([]byte, stringDataOffset)
+	copy(data, "dex\n035\x00")
+	put := func(offset, value int) { binary.LittleEndian.PutUint32(data[offset:], uint32(value)) }
+	put(36, headerSize)
+	put(40, endianTag)
+	put(56, count)
+	put(60, stringIDsOffset)
+	put(64, count)
+	put(68, typeIDsOffset)
+	put(80, fields)
+	put(84, stringDataOffset)
+	put(88, methods)
+	put(92, stringDataOffset)
+	put(96, count)
+	put(100, classDefsOffset)
+
+	for i, descriptor := range descriptors {
+		put(stringIDsOffset+i*stringIDSize, len(data))
+		put(typeIDsOffset+i*typeIDSize, i)
+		put(classDefsOffset+i*classDefSize, i)
+		data = append(data, byte(len(descriptor)))
+		data = append(append(data, descriptor...), 0)
+	}
+	put(32, len(data))
+
+	return data
+}
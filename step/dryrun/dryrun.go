@@ -0,0 +1,118 @@
+// Package dryrun interprets the output of `gradlew <tasks> --dry-run` and predicts the
+// artifacts the planned tasks would produce.
+package dryrun
+
+import (
+	"bufio"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	skippedTaskRegexp = regexp.MustCompile(`^(:?[\w\-.:]+) SKIPPED$`)
+	variantTaskRegexp = regexp.MustCompile(`^(.*):(assemble|bundle)([A-Z]\w*)$`)
+)
+
+// PlannedArtifact is an artifact expected to be produced by a variant task.
+type PlannedArtifact struct {
+	Task    string
+	Module  string
+	Variant string
+	Path    string
+}
+
+// ParseTaskGraph returns the tasks Gradle would execute, in execution order.
+// With --dry-run every task is printed as `:module:task SKIPPED`.
+func ParseTaskGraph(output string) []string {
+	var tasks []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if match := skippedTaskRegexp.FindStringSubmatch(strings.TrimSpace(scanner.Text())); match != nil {
+			tasks = append(tasks, match[1])
+		}
+	}
+
+	return tasks
+}
+
+// PlannedArtifacts predicts the output paths of the assemble<Variant> (apk) or bundle<Variant> (aab) tasks
+// of the task graph, following the Android Gradle Plugin's default output locations:
+// <module>/build/outputs/apk/<flavors>/<buildType>/<module>-<flavor>-<buildType>.apk and
+// <module>/build/outputs/bundle/<variant>/<module>-<flavor>-<buildType>.aab.
+func PlannedArtifacts(projectDir string, tasks []string, appType string) []PlannedArtifact {
+	taskPrefix := "assemble"
+	if appType == "aab" {
+		taskPrefix = "bundle"
+	}
+
+	var artifacts []PlannedArtifact
+	for _, task := range tasks {
+		match := variantTaskRegexp.FindStringSubmatch(task)
+		if match == nil || match[2] != taskPrefix || isTestVariant(match[3]) {
+			continue
+		}
+
+		modulePath := strings.TrimPrefix(match[1], ":")
+		if modulePath == "" {
+			continue
+		}
+		variant := lowerFirst(match[3])
+		module := modulePath[strings.LastIndex(modulePath, ":")+1:]
+		moduleDir := filepath.Join(projectDir, filepath.Join(strings.Split(modulePath, ":")...))
+
+		words := splitCamelCase(variant)
+		fileName := module + "-" + strings.ToLower(strings.Join(words, "-")) + "." + appType
+
+		var outputDir string
+		if appType == "aab" {
+			outputDir = filepath.Join(moduleDir, "build", "outputs", "bundle", variant)
+		} else {
+			buildType := strings.ToLower(words[len(words)-1])
+			outputDir = filepath.Join(moduleDir, "build", "outputs", "apk")
+			if flavors := strings.TrimSuffix(variant, words[len(words)-1]); flavors != "" {
+				outputDir = filepath.Join(outputDir, flavors)
+			}
+			outputDir = filepath.Join(outputDir, buildType)
+		}
+
+		artifacts = append(artifacts, PlannedArtifact{
+			Task:    task,
+			Module:  modulePath,
+			Variant: variant,
+			Path:    filepath.Join(outputDir, fileName),
+		})
+	}
+
+	return artifacts
+}
+
+func isTestVariant(variant string) bool {
+	return strings.HasSuffix(variant, "AndroidTest") || strings.HasSuffix(variant, "UnitTest")
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+
+	return string(r)
+}
+
+// splitCamelCase splits a variant name into its flavor and build type words: demoFreeRelease -> demo, Free, Release.
+func splitCamelCase(s string) []string {
+	var words []string
+	start := 0
+	runes := []rune(s)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return append(words, string(runes[start:]))
+}
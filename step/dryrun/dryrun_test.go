@@ -0,0 +1,66 @@
+package dryrun
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dryRunOutput = `> Task :buildSrc:compileKotlin
+Starting a Gradle Daemon (subsequent builds will be faster)
+:app:preBuild SKIPPED
+:app:preDemoReleaseBuild SKIPPED
+:app:compileDemoReleaseKotlin SKIPPED
+:app:assembleDemoRelease SKIPPED
+:core:ui:assembleRelease SKIPPED
+:app:assembleDemoReleaseUnitTest SKIPPED
+
+BUILD SUCCESSFUL in 3s
+`
+
+func TestParseTaskGraph(t *testing.T) {
+	tasks := ParseTaskGraph(dryRunOutput)
+
+	assert.Equal(t, []string{
+		":app:preBuild",
+		":app:preDemoReleaseBuild",
+		":app:compileDemoReleaseKotlin",
+		":app:assembleDemoRelease",
+		":core:ui:assembleRelease",
+		":app:assembleDemoReleaseUnitTest",
+	}, tasks)
+}
+
+func TestPlannedArtifacts_APK(t *testing.T) {
+	artifacts := PlannedArtifacts("/project", ParseTaskGraph(dryRunOutput), "apk")
+
+	assert.Equal(t, []PlannedArtifact{
+		{
+			Task:    ":app:assembleDemoRelease",
+			Module:  "app",
+			Variant: "demoRelease",
+			Path:    "/project/app/build/outputs/apk/demo/release/app-demo-release.apk",
+		},
+		{
+			Task:    ":core:ui:assembleRelease",
+			Module:  "core:ui",
+			Variant: "release",
+			Path:    "/project/core/ui/build/outputs/apk/release/ui-release.apk",
+		},
+	}, artifacts)
+}
+
+func TestPlannedArtifacts_AAB(t *testing.T) {
+	tasks := []string{":app:preBuild", ":app:bundleDemoFreeRelease", ":app:assembleDemoFreeRelease"}
+
+	artifacts := PlannedArtifacts("/project", tasks, "aab")
+
+	assert.Equal(t, []PlannedArtifact{
+		{
+			Task:    ":app:bundleDemoFreeRelease",
+			Module:  "app",
+			Variant: "demoFreeRelease",
+			Path:    "/project/app/build/outputs/bundle/demoFreeRelease/app-demo-free-release.aab",
+		},
+	}, artifacts)
+}
@@ -0,0 +1,153 @@
+package gradleargs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+)
+
+// Change is a modification made to the arguments to make them work with the project's Gradle version.
+type Change struct {
+	From   []string
+	To     []string
+	Reason string
+}
+
+func (c Change) String() string {
+	if len(c.To) == 0 {
+		return fmt.Sprintf("removed %s: %s", strings.Join(c.From, " "), c.Reason)
+	}
+
+	return fmt.Sprintf("replaced %s with %s: %s", strings.Join(c.From, " "), strings.Join(c.To, " "), c.Reason)
+}
+
+type translation struct {
+	from  string
+	to    []string
+	since gradlewrapper.Version
+}
+
+type unsupported struct {
+	flags       []string
+	since       gradlewrapper.Version
+	removedIn   gradlewrapper.Version
+	takesValues bool
+}
+
+var (
+	problemsReportSince = gradlewrapper.MustParseVersion("8.12")
+	plainConsoleSince   = gradlewrapper.MustParseVersion("4.0")
+	watchFSSince        = gradlewrapper.MustParseVersion("6.7")
+	stableCCSince       = gradlewrapper.MustParseVersion("8.1")
+	gradle9             = gradlewrapper.MustParseVersion("9.0")
+)
+
+// translations replace deprecated spellings with the ones the Gradle version understands.
+var translations = []translation{
+	{from: "-Dorg.gradle.unsafe.configuration-cache=true", to: []string{"-Dorg.gradle.configuration-cache=true"}, since: stableCCSince},
+	{from: "-Dorg.gradle.unsafe.configuration-cache=false", to: []string{"-Dorg.gradle.configuration-cache=false"}, since: stableCCSince},
+	{from: "-Dorg.gradle.unsafe.configuration-cache-problems=warn", to: []string{"-Dorg.gradle.configuration-cache.problems=warn"}, since: stableCCSince},
+	{from: "-Dorg.gradle.unsafe.watch-fs=true", to: []string{"--watch-fs"}, since: watchFSSince},
+	{from: "-Dorg.gradle.unsafe.watch-fs=false", to: []string{"--no-watch-fs"}, since: watchFSSince},
+	{from: "--configuration-cache=on", to: []string{"--configuration-cache"}, since: configurationCacheSince},
+	{from: "--configuration-cache=warn", to: []string{"--configuration-cache", "--configuration-cache-problems=warn"}, since: configurationCacheSince},
+	{from: "--configuration-cache=off", to: []string{"--no-configuration-cache"}, since: configurationCacheSince},
+}
+
+// unsupportedFlags are removed when the Gradle version is older than since, or not older than removedIn.
+var unsupportedFlags = []unsupported{
+	{flags: []string{"--configuration-cache", "--no-configuration-cache", "--configuration-cache-problems"}, since: configurationCacheSince},
+	{flags: []string{"--problems-report", "--no-problems-report"}, since: problemsReportSince},
+	{flags: []string{"--watch-fs", "--no-watch-fs"}, since: watchFSSince},
+	{flags: []string{"--build-cache", "--no-build-cache"}, since: buildCacheSince},
+	{flags: []string{"--console"}, since: plainConsoleSince, takesValues: true},
+	{flags: []string{"--warning-mode"}, since: warningModeSince, takesValues: true},
+	{flags: []string{"--build-file", "-b", "--settings-file", "-c"}, removedIn: gradle9, takesValues: true},
+}
+
+// PlainConsole returns the --console=plain option, which keeps the CI log free of progress bar artifacts.
+func PlainConsole() Option {
+	return Option{Flag: "--console", Value: "plain", Since: plainConsoleSince}
+}
+
+// SupportsProblemsReport reports whether the Gradle version generates the problems report
+// (build/reports/problems/problems-report.html).
+func SupportsProblemsReport(version gradlewrapper.Version) bool {
+	return !version.IsZero() && version.AtLeast(problemsReportSince)
+}
+
+// Adapt rewrites the arguments for the Gradle version: deprecated spellings are translated and options the version
+// does not know are removed. Arguments are left untouched if the version is unknown.
+func Adapt(args []string, version gradlewrapper.Version) ([]string, []Change) {
+	if version.IsZero() {
+		return args, nil
+	}
+
+	var adapted []string
+	var changes []Change
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if t, ok := findTranslation(arg); ok && version.AtLeast(t.since) {
+			adapted = append(adapted, t.to...)
+			changes = append(changes, Change{From: []string{arg}, To: t.to, Reason: fmt.Sprintf("deprecated in Gradle %s", version)})
+			continue
+		}
+
+		if u, flag, ok := findUnsupported(arg); ok && !isSupported(u, version) {
+			from := []string{arg}
+			if u.takesValues && arg == flag && i+1 < len(args) {
+				i++
+				from = append(from, args[i])
+			}
+			changes = append(changes, Change{From: from, Reason: unsupportedReason(u, version)})
+			continue
+		}
+
+		adapted = append(adapted, arg)
+	}
+
+	return adapted, changes
+}
+
+func findTranslation(arg string) (translation, bool) {
+	for _, t := range translations {
+		if arg == t.from {
+			return t, true
+		}
+	}
+
+	return translation{}, false
+}
+
+func findUnsupported(arg string) (unsupported, string, bool) {
+	for _, u := range unsupportedFlags {
+		for _, flag := range u.flags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return u, flag, true
+			}
+		}
+	}
+
+	return unsupported{}, "", false
+}
+
+func isSupported(u unsupported, version gradlewrapper.Version) bool {
+	if !u.since.IsZero() && !version.AtLeast(u.since) {
+		return false
+	}
+	if !u.removedIn.IsZero() && version.AtLeast(u.removedIn) {
+		return false
+	}
+
+	return true
+}
+
+func unsupportedReason(u unsupported, version gradlewrapper.Version) string {
+	if !u.removedIn.IsZero() && version.AtLeast(u.removedIn) {
+		return fmt.Sprintf("removed in Gradle %s, the project uses Gradle %s", u.removedIn, version)
+	}
+
+	return fmt.Sprintf("requires Gradle %s or newer, the project uses Gradle %s", u.since, version)
+}
@@ -0,0 +1,92 @@
+package gradleargs
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapt(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		version     string
+		want        []string
+		wantChanges int
+	}{
+		{
+			name:    "unknown version",
+			args:    []string{"--configuration-cache", "--problems-report"},
+			version: "",
+			want:    []string{"--configuration-cache", "--problems-report"},
+		},
+		{
+			name:        "configuration cache on old Gradle",
+			args:        []string{"--stacktrace", "--configuration-cache", "--configuration-cache-problems=warn"},
+			version:     "6.1.1",
+			want:        []string{"--stacktrace"},
+			wantChanges: 2,
+		},
+		{
+			name:        "deprecated configuration cache property",
+			args:        []string{"-Dorg.gradle.unsafe.configuration-cache=true", "-Dorg.gradle.unsafe.configuration-cache-problems=warn"},
+			version:     "8.4",
+			want:        []string{"-Dorg.gradle.configuration-cache=true", "-Dorg.gradle.configuration-cache.problems=warn"},
+			wantChanges: 2,
+		},
+		{
+			name:    "deprecated configuration cache property on old Gradle",
+			args:    []string{"-Dorg.gradle.unsafe.configuration-cache=true"},
+			version: "7.6",
+			want:    []string{"-Dorg.gradle.unsafe.configuration-cache=true"},
+		},
+		{
+			name:        "preview configuration cache syntax",
+			args:        []string{"--configuration-cache=warn"},
+			version:     "7.0",
+			want:        []string{"--configuration-cache", "--configuration-cache-problems=warn"},
+			wantChanges: 1,
+		},
+		{
+			name:        "problems report before 8.12",
+			args:        []string{"--no-problems-report", "--warning-mode", "all"},
+			version:     "8.4",
+			want:        []string{"--warning-mode", "all"},
+			wantChanges: 1,
+		},
+		{
+			name:        "separate value of removed flag",
+			args:        []string{"-b", "app/build.gradle", "assembleDebug"},
+			version:     "9.0.0",
+			want:        []string{"assembleDebug"},
+			wantChanges: 1,
+		},
+		{
+			name:        "separate value of unsupported flag",
+			args:        []string{"--warning-mode", "all", "--info"},
+			version:     "4.4",
+			want:        []string{"--info"},
+			wantChanges: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var version gradlewrapper.Version
+			if tt.version != "" {
+				version = gradlewrapper.MustParseVersion(tt.version)
+			}
+
+			got, changes := Adapt(tt.args, version)
+
+			assert.Equal(t, tt.want, got)
+			assert.Len(t, changes, tt.wantChanges)
+		})
+	}
+}
+
+func TestSupportsProblemsReport(t *testing.T) {
+	assert.False(t, SupportsProblemsReport(gradlewrapper.Version{}))
+	assert.False(t, SupportsProblemsReport(gradlewrapper.MustParseVersion("8.11.1")))
+	assert.True(t, SupportsProblemsReport(gradlewrapper.MustParseVersion("8.12")))
+}
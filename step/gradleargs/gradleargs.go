@@ -0,0 +1,116 @@
+// Package gradleargs builds Gradle command line options from typed step inputs, taking the
+// project's Gradle version and the free-form arguments input into account.
+package gradleargs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+)
+
+// Option is a Gradle command line option.
+type Option struct {
+	// Flag is the option name, for example --max-workers.
+	Flag string
+	// Value is appended to the flag as --flag=value, if not empty.
+	Value string
+	// Since is the first Gradle version supporting the option. Zero means any version.
+	Since gradlewrapper.Version
+	// Conflicts are other options setting the same thing, for example --no-build-cache.
+	Conflicts []string
+}
+
+func (o Option) String() string {
+	if o.Value == "" {
+		return o.Flag
+	}
+
+	return o.Flag + "=" + o.Value
+}
+
+// Dropped is an option that was not added to the arguments.
+type Dropped struct {
+	Option Option
+	Reason string
+}
+
+var (
+	offlineSince            = gradlewrapper.Version{}
+	buildCacheSince         = gradlewrapper.MustParseVersion("3.5")
+	configurationCacheSince = gradlewrapper.MustParseVersion("6.6")
+	parallelSince           = gradlewrapper.Version{}
+	maxWorkersSince         = gradlewrapper.MustParseVersion("4.0")
+	warningModeSince        = gradlewrapper.MustParseVersion("4.5")
+	warningModeFailSince    = gradlewrapper.MustParseVersion("5.6")
+)
+
+// Offline returns the --offline option.
+func Offline() Option {
+	return Option{Flag: "--offline", Since: offlineSince}
+}
+
+// BuildCache returns the --build-cache option.
+func BuildCache() Option {
+	return Option{Flag: "--build-cache", Since: buildCacheSince, Conflicts: []string{"--no-build-cache"}}
+}
+
+// ConfigurationCache returns the --configuration-cache option.
+func ConfigurationCache() Option {
+	return Option{Flag: "--configuration-cache", Since: configurationCacheSince, Conflicts: []string{"--no-configuration-cache"}}
+}
+
+// Parallel returns the --parallel option.
+func Parallel() Option {
+	return Option{Flag: "--parallel", Since: parallelSince, Conflicts: []string{"--no-parallel"}}
+}
+
+// MaxWorkers returns the --max-workers option.
+func MaxWorkers(workers int) Option {
+	return Option{Flag: "--max-workers", Value: fmt.Sprint(workers), Since: maxWorkersSince}
+}
+
+// WarningMode returns the --warning-mode option.
+func WarningMode(mode string) Option {
+	since := warningModeSince
+	if mode == "fail" {
+		since = warningModeFailSince
+	}
+
+	return Option{Flag: "--warning-mode", Value: mode, Since: since}
+}
+
+// Merge appends the options to the arguments. Options not supported by the Gradle version are dropped,
+// so are the ones already set in the arguments (the explicit arguments win). If the Gradle version is unknown,
+// every option is considered supported.
+func Merge(args []string, options []Option, version gradlewrapper.Version) ([]string, []Dropped) {
+	merged := append([]string{}, args...)
+	var dropped []Dropped
+	for _, option := range options {
+		if present := Find(args, append([]string{option.Flag}, option.Conflicts...)...); present != "" {
+			dropped = append(dropped, Dropped{Option: option, Reason: fmt.Sprintf("%s is already set in the arguments", present)})
+			continue
+		}
+		if !version.IsZero() && !version.AtLeast(option.Since) {
+			dropped = append(dropped, Dropped{Option: option, Reason: fmt.Sprintf("requires Gradle %s or newer, the project uses Gradle %s", option.Since, version)})
+			continue
+		}
+		merged = append(merged, option.String())
+	}
+
+	return merged, dropped
+}
+
+// Find returns the first argument setting any of the given flags, either as --flag, --flag=value
+// or --flag value.
+func Find(args []string, flags ...string) string {
+	for _, arg := range args {
+		for _, flag := range flags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return arg
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,68 @@
+package gradleargs
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	options := []Option{Offline(), BuildCache(), ConfigurationCache(), Parallel(), MaxWorkers(4), WarningMode("all")}
+
+	tests := []struct {
+		name        string
+		args        []string
+		version     string
+		wantArgs    []string
+		wantDropped []string
+	}{
+		{
+			name:     "all supported",
+			args:     []string{"--stacktrace"},
+			version:  "8.4",
+			wantArgs: []string{"--stacktrace", "--offline", "--build-cache", "--configuration-cache", "--parallel", "--max-workers=4", "--warning-mode=all"},
+		},
+		{
+			name:        "configuration cache not supported",
+			version:     "6.5",
+			wantArgs:    []string{"--offline", "--build-cache", "--parallel", "--max-workers=4", "--warning-mode=all"},
+			wantDropped: []string{"--configuration-cache"},
+		},
+		{
+			name:        "duplicates in arguments",
+			args:        []string{"--no-build-cache", "--max-workers", "2", "--warning-mode=none"},
+			version:     "8.4",
+			wantArgs:    []string{"--no-build-cache", "--max-workers", "2", "--warning-mode=none", "--offline", "--configuration-cache", "--parallel"},
+			wantDropped: []string{"--build-cache", "--max-workers=4", "--warning-mode=all"},
+		},
+		{
+			name:     "unknown version",
+			wantArgs: []string{"--offline", "--build-cache", "--configuration-cache", "--parallel", "--max-workers=4", "--warning-mode=all"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var version gradlewrapper.Version
+			if tt.version != "" {
+				version = gradlewrapper.MustParseVersion(tt.version)
+			}
+
+			args, dropped := Merge(tt.args, options, version)
+
+			assert.Equal(t, tt.wantArgs, args)
+			var droppedOptions []string
+			for _, d := range dropped {
+				droppedOptions = append(droppedOptions, d.Option.String())
+				assert.NotEmpty(t, d.Reason)
+			}
+			assert.Equal(t, tt.wantDropped, droppedOptions)
+		})
+	}
+}
+
+func TestWarningMode_Fail(t *testing.T) {
+	_, dropped := Merge(nil, []Option{WarningMode("fail")}, gradlewrapper.MustParseVersion("5.5"))
+
+	assert.Len(t, dropped, 1)
+}
@@ -0,0 +1,87 @@
+package gradleargs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const secretPrefix = "secret:"
+
+// envReferenceRegexp matches the $VAR and ${VAR} environment variable references.
+var envReferenceRegexp = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// Property is a Gradle project property passed as -Pkey=value.
+type Property struct {
+	Key   string
+	Value string
+	// Secret properties are redacted from the printed command line.
+	Secret bool
+}
+
+// Arg returns the command line argument of the property.
+func (p Property) Arg() string {
+	return fmt.Sprintf("-P%s=%s", p.Key, p.Value)
+}
+
+// ParseProperties parses KEY=VALUE lines. Lines prefixed with "secret:" are secret properties, so are
+// the ones whose value references an environment variable ($VAR or ${VAR}); the references are expanded
+// with lookupEnv. Empty lines and lines starting with # are ignored.
+func ParseProperties(input string, lookupEnv func(string) (string, bool)) ([]Property, error) {
+	var properties []Property
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		secret := strings.HasPrefix(line, secretPrefix)
+		line = strings.TrimSpace(strings.TrimPrefix(line, secretPrefix))
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid Gradle property, expected KEY=VALUE: %s", line)
+		}
+
+		value, fromEnv := expandEnv(strings.TrimSpace(parts[1]), lookupEnv)
+		properties = append(properties, Property{Key: key, Value: value, Secret: secret || fromEnv})
+	}
+
+	return properties, nil
+}
+
+// expandEnv expands the $VAR and ${VAR} references to defined environment variables. Everything else is kept
+// as is, so literal values containing $ (like pa$$word) are not changed. Reports whether a non-empty value was
+// substituted.
+func expandEnv(s string, lookupEnv func(string) (string, bool)) (string, bool) {
+	var b strings.Builder
+	var fromEnv bool
+	for i := 0; i < len(s); {
+		match := envReferenceRegexp.FindStringSubmatchIndex(s[i:])
+		if match == nil {
+			b.WriteString(s[i:])
+			break
+		}
+
+		start, end := i+match[0], i+match[1]
+		name := envReferenceName(s[i:], match)
+		b.WriteString(s[i:start])
+		if value, ok := lookupEnv(name); ok {
+			b.WriteString(value)
+			fromEnv = fromEnv || value != ""
+		} else {
+			b.WriteString(s[start:end])
+		}
+		i = end
+	}
+
+	return b.String(), fromEnv
+}
+
+func envReferenceName(s string, match []int) string {
+	if match[2] >= 0 {
+		return s[match[2]:match[3]]
+	}
+	return s[match[4]:match[5]]
+}
@@ -0,0 +1,60 @@
+package gradleargs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProperties(t *testing.T) {
+	env := map[string]string{"STORE_PASSWORD": "s3cr3t", "BITRISE_BUILD_NUMBER": "42"}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	input := `
+# signing
+secret:keyPassword=p@ss=word
+storePassword=$STORE_PASSWORD
+buildNumber=build-${BITRISE_BUILD_NUMBER}
+flavor = demo
+empty=$UNDEFINED
+`
+
+	properties, err := ParseProperties(input, lookupEnv)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Property{
+		{Key: "keyPassword", Value: "p@ss=word", Secret: true},
+		{Key: "storePassword", Value: "s3cr3t", Secret: true},
+		{Key: "buildNumber", Value: "build-42", Secret: true},
+		{Key: "flavor", Value: "demo", Secret: false},
+		{Key: "empty", Value: "$UNDEFINED", Secret: false},
+	}, properties)
+	assert.Equal(t, "-PkeyPassword=p@ss=word", properties[0].Arg())
+}
+
+func TestParseProperties_LiteralDollar(t *testing.T) {
+	lookupEnv := func(key string) (string, bool) {
+		if key == "STORE_PASSWORD" {
+			return "s3cr3t", true
+		}
+		return "", false
+	}
+
+	properties, err := ParseProperties("secret:keyPassword=pa$$w0rd\nstorePassword=abc$1\nprice=5$\nmixed=${STORE_PASSWORD}$$", lookupEnv)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Property{
+		{Key: "keyPassword", Value: "pa$$w0rd", Secret: true},
+		{Key: "storePassword", Value: "abc$1", Secret: false},
+		{Key: "price", Value: "5$", Secret: false},
+		{Key: "mixed", Value: "s3cr3t$$", Secret: true},
+	}, properties)
+}
+
+func TestParseProperties_Invalid(t *testing.T) {
+	_, err := ParseProperties("justakey", func(string) (string, bool) { return "", false })
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+// Command generate refreshes the bundled list of official Gradle wrapper jar checksums.
+//
+// Usage: go generate ./step/gradlewrapper
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+)
+
+const header = `# Official Gradle wrapper jar SHA-256 checksums.
+# Source: https://services.gradle.org/versions/all (wrapperChecksumUrl of every version).
+# Generated by "go generate ./step/gradlewrapper", do not edit manually.
+`
+
+func main() {
+	output := flag.String("o", "checksums.txt", "output file")
+	flag.Parse()
+
+	checksums, err := gradlewrapper.FetchOfficialChecksums(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to fetch checksums: %s\n", err)
+		os.Exit(1)
+	}
+	sort.Strings(checksums)
+
+	content := header + strings.Join(checksums, "\n") + "\n"
+	if err := os.WriteFile(*output, []byte(content), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %s\n", *output, err)
+		os.Exit(1)
+	}
+}
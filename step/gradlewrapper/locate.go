@@ -0,0 +1,62 @@
+package gradlewrapper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ScriptName is the name of the Gradle wrapper script.
+const ScriptName = "gradlew"
+
+// ErrNotFound is returned when no Gradle wrapper script is found.
+var ErrNotFound = errors.New("gradlew not found")
+
+// Locate returns the absolute path of the Gradle wrapper script. The project dir is searched first,
+// then its parent directories up to the repository root (the first directory containing .git)
+// or the filesystem root.
+func Locate(projectDir string) (string, error) {
+	dir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		pth := filepath.Join(dir, ScriptName)
+		if info, err := os.Stat(pth); err == nil && !info.IsDir() {
+			return pth, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "", ErrNotFound
+}
+
+// EnsureExecutable adds the executable bit to the wrapper script if it is missing
+// (common when the script was committed from Windows). Returns true if the permissions were changed.
+func EnsureExecutable(pth string) (bool, error) {
+	info, err := os.Stat(pth)
+	if err != nil {
+		return false, err
+	}
+
+	mode := info.Mode()
+	if mode&0o111 != 0 {
+		return false, nil
+	}
+
+	if err := os.Chmod(pth, mode|0o111); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
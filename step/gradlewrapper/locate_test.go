@@ -0,0 +1,77 @@
+package gradlewrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createFile(t *testing.T, pth string, perm os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(pth, []byte("#!/bin/sh\n"), perm); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestLocate_InProjectDir(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, filepath.Join(root, "gradlew"), 0o755)
+
+	got, err := Locate(root)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "gradlew"), got)
+}
+
+func TestLocate_InParentDir(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, filepath.Join(root, "gradlew"), 0o755)
+	projectDir := filepath.Join(root, "android", "app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+
+	got, err := Locate(projectDir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "gradlew"), got)
+}
+
+func TestLocate_StopsAtRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, filepath.Join(root, "gradlew"), 0o755)
+	repo := filepath.Join(root, "repo")
+	projectDir := filepath.Join(repo, "android")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+
+	_, err := Locate(projectDir)
+
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestEnsureExecutable(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "gradlew")
+	createFile(t, pth, 0o644)
+
+	fixed, err := EnsureExecutable(pth)
+	assert.NoError(t, err)
+	assert.True(t, fixed)
+
+	info, err := os.Stat(pth)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	fixed, err = EnsureExecutable(pth)
+	assert.NoError(t, err)
+	assert.False(t, fixed)
+}
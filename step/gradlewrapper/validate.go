@@ -0,0 +1,263 @@
+// Package gradlewrapper inspects the Gradle wrapper (gradlew, gradle/wrapper/*) of a project.
+package gradlewrapper
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// JarRelPath is the location of the wrapper jar relative to the gradlew script.
+	JarRelPath = "gradle/wrapper/gradle-wrapper.jar"
+
+	versionsURL   = "https://services.gradle.org/versions/all"
+	fetchTimeout  = 2 * time.Minute
+	fetchParallel = 10
+)
+
+// versionChecksumURL is the wrapper jar checksum of a Gradle version, a variable so that tests can serve it locally.
+var versionChecksumURL = "https://services.gradle.org/distributions/gradle-%s-wrapper.jar.sha256"
+
+//go:generate go run ./internal/generate -o checksums.txt
+
+//go:embed checksums.txt
+var bundledChecksums string
+
+// UnknownChecksumError is returned when the wrapper jar's checksum is not an official one.
+type UnknownChecksumError struct {
+	Path     string
+	Checksum string
+}
+
+func (e UnknownChecksumError) Error() string {
+	return fmt.Sprintf("%s (sha256: %s) does not match any official Gradle wrapper checksum", e.Path, e.Checksum)
+}
+
+// ChecksumFetcher returns the list of official wrapper jar checksums.
+type ChecksumFetcher func(ctx context.Context) ([]string, error)
+
+// BundledChecksums returns the official wrapper jar checksums shipped with the step.
+func BundledChecksums() []string {
+	checksums, _ := parseChecksums(strings.NewReader(bundledChecksums))
+	return checksums
+}
+
+// ReadChecksumsFile reads additional allowed checksums from a file,
+// one SHA-256 per line, lines starting with # are ignored.
+func ReadChecksumsFile(pth string) ([]string, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return parseChecksums(f)
+}
+
+// Checksum returns the hex encoded SHA-256 of the file.
+func Checksum(pth string) (string, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Validate checks the wrapper jar against the known checksums. If the checksum is unknown, the fetchers are called
+// in order until one of them returns the checksum (the bundled list might be outdated), so cheap lookups should come
+// first. Returns the checksum of the jar.
+func Validate(ctx context.Context, jarPath string, known []string, fetchers ...ChecksumFetcher) (string, error) {
+	checksum, err := Checksum(jarPath)
+	if err != nil {
+		return "", err
+	}
+
+	if contains(known, checksum) {
+		return checksum, nil
+	}
+
+	var fetchErr error
+	for _, fetch := range fetchers {
+		if fetch == nil {
+			continue
+		}
+		official, err := fetch(ctx)
+		if err != nil {
+			fetchErr = err
+			continue
+		}
+		if contains(official, checksum) {
+			return checksum, nil
+		}
+	}
+	if fetchErr != nil {
+		return checksum, fmt.Errorf("checksum (%s) is not bundled and fetching the official checksums failed: %w", checksum, fetchErr)
+	}
+
+	return checksum, UnknownChecksumError{Path: jarPath, Checksum: checksum}
+}
+
+// VersionChecksumFetcher returns a fetcher downloading the wrapper jar checksum of a single Gradle version,
+// a single request instead of the checksums of every released version.
+func VersionChecksumFetcher(version Version) ChecksumFetcher {
+	return func(ctx context.Context) ([]string, error) {
+		ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+
+		body, err := get(ctx, &http.Client{}, fmt.Sprintf(versionChecksumURL, version.Raw))
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{strings.ToLower(strings.TrimSpace(string(body)))}, nil
+	}
+}
+
+// FetchOfficialChecksums downloads the wrapper jar checksum of every released Gradle version
+// from services.gradle.org.
+func FetchOfficialChecksums(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	client := &http.Client{}
+
+	body, err := get(ctx, client, versionsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []struct {
+		WrapperChecksumURL string `json:"wrapperChecksumUrl"`
+	}
+	if err := json.Unmarshal(body, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse Gradle versions: %w", err)
+	}
+
+	urls := make(chan string)
+	go func() {
+		defer close(urls)
+		for _, v := range versions {
+			if v.WrapperChecksumURL != "" {
+				urls <- v.WrapperChecksumURL
+			}
+		}
+	}()
+
+	var (
+		mu        sync.Mutex
+		checksums []string
+		firstErr  error
+		wg        sync.WaitGroup
+	)
+	for i := 0; i < fetchParallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range urls {
+				body, err := get(ctx, client, url)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				} else if err == nil {
+					checksums = append(checksums, strings.TrimSpace(string(body)))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(checksums) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	return unique(checksums), nil
+}
+
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func parseChecksums(r io.Reader) ([]string, error) {
+	var checksums []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		checksums = append(checksums, strings.ToLower(line))
+	}
+
+	return checksums, scanner.Err()
+}
+
+// JarPath returns the wrapper jar belonging to the given gradlew script.
+func JarPath(gradlewPath string) string {
+	return filepath.Join(filepath.Dir(gradlewPath), JarRelPath)
+}
+
+func contains(checksums []string, checksum string) bool {
+	for _, c := range checksums {
+		if strings.EqualFold(c, checksum) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unique(items []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, item := range items {
+		if seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+
+	return result
+}
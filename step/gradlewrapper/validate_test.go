@@ -0,0 +1,160 @@
+package gradlewrapper
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeJar(t *testing.T, content string) string {
+	t.Helper()
+	pth := filepath.Join(t.TempDir(), "gradle-wrapper.jar")
+	if err := os.WriteFile(pth, []byte(content), 0o644); err != nil {
+		t.Fatalf("write jar: %v", err)
+	}
+
+	return pth
+}
+
+func checksumOf(t *testing.T, pth string) string {
+	t.Helper()
+	checksum, err := Checksum(pth)
+	if err != nil {
+		t.Fatalf("checksum: %v", err)
+	}
+
+	return checksum
+}
+
+func TestValidate_KnownChecksum(t *testing.T) {
+	jar := writeJar(t, "wrapper")
+	checksum := checksumOf(t, jar)
+	fetch := func(context.Context) ([]string, error) {
+		t.Fatal("fetch should not be called for known checksums")
+		return nil, nil
+	}
+
+	got, err := Validate(context.Background(), jar, []string{"other", checksum}, fetch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, got)
+}
+
+func TestValidate_FetchedChecksum(t *testing.T) {
+	jar := writeJar(t, "wrapper")
+	checksum := checksumOf(t, jar)
+	fetch := func(context.Context) ([]string, error) {
+		return []string{checksum}, nil
+	}
+
+	_, err := Validate(context.Background(), jar, nil, fetch)
+
+	assert.NoError(t, err)
+}
+
+func TestValidate_UnknownChecksum(t *testing.T) {
+	jar := writeJar(t, "malicious")
+	fetch := func(context.Context) ([]string, error) {
+		return []string{"official"}, nil
+	}
+
+	checksum, err := Validate(context.Background(), jar, []string{"bundled"}, fetch)
+
+	assert.Equal(t, UnknownChecksumError{Path: jar, Checksum: checksum}, err)
+}
+
+func TestValidate_FetchFails(t *testing.T) {
+	jar := writeJar(t, "wrapper")
+	fetch := func(context.Context) ([]string, error) {
+		return nil, errors.New("offline")
+	}
+
+	_, err := Validate(context.Background(), jar, nil, fetch)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "offline")
+}
+
+func TestBundledChecksums(t *testing.T) {
+	for _, checksum := range BundledChecksums() {
+		decoded, err := hex.DecodeString(checksum)
+		assert.NoError(t, err, checksum)
+		assert.Len(t, decoded, 32, checksum)
+	}
+}
+
+func TestReadChecksumsFile(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "checksums.txt")
+	content := "# custom wrappers\n\nABCDEF\n  123456  \n"
+	if err := os.WriteFile(pth, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	got, err := ReadChecksumsFile(pth)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcdef", "123456"}, got)
+}
+
+func TestJarPath(t *testing.T) {
+	assert.Equal(t, "/project/gradle/wrapper/gradle-wrapper.jar", JarPath("/project/gradlew"))
+}
+
+func TestValidate_FetchersInOrder(t *testing.T) {
+	jar := writeJar(t, "wrapper")
+	checksum := checksumOf(t, jar)
+	versionFetch := func(context.Context) ([]string, error) {
+		return []string{checksum}, nil
+	}
+	allFetch := func(context.Context) ([]string, error) {
+		t.Fatal("all checksums should not be fetched if the version checksum matches")
+		return nil, nil
+	}
+
+	_, err := Validate(context.Background(), jar, nil, versionFetch, allFetch)
+
+	assert.NoError(t, err)
+}
+
+func TestValidate_FallbackFetcher(t *testing.T) {
+	jar := writeJar(t, "older-wrapper")
+	checksum := checksumOf(t, jar)
+	versionFetch := func(context.Context) ([]string, error) {
+		return []string{"other"}, nil
+	}
+	allFetch := func(context.Context) ([]string, error) {
+		return []string{"other", checksum}, nil
+	}
+
+	_, err := Validate(context.Background(), jar, nil, versionFetch, allFetch)
+
+	assert.NoError(t, err)
+}
+
+func TestVersionChecksumFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gradle-8.5-wrapper.jar.sha256" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("ABCDEF\n"))
+	}))
+	defer server.Close()
+	original := versionChecksumURL
+	versionChecksumURL = server.URL + "/gradle-%s-wrapper.jar.sha256"
+	defer func() { versionChecksumURL = original }()
+
+	checksums, err := VersionChecksumFetcher(MustParseVersion("8.5"))(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcdef"}, checksums)
+
+	_, err = VersionChecksumFetcher(MustParseVersion("0.1"))(context.Background())
+	assert.Error(t, err)
+}
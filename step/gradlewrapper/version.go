@@ -0,0 +1,102 @@
+package gradlewrapper
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PropertiesRelPath is the location of the wrapper properties relative to the gradlew script.
+const PropertiesRelPath = "gradle/wrapper/gradle-wrapper.properties"
+
+var (
+	distributionURLRegexp = regexp.MustCompile(`gradle-([^/]+?)-(?:bin|all)\.zip`)
+	versionRegexp         = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+)
+
+// Version is a Gradle version. The zero value means an unknown version.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Raw   string
+}
+
+// ParseVersion parses a Gradle version, like 8.4, 7.6.1 or 8.5-rc-1.
+func ParseVersion(version string) (Version, error) {
+	match := versionRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid Gradle version: %s", version)
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+
+	return Version{Major: major, Minor: minor, Patch: patch, Raw: version}, nil
+}
+
+// MustParseVersion is like ParseVersion but panics on invalid input. Only use it for constants.
+func MustParseVersion(version string) Version {
+	v, err := ParseVersion(version)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// IsZero reports whether the version is unknown.
+func (v Version) IsZero() bool {
+	return v.Raw == ""
+}
+
+// AtLeast reports whether v is the same or newer than other (pre-release suffixes are ignored).
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+
+	return v.Patch >= other.Patch
+}
+
+func (v Version) String() string {
+	return v.Raw
+}
+
+// ReadVersion returns the Gradle version from the distributionUrl of the wrapper properties
+// belonging to the given gradlew script.
+func ReadVersion(gradlewPath string) (Version, error) {
+	pth := filepath.Join(filepath.Dir(gradlewPath), PropertiesRelPath)
+	f, err := os.Open(pth)
+	if err != nil {
+		return Version{}, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "distributionUrl") {
+			continue
+		}
+		if match := distributionURLRegexp.FindStringSubmatch(line); match != nil {
+			return ParseVersion(match[1])
+		}
+		return Version{}, fmt.Errorf("unexpected distributionUrl in %s: %s", pth, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Version{}, err
+	}
+
+	return Version{}, fmt.Errorf("no distributionUrl in %s", pth)
+}
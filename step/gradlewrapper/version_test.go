@@ -0,0 +1,59 @@
+package gradlewrapper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]Version{
+		"8.4":             {Major: 8, Minor: 4, Raw: "8.4"},
+		"7.6.1":           {Major: 7, Minor: 6, Patch: 1, Raw: "7.6.1"},
+		"8.5-rc-1":        {Major: 8, Minor: 5, Raw: "8.5-rc-1"},
+		"8.0-milestone-1": {Major: 8, Minor: 0, Raw: "8.0-milestone-1"},
+	}
+	for input, want := range tests {
+		got, err := ParseVersion(input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseVersion("latest")
+	assert.Error(t, err)
+}
+
+func TestVersion_AtLeast(t *testing.T) {
+	assert.True(t, MustParseVersion("6.6").AtLeast(MustParseVersion("6.6")))
+	assert.True(t, MustParseVersion("7.0").AtLeast(MustParseVersion("6.6")))
+	assert.True(t, MustParseVersion("6.6.1").AtLeast(MustParseVersion("6.6")))
+	assert.False(t, MustParseVersion("6.5.1").AtLeast(MustParseVersion("6.6")))
+	assert.False(t, MustParseVersion("5.9").AtLeast(MustParseVersion("6.0")))
+}
+
+func TestReadVersion(t *testing.T) {
+	projectDir := t.TempDir()
+	pth := filepath.Join(projectDir, PropertiesRelPath)
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	content := `distributionBase=GRADLE_USER_HOME
+distributionPath=wrapper/dists
+distributionUrl=https\://services.gradle.org/distributions/gradle-8.2.1-all.zip
+zipStoreBase=GRADLE_USER_HOME
+`
+	if err := os.WriteFile(pth, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	version, err := ReadVersion(filepath.Join(projectDir, "gradlew"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, MustParseVersion("8.2.1"), version)
+
+	_, err = ReadVersion(filepath.Join(t.TempDir(), "gradlew"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+// Package initscript generates the Gradle init scripts the step injects into the build
+// (with --init-script) to configure the Android Gradle Plugin without changing the build files.
+package initscript
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Script is a Gradle init script.
+type Script struct {
+	Name    string
+	Content string
+}
+
+// Write writes the scripts into a new temporary directory. The returned cleanup function removes the directory.
+func Write(scripts []Script) ([]string, func() error, error) {
+	noop := func() error { return nil }
+	if len(scripts) == 0 {
+		return nil, noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "android-build-init-scripts")
+	if err != nil {
+		return nil, noop, err
+	}
+	cleanup := func() error {
+		return os.RemoveAll(dir)
+	}
+
+	var paths []string
+	for _, script := range scripts {
+		pth := filepath.Join(dir, script.Name)
+		if err := os.WriteFile(pth, []byte(script.Content), 0o600); err != nil {
+			_ = cleanup()
+			return nil, noop, err
+		}
+		paths = append(paths, pth)
+	}
+
+	return paths, cleanup, nil
+}
+
+// Args returns the Gradle arguments applying the init scripts.
+func Args(paths []string) []string {
+	var args []string
+	for _, pth := range paths {
+		args = append(args, "--init-script", pth)
+	}
+
+	return args
+}
+
+// groovyString returns s as a single quoted Groovy string literal, which is not interpolated.
+func groovyString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(s) + "'"
+}
+
+func groovyInt(i int) string {
+	return fmt.Sprint(i)
+}
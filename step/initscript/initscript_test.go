@@ -0,0 +1,54 @@
+package initscript
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	paths, cleanup, err := Write([]Script{{Name: "a.gradle", Content: "println 'a'"}, {Name: "b.gradle", Content: "println 'b'"}})
+	assert.NoError(t, err)
+	assert.Len(t, paths, 2)
+
+	content, err := os.ReadFile(paths[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "println 'b'", string(content))
+	assert.Equal(t, []string{"--init-script", paths[0], "--init-script", paths[1]}, Args(paths))
+
+	assert.NoError(t, cleanup())
+	_, err = os.Stat(paths[0])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWrite_NoScripts(t *testing.T) {
+	paths, cleanup, err := Write(nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, paths)
+	assert.NoError(t, cleanup())
+}
+
+func TestVersionOverride(t *testing.T) {
+	script := VersionOverride(1042, `1.2.0 'beta' $x`)
+
+	assert.Contains(t, script.Content, "def versionCodeOverride = 1042\n")
+	assert.Contains(t, script.Content, `def versionNameOverride = '1.2.0 \'beta\' $x'`+"\n")
+
+	script = VersionOverride(0, "")
+
+	assert.Contains(t, script.Content, "def versionCodeOverride = null\n")
+	assert.Contains(t, script.Content, "def versionNameOverride = null\n")
+}
+
+func TestSigning(t *testing.T) {
+	script := Signing([]string{"demoRelease", "release"})
+
+	assert.Contains(t, script.Content, "def signedVariants = ['demoRelease', 'release']\n")
+	assert.Contains(t, script.Content, "System.getenv('BITRISE_ANDROID_BUILD_KEYSTORE_PASSWORD')")
+
+	script = Signing(nil)
+
+	assert.Contains(t, script.Content, "def signedVariants = []\n")
+}
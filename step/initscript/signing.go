@@ -0,0 +1,62 @@
+package initscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Environment variables passing the signing configuration to the signing init script,
+// so that the secrets do not appear in the Gradle command.
+const (
+	KeystorePathEnvKey     = "BITRISE_ANDROID_BUILD_KEYSTORE_PATH"
+	KeystorePasswordEnvKey = "BITRISE_ANDROID_BUILD_KEYSTORE_PASSWORD"
+	KeyAliasEnvKey         = "BITRISE_ANDROID_BUILD_KEY_ALIAS"
+	KeyPasswordEnvKey      = "BITRISE_ANDROID_BUILD_KEY_PASSWORD"
+)
+
+// The listener is registered before the build script is evaluated, so it runs before the Android Gradle Plugin
+// creates the variants, and after the build script configured its own signing configs.
+const signingTemplate = `// Generated by the Android Build step: signs the release build types with the provided keystore.
+def signedVariants = %s
+
+def isSelected = { buildType ->
+    signedVariants.isEmpty() || signedVariants.any { variant ->
+        variant == buildType.name || variant.toLowerCase().endsWith(buildType.name.toLowerCase())
+    }
+}
+
+allprojects {
+    afterEvaluate {
+        if (!plugins.hasPlugin('com.android.application')) {
+            return
+        }
+
+        def bitriseSigning = android.signingConfigs.create('bitriseAndroidBuild')
+        bitriseSigning.storeFile = file(System.getenv('%s'))
+        bitriseSigning.storePassword = System.getenv('%s')
+        bitriseSigning.keyAlias = System.getenv('%s')
+        bitriseSigning.keyPassword = System.getenv('%s')
+
+        android.buildTypes.each { buildType ->
+            if (!buildType.debuggable && isSelected(buildType)) {
+                buildType.signingConfig = bitriseSigning
+            }
+        }
+    }
+}
+`
+
+// Signing returns the init script signing the non-debuggable build types of the given variants
+// (all of them if no variant is given) with the keystore passed in the signing environment variables.
+func Signing(variants []string) Script {
+	var literals []string
+	for _, variant := range variants {
+		literals = append(literals, groovyString(variant))
+	}
+
+	return Script{
+		Name: "signing.gradle",
+		Content: fmt.Sprintf(signingTemplate, "["+strings.Join(literals, ", ")+"]",
+			KeystorePathEnvKey, KeystorePasswordEnvKey, KeyAliasEnvKey, KeyPasswordEnvKey),
+	}
+}
@@ -0,0 +1,56 @@
+package initscript
+
+import "fmt"
+
+const versionTemplate = `// Generated by the Android Build step: overrides the version of every application variant output.
+def versionCodeOverride = %s
+def versionNameOverride = %s
+
+allprojects {
+    plugins.withId('com.android.application') { plugin ->
+        def agpVersion = plugin.class.classLoader.loadClass('com.android.Version').getField('ANDROID_GRADLE_PLUGIN_VERSION').get(null)
+        def agpMajor = agpVersion.tokenize('.')[0] as int
+        if (agpMajor >= 7) {
+            def androidComponents = extensions.getByName('androidComponents')
+            androidComponents.onVariants(androidComponents.selector().all(), { variant ->
+                variant.outputs.each { output ->
+                    if (versionCodeOverride != null) {
+                        output.versionCode.set(versionCodeOverride)
+                    }
+                    if (versionNameOverride != null) {
+                        output.versionName.set(versionNameOverride)
+                    }
+                }
+            })
+        } else {
+            android.applicationVariants.all { variant ->
+                variant.outputs.all { output ->
+                    if (versionCodeOverride != null) {
+                        output.versionCodeOverride = versionCodeOverride
+                    }
+                    if (versionNameOverride != null) {
+                        output.versionNameOverride = versionNameOverride
+                    }
+                }
+            }
+        }
+    }
+}
+`
+
+// VersionOverride returns the init script setting the version code and name of every application variant output.
+// A zero version code or an empty version name is left unchanged.
+func VersionOverride(versionCode int, versionName string) Script {
+	code, name := "null", "null"
+	if versionCode > 0 {
+		code = groovyInt(versionCode)
+	}
+	if versionName != "" {
+		name = groovyString(versionName)
+	}
+
+	return Script{
+		Name:    "version-override.gradle",
+		Content: fmt.Sprintf(versionTemplate, code, name),
+	}
+}
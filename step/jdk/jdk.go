@@ -0,0 +1,262 @@
+// Package jdk determines the Java version a Gradle Android project requires and finds
+// a matching JDK installed on the machine.
+package jdk
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/buildscript"
+)
+
+const (
+	agpPluginID     = "com.android.application"
+	agpLibraryID    = "com.android.library"
+	agpModule       = "com.android.tools.build:gradle"
+	javaHomeEnvKey  = "JAVA_HOME"
+	releaseFileName = "release"
+)
+
+var (
+	classpathRegexp = regexp.MustCompile(`com\.android\.tools\.build:gradle:(\d[^'"\s)]*)`)
+	pluginRegexp    = regexp.MustCompile(`id\s*\(?\s*["']com\.android\.(?:application|library)["']\s*\)?\s*version\s*\(?\s*["'](\d[^"']*)["']`)
+	toolchainRegexp = regexp.MustCompile(`(?:JavaLanguageVersion\.of|jvmToolchain)\s*\(\s*(\d+)\s*\)|jvmToolchain\s+(\d+)`)
+	javaVersionRe   = regexp.MustCompile(`version "([^"]+)"`)
+	releaseRegexp   = regexp.MustCompile(`^JAVA_VERSION="([^"]+)"`)
+)
+
+// Requirement is the minimum Java version required to build the project, with the reason.
+// A zero Version means the requirement could not be determined.
+type Requirement struct {
+	Version int
+	Reason  string
+}
+
+// Installation is a JDK installed on the machine.
+type Installation struct {
+	Home    string
+	Version int
+}
+
+// RequiredVersion determines the minimum Java version running the Gradle daemon from the Android Gradle Plugin version
+// (version catalog, buildscript classpath or plugins block).
+func RequiredVersion(projectDir string) (Requirement, error) {
+	scripts, err := buildscript.Find(projectDir)
+	if err != nil {
+		return Requirement{}, err
+	}
+
+	catalog, err := buildscript.ReadCatalog(projectDir)
+	if err != nil {
+		return Requirement{}, err
+	}
+
+	agpVersion, source := agpVersion(scripts, catalog)
+	if agpVersion == "" {
+		return Requirement{}, nil
+	}
+	version := javaVersionForAGP(agpVersion)
+	if version == 0 {
+		return Requirement{}, nil
+	}
+
+	return Requirement{
+		Version: version,
+		Reason:  fmt.Sprintf("Android Gradle Plugin %s (%s) requires Java %d", agpVersion, source, version),
+	}, nil
+}
+
+// ToolchainVersion returns the highest Java version of the toolchain settings (java.toolchain.languageVersion
+// or kotlin.jvmToolchain). Gradle provisions the toolchain for the compilation, the daemon does not need to run on it.
+func ToolchainVersion(projectDir string) (Requirement, error) {
+	scripts, err := buildscript.Find(projectDir)
+	if err != nil {
+		return Requirement{}, err
+	}
+
+	var toolchain Requirement
+	for _, script := range scripts {
+		for _, match := range toolchainRegexp.FindAllStringSubmatch(script.Code(), -1) {
+			value := match[1]
+			if value == "" {
+				value = match[2]
+			}
+			version, err := strconv.Atoi(value)
+			if err != nil || version <= toolchain.Version {
+				continue
+			}
+			toolchain = Requirement{
+				Version: version,
+				Reason:  fmt.Sprintf("the Java toolchain in %s uses Java %d", script.Path, version),
+			}
+		}
+	}
+
+	return toolchain, nil
+}
+
+func agpVersion(scripts []buildscript.Script, catalog buildscript.Catalog) (string, string) {
+	if version := catalog.PluginVersion(agpPluginID); version != "" {
+		return version, buildscript.CatalogRelPath
+	}
+	if version := catalog.PluginVersion(agpLibraryID); version != "" {
+		return version, buildscript.CatalogRelPath
+	}
+	if version := catalog.LibraryVersion(agpModule); version != "" {
+		return version, buildscript.CatalogRelPath
+	}
+	if version, script, ok := buildscript.FindFirst(scripts, classpathRegexp); ok {
+		return version, script.Path
+	}
+	if version, script, ok := buildscript.FindFirst(scripts, pluginRegexp); ok {
+		return version, script.Path
+	}
+
+	return "", ""
+}
+
+// javaVersionForAGP returns the minimum Java version of an Android Gradle Plugin version.
+func javaVersionForAGP(agpVersion string) int {
+	major, err := strconv.Atoi(strings.SplitN(agpVersion, ".", 2)[0])
+	if err != nil {
+		return 0
+	}
+
+	switch {
+	case major >= 8:
+		return 17
+	case major == 7:
+		return 11
+	default:
+		return 8
+	}
+}
+
+// ParseVersionOutput returns the major version from the output of `java -version`,
+// for example `openjdk version "17.0.2" 2022-01-18` or `java version "1.8.0_292"`.
+func ParseVersionOutput(output string) (int, error) {
+	match := javaVersionRe.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("unexpected java -version output: %s", output)
+	}
+
+	return majorVersion(match[1])
+}
+
+func majorVersion(version string) (int, error) {
+	version = strings.TrimPrefix(version, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end != -1 {
+		version = version[:end]
+	}
+
+	return strconv.Atoi(version)
+}
+
+// SearchPaths returns the glob patterns of the common JDK install locations
+// on Linux and macOS, including SDKMAN!, asdf and Homebrew installs.
+func SearchPaths() []string {
+	patterns := []string{
+		"/usr/lib/jvm/*",
+		"/Library/Java/JavaVirtualMachines/*/Contents/Home",
+		"/opt/homebrew/opt/openjdk*/libexec/openjdk.jdk/Contents/Home",
+		"/usr/local/opt/openjdk*/libexec/openjdk.jdk/Contents/Home",
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		patterns = append(patterns,
+			filepath.Join(home, "Library/Java/JavaVirtualMachines/*/Contents/Home"),
+			filepath.Join(home, ".sdkman/candidates/java/*"),
+			filepath.Join(home, ".asdf/installs/java/*"),
+			filepath.Join(home, ".jenv/versions/*"),
+		)
+	}
+
+	// CI images commonly expose the preinstalled JDKs as JAVA_HOME_17_X64 style env vars.
+	for _, env := range os.Environ() {
+		key := strings.SplitN(env, "=", 2)
+		if len(key) == 2 && strings.HasPrefix(key[0], javaHomeEnvKey+"_") && key[1] != "" {
+			patterns = append(patterns, key[1])
+		}
+	}
+
+	return patterns
+}
+
+// FindInstallations returns the JDKs found at the given glob patterns, the ones without
+// a readable release file are skipped.
+func FindInstallations(patterns []string) []Installation {
+	seen := map[string]bool{}
+	var installations []Installation
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		for _, home := range matches {
+			resolved, err := filepath.EvalSymlinks(home)
+			if err != nil || seen[resolved] {
+				continue
+			}
+			seen[resolved] = true
+
+			if _, err := os.Stat(filepath.Join(home, "bin", "java")); err != nil {
+				continue
+			}
+			version, err := releaseVersion(home)
+			if err != nil {
+				continue
+			}
+			installations = append(installations, Installation{Home: home, Version: version})
+		}
+	}
+
+	return installations
+}
+
+func releaseVersion(home string) (int, error) {
+	f, err := os.Open(filepath.Join(home, releaseFileName))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if match := releaseRegexp.FindStringSubmatch(scanner.Text()); match != nil {
+			return majorVersion(match[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("no JAVA_VERSION in %s", f.Name())
+}
+
+// Select returns the installation matching the required version, or the closest newer one.
+func Select(installations []Installation, required int) (Installation, bool) {
+	var candidates []Installation
+	for _, installation := range installations {
+		if installation.Version >= required {
+			candidates = append(candidates, installation)
+		}
+	}
+	if len(candidates) == 0 {
+		return Installation{}, false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Version < candidates[j].Version
+	})
+
+	return candidates[0], true
+}
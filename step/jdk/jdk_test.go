@@ -0,0 +1,149 @@
+package jdk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, pth, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(pth, []byte(content), 0o755); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestRequiredVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		version int
+	}{
+		{
+			name:    "no AGP",
+			files:   map[string]string{"build.gradle": ""},
+			version: 0,
+		},
+		{
+			name: "buildscript classpath",
+			files: map[string]string{"build.gradle": `buildscript {
+    dependencies {
+        classpath 'com.android.tools.build:gradle:7.4.2'
+    }
+}`},
+			version: 11,
+		},
+		{
+			name: "plugins block",
+			files: map[string]string{"settings.gradle.kts": `plugins {
+    id("com.android.application") version "8.1.0" apply false
+}`},
+			version: 17,
+		},
+		{
+			name: "version catalog",
+			files: map[string]string{
+				"build.gradle.kts":          `plugins { alias(libs.plugins.android.application) apply false }`,
+				"gradle/libs.versions.toml": "[versions]\nagp = \"8.3.0\"\n[plugins]\nandroid-application = { id = \"com.android.application\", version.ref = \"agp\" }\n",
+			},
+			version: 17,
+		},
+		{
+			name:    "old AGP",
+			files:   map[string]string{"build.gradle": `classpath "com.android.tools.build:gradle:4.2.2"`},
+			version: 8,
+		},
+		{
+			name: "toolchain does not raise the requirement",
+			files: map[string]string{
+				"build.gradle":     `classpath "com.android.tools.build:gradle:8.2.0"`,
+				"app/build.gradle": "kotlin {\n    jvmToolchain(21)\n}",
+			},
+			version: 17,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(projectDir, name), content)
+			}
+
+			got, err := RequiredVersion(projectDir)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.version, got.Version)
+			if tt.version > 0 {
+				assert.NotEmpty(t, got.Reason)
+			}
+		})
+	}
+}
+
+func TestToolchainVersion(t *testing.T) {
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, "build.gradle"), `classpath "com.android.tools.build:gradle:7.0.0"`)
+
+	toolchain, err := ToolchainVersion(projectDir)
+	assert.NoError(t, err)
+	assert.Equal(t, Requirement{}, toolchain)
+
+	writeFile(t, filepath.Join(projectDir, "app", "build.gradle"), "java {\n    toolchain {\n        languageVersion = JavaLanguageVersion.of(17)\n    }\n}")
+	writeFile(t, filepath.Join(projectDir, "lib", "build.gradle.kts"), "kotlin {\n    jvmToolchain(21)\n}")
+
+	toolchain, err = ToolchainVersion(projectDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 21, toolchain.Version)
+	assert.Equal(t, "the Java toolchain in "+filepath.Join(projectDir, "lib", "build.gradle.kts")+" uses Java 21", toolchain.Reason)
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	tests := map[string]int{
+		`openjdk version "17.0.2" 2022-01-18`:                                17,
+		"java version \"1.8.0_292\"\nJava(TM) SE Runtime Environment":        8,
+		`openjdk version "21" 2023-09-19`:                                    21,
+		"Picked up JAVA_TOOL_OPTIONS: -Xmx1g\nopenjdk version \"11.0.20.1\"": 11,
+	}
+	for output, want := range tests {
+		got, err := ParseVersionOutput(output)
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, output)
+	}
+
+	_, err := ParseVersionOutput("command not found")
+	assert.Error(t, err)
+}
+
+func TestFindInstallationsAndSelect(t *testing.T) {
+	jvmDir := t.TempDir()
+	for name, version := range map[string]string{"java-11": "11.0.20", "java-17": "17.0.8", "java-21": "21.0.1"} {
+		writeFile(t, filepath.Join(jvmDir, name, "bin", "java"), "")
+		writeFile(t, filepath.Join(jvmDir, name, "release"), "IMPLEMENTOR=\"Eclipse Adoptium\"\nJAVA_VERSION=\""+version+"\"\n")
+	}
+	writeFile(t, filepath.Join(jvmDir, "broken", "bin", "java"), "")
+
+	installations := FindInstallations([]string{filepath.Join(jvmDir, "*")})
+
+	assert.ElementsMatch(t, []Installation{
+		{Home: filepath.Join(jvmDir, "java-11"), Version: 11},
+		{Home: filepath.Join(jvmDir, "java-17"), Version: 17},
+		{Home: filepath.Join(jvmDir, "java-21"), Version: 21},
+	}, installations)
+
+	selected, ok := Select(installations, 17)
+	assert.True(t, ok)
+	assert.Equal(t, 17, selected.Version)
+
+	selected, ok = Select(installations, 12)
+	assert.True(t, ok)
+	assert.Equal(t, 17, selected.Version)
+
+	_, ok = Select(installations, 22)
+	assert.False(t, ok)
+}
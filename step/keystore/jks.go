@@ -0,0 +1,194 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+const (
+	jksMagic   = 0xfeedfeed
+	jceksMagic = 0xcececece
+
+	jksPrivateKeyTag  = 1
+	jksTrustedCertTag = 2
+
+	jksIntegritySalt = "Mighty Aphrodite"
+	jksKeySaltSize   = sha1.Size
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// jksReader reads the big-endian structures of a JKS keystore.
+type jksReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (j *jksReader) uint32() uint32 {
+	var v uint32
+	if j.err == nil {
+		j.err = binary.Read(j.r, binary.BigEndian, &v)
+	}
+	return v
+}
+
+func (j *jksReader) uint64() uint64 {
+	var v uint64
+	if j.err == nil {
+		j.err = binary.Read(j.r, binary.BigEndian, &v)
+	}
+	return v
+}
+
+func (j *jksReader) bytes(n int) []byte {
+	if j.err != nil {
+		return nil
+	}
+	if n < 0 || n > j.r.Len() {
+		j.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := make([]byte, n)
+	_, j.err = io.ReadFull(j.r, b)
+	return b
+}
+
+// utf reads a Java modified UTF-8 string, which matches UTF-8 for the characters of aliases and certificate types.
+func (j *jksReader) utf() string {
+	var length uint16
+	if j.err == nil {
+		j.err = binary.Read(j.r, binary.BigEndian, &length)
+	}
+	return string(j.bytes(int(length)))
+}
+
+func (j *jksReader) certificate() *x509.Certificate {
+	if certType := j.utf(); j.err == nil && certType != "X.509" {
+		j.err = fmt.Errorf("unsupported certificate type: %s", certType)
+	}
+	der := j.bytes(int(j.uint32()))
+	if j.err != nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		j.err = err
+	}
+	return cert
+}
+
+func parseJKS(data []byte, password string) (*Keystore, error) {
+	if len(data) < sha1.Size {
+		return nil, errors.New("invalid JKS keystore")
+	}
+
+	content, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	h := sha1.New()
+	h.Write(jksPassword(password))
+	h.Write([]byte(jksIntegritySalt))
+	h.Write(content)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return nil, ErrInvalidPassword
+	}
+
+	r := &jksReader{r: bytes.NewReader(content)}
+	r.uint32() // magic
+	version := r.uint32()
+	if r.err == nil && version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported JKS version: %d", version)
+	}
+
+	keystore := &Keystore{Format: FormatJKS}
+	count := r.uint32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		tag := r.uint32()
+		alias := r.utf()
+		r.uint64() // creation time
+
+		switch tag {
+		case jksPrivateKeyTag:
+			encrypted := r.bytes(int(r.uint32()))
+			var certificates []*x509.Certificate
+			chainLength := r.uint32()
+			for c := uint32(0); c < chainLength && r.err == nil; c++ {
+				certificates = append(certificates, r.certificate())
+			}
+			keystore.addKey(keyEntry{
+				alias:        alias,
+				certificates: certificates,
+				decrypt: func(password string) (crypto.Signer, error) {
+					return decryptJKSKey(encrypted, password)
+				},
+			})
+		case jksTrustedCertTag:
+			r.certificate()
+		default:
+			return nil, fmt.Errorf("unsupported JKS entry type: %d", tag)
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid JKS keystore: %s", r.err)
+	}
+
+	return keystore, nil
+}
+
+// decryptJKSKey decrypts a private key protected by the proprietary JKS key protection algorithm:
+// the key is XOR-ed with a SHA-1 based keystream, followed by the SHA-1 checksum of the password and the key.
+func decryptJKSKey(data []byte, password string) (crypto.Signer, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid JKS private key: %s", err)
+	}
+
+	encrypted := info.EncryptedData
+	if len(encrypted) < jksKeySaltSize+sha1.Size {
+		return nil, errors.New("invalid JKS private key")
+	}
+	salt := encrypted[:jksKeySaltSize]
+	checksum := encrypted[len(encrypted)-sha1.Size:]
+	encrypted = encrypted[jksKeySaltSize : len(encrypted)-sha1.Size]
+
+	passwordBytes := jksPassword(password)
+	plain := make([]byte, len(encrypted))
+	keystream := salt
+	for i := range encrypted {
+		if i%sha1.Size == 0 {
+			h := sha1.New()
+			h.Write(passwordBytes)
+			h.Write(keystream)
+			keystream = h.Sum(nil)
+		}
+		plain[i] = encrypted[i] ^ keystream[i%sha1.Size]
+	}
+
+	h := sha1.New()
+	h.Write(passwordBytes)
+	h.Write(plain)
+	if !bytes.Equal(h.Sum(nil), checksum) {
+		return nil, ErrInvalidKeyPassword
+	}
+
+	return parsePrivateKey(plain)
+}
+
+// jksPassword returns the password as big-endian UTF-16 code units.
+func jksPassword(password string) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(password)) {
+		b = append(b, byte(unit>>8), byte(unit))
+	}
+	return b
+}
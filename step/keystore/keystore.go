@@ -0,0 +1,150 @@
+// Package keystore reads the signing keys and certificates of Java KeyStore (JKS) and PKCS#12 keystores,
+// the formats created by keytool and Android Studio.
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Errors of opening a keystore and reading its keys.
+var (
+	ErrInvalidPassword    = errors.New("keystore password is incorrect")
+	ErrAliasNotFound      = errors.New("key alias not found")
+	ErrInvalidKeyPassword = errors.New("key password is incorrect")
+)
+
+// Formats of the keystore.
+const (
+	FormatJKS    = "JKS"
+	FormatPKCS12 = "PKCS12"
+)
+
+// Key is a private key entry of the keystore.
+type Key struct {
+	PrivateKey crypto.Signer
+	// Certificates is the certificate chain of the key, starting with the key's certificate.
+	Certificates []*x509.Certificate
+}
+
+// Keystore is an opened keystore, the private keys are decrypted on access.
+type Keystore struct {
+	Format string
+	keys   map[string]keyEntry
+}
+
+type keyEntry struct {
+	alias        string
+	certificates []*x509.Certificate
+	decrypt      func(password string) (crypto.Signer, error)
+}
+
+// Open reads and opens the keystore at the given path.
+func Open(pth, password string) (*Keystore, error) {
+	data, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data, password)
+}
+
+// Parse opens the keystore, the format is detected from the content. The keystore password is verified,
+// and for PKCS#12 keystores it is used to decrypt the certificates.
+func Parse(data []byte, password string) (*Keystore, error) {
+	if len(data) >= 4 {
+		switch binary.BigEndian.Uint32(data) {
+		case jksMagic:
+			return parseJKS(data, password)
+		case jceksMagic:
+			return nil, errors.New("JCEKS keystores are not supported, convert the keystore to PKCS12 with keytool -importkeystore")
+		}
+	}
+
+	return parsePKCS12(data, password)
+}
+
+// Aliases returns the aliases of the private key entries.
+func (k *Keystore) Aliases() []string {
+	var aliases []string
+	for _, entry := range k.keys {
+		aliases = append(aliases, entry.alias)
+	}
+	sort.Strings(aliases)
+
+	return aliases
+}
+
+// Certificates returns the certificate chain of the private key entry, without decrypting the key.
+func (k *Keystore) Certificates(alias string) ([]*x509.Certificate, error) {
+	entry, ok := k.keys[strings.ToLower(alias)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAliasNotFound, alias)
+	}
+
+	return entry.certificates, nil
+}
+
+// Key decrypts the private key entry. Aliases are case-insensitive, as in keytool.
+func (k *Keystore) Key(alias, password string) (Key, error) {
+	entry, ok := k.keys[strings.ToLower(alias)]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %s", ErrAliasNotFound, alias)
+	}
+
+	privateKey, err := entry.decrypt(password)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if len(entry.certificates) == 0 {
+		return Key{}, fmt.Errorf("no certificate for key: %s", alias)
+	}
+	if !publicKeysEqual(privateKey.Public(), entry.certificates[0].PublicKey) {
+		return Key{}, fmt.Errorf("certificate does not match the key: %s", alias)
+	}
+
+	return Key{PrivateKey: privateKey, Certificates: entry.certificates}, nil
+}
+
+func (k *Keystore) addKey(entry keyEntry) {
+	if k.keys == nil {
+		k.keys = map[string]keyEntry{}
+	}
+	k.keys[strings.ToLower(entry.alias)] = entry
+}
+
+// parsePrivateKey parses a PKCS#8 private key, errors are reported as incorrect password,
+// as decrypting with a wrong password results in garbage.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, ErrInvalidKeyPassword
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+
+	return signer, nil
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	aDER, err := x509.MarshalPKIXPublicKey(a)
+	if err != nil {
+		return false
+	}
+	bDER, err := x509.MarshalPKIXPublicKey(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(aDER, bDER)
+}
@@ -0,0 +1,223 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The PKCS12 test keystores were generated with OpenSSL 3, with the store-pass password:
+//   openssl req -x509 -newkey rsa:2048 -nodes -keyout key.pem -out cert.pem -days 9000 -subj "/CN=Android Build Test"
+//   openssl pkcs12 -export -in cert.pem -inkey key.pem -name upload -passout pass:store-pass -out rsa-aes.p12
+//   openssl pkcs12 -export -legacy -in cert.pem -inkey key.pem -name upload -passout pass:store-pass -out rsa-legacy.p12
+//   openssl req -x509 -newkey ec -pkeyopt ec_paramgen_curve:P-256 -nodes -keyout eckey.pem -out eccert.pem -days 9000 -subj "/CN=Android Build Test EC"
+//   openssl pkcs12 -export -in eccert.pem -inkey eckey.pem -name ec-upload -passout pass:store-pass -out ec-aes.p12
+
+func TestOpen_PKCS12(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		alias      string
+		commonName string
+		keyType    interface{}
+	}{
+		{name: "PBES2 AES-256", path: "testdata/rsa-aes.p12", alias: "upload", commonName: "Android Build Test", keyType: &rsa.PrivateKey{}},
+		{name: "legacy RC2 and 3DES", path: "testdata/rsa-legacy.p12", alias: "upload", commonName: "Android Build Test", keyType: &rsa.PrivateKey{}},
+		{name: "EC key", path: "testdata/ec-aes.p12", alias: "ec-upload", commonName: "Android Build Test EC", keyType: &ecdsa.PrivateKey{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keystore, err := Open(tt.path, "store-pass")
+			if err != nil {
+				t.Fatalf("failed to open keystore: %s", err)
+			}
+			assert.Equal(t, FormatPKCS12, keystore.Format)
+			assert.Equal(t, []string{tt.alias}, keystore.Aliases())
+
+			key, err := keystore.Key(tt.alias, "store-pass")
+			if err != nil {
+				t.Fatalf("failed to decrypt key: %s", err)
+			}
+			assert.IsType(t, tt.keyType, key.PrivateKey)
+			assert.Len(t, key.Certificates, 1)
+			assert.Equal(t, tt.commonName, key.Certificates[0].Subject.CommonName)
+
+			_, err = Open(tt.path, "wrong-pass")
+			assert.Equal(t, ErrInvalidPassword, err)
+		})
+	}
+}
+
+func TestKeystore_Key_AliasNotFound(t *testing.T) {
+	keystore, err := Open("testdata/rsa-aes.p12", "store-pass")
+	if err != nil {
+		t.Fatalf("failed to open keystore: %s", err)
+	}
+
+	_, err = keystore.Key("release", "store-pass")
+	assert.True(t, errors.Is(err, ErrAliasNotFound))
+
+	_, err = keystore.Certificates("release")
+	assert.True(t, errors.Is(err, ErrAliasNotFound))
+}
+
+func TestOpen_JKS(t *testing.T) {
+	privateKey, certificate := newTestKey(t)
+	data := newJKS(t, "store-pass", "Upload", "key-pass", privateKey, certificate)
+
+	keystore, err := Parse(data, "store-pass")
+	if err != nil {
+		t.Fatalf("failed to open keystore: %s", err)
+	}
+	assert.Equal(t, FormatJKS, keystore.Format)
+	assert.Equal(t, []string{"Upload"}, keystore.Aliases())
+
+	key, err := keystore.Key("upload", "key-pass")
+	if err != nil {
+		t.Fatalf("failed to decrypt key: %s", err)
+	}
+	assert.Equal(t, privateKey, key.PrivateKey)
+	assert.Equal(t, certificate.Raw, key.Certificates[0].Raw)
+
+	_, err = keystore.Key("upload", "store-pass")
+	assert.Equal(t, ErrInvalidKeyPassword, err)
+
+	_, err = Parse(data, "wrong-pass")
+	assert.Equal(t, ErrInvalidPassword, err)
+}
+
+func TestOpen_JKS_NonASCIIPasswords(t *testing.T) {
+	privateKey, certificate := newTestKey(t)
+	data := newJKS(t, "tárolójelszó-🔑", "upload", "kulcsjelszó-🔑", privateKey, certificate)
+
+	keystore, err := Parse(data, "tárolójelszó-🔑")
+	if err != nil {
+		t.Fatalf("failed to open keystore: %s", err)
+	}
+	key, err := keystore.Key("upload", "kulcsjelszó-🔑")
+	if err != nil {
+		t.Fatalf("failed to decrypt key: %s", err)
+	}
+	assert.Equal(t, privateKey, key.PrivateKey)
+}
+
+func Test_pkcs12KDF(t *testing.T) {
+	// Test vectors of the golang.org/x/crypto/pkcs12 package.
+	key := pkcs12KDF(crypto.SHA1, bmpString("sesame"), []byte("\xff\xff\xff\xff\xff\xff\xff\xff"), 2048, pkcs12KeyID, 24)
+	assert.Equal(t, []byte("\x7c\xd9\xfd\x3e\x2b\x3b\xe7\x69\x1a\x44\xe3\xbe\xf0\xf9\xea\x0f\xb9\xb8\x97\xd4\xe3\x25\xd9\xd1"), key)
+
+	// I_j ends up with a leading zero byte.
+	key = pkcs12KDF(crypto.SHA1, []byte("\x00\x00"), []byte("\xf3\x7e\x05\xb5\x18\x32\x4b\x4b"), 2048, pkcs12KeyID, 24)
+	assert.Equal(t, []byte("\x00\xf7\x59\xff\x47\xd1\x4d\xd0\x36\x65\xd5\x94\x3c\xb3\xc4\xa3\x9a\x25\x55\xc0\x2a\xed\x66\xe1"), key)
+}
+
+func newTestKey(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Android Build Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	return privateKey, certificate
+}
+
+// newJKS creates a JKS keystore with a single private key entry, as keytool does.
+// The format constants are spelled out instead of reusing the ones of the parser, so that the test catches
+// a mistake in them.
+func newJKS(t *testing.T, storePassword, alias, keyPassword string, privateKey *rsa.PrivateKey, certificate *x509.Certificate) []byte {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	salt := make([]byte, 20)
+	if _, err := rand.Read(salt); err != nil {
+		t.Fatalf("failed to generate salt: %s", err)
+	}
+	passwordBytes := utf16BE(keyPassword)
+	encrypted := append([]byte{}, salt...)
+	keystream := salt
+	for i, b := range pkcs8 {
+		if i%sha1.Size == 0 {
+			sum := sha1.Sum(append(append([]byte{}, passwordBytes...), keystream...))
+			keystream = sum[:]
+		}
+		encrypted = append(encrypted, b^keystream[i%sha1.Size])
+	}
+	checksum := sha1.Sum(append(append([]byte{}, passwordBytes...), pkcs8...))
+	encrypted = append(encrypted, checksum[:]...)
+
+	protectedKey, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}, Parameters: asn1.NullRawValue},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			t.Fatalf("failed to write keystore: %s", err)
+		}
+	}
+	writeUTF := func(s string) {
+		write(uint16(len(s)))
+		buf.WriteString(s)
+	}
+
+	write(uint32(0xfeedfeed))
+	write(uint32(2))
+	write(uint32(1))
+	write(uint32(1))
+	writeUTF(alias)
+	write(uint64(time.Now().UnixNano() / int64(time.Millisecond)))
+	write(uint32(len(protectedKey)))
+	buf.Write(protectedKey)
+	write(uint32(1))
+	writeUTF("X.509")
+	write(uint32(len(certificate.Raw)))
+	buf.Write(certificate.Raw)
+
+	digest := sha1.Sum(append(append(utf16BE(storePassword), "Mighty Aphrodite"...), buf.Bytes()...))
+
+	return append(buf.Bytes(), digest[:]...)
+}
+
+// utf16BE encodes the password as Java's String.toCharArray does, in big-endian byte order.
+func utf16BE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.BigEndian.PutUint16(b[2*i:], unit)
+	}
+	return b
+}
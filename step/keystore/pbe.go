@@ -0,0 +1,279 @@
+package keystore
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"unicode/utf16"
+
+	// Registers the digest algorithms of the password based encryption schemes.
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/keystore/internal/rc2"
+)
+
+var (
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd128BitRC2CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 5}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+	oidPBES2                         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2                        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// errInvalidInput is returned when the decrypted data has invalid padding.
+var errInvalidInput = errors.New("decryption failed")
+
+// Purposes of the PKCS#12 key derivation function.
+const (
+	pkcs12KeyID = 1
+	pkcs12IVID  = 2
+	pkcs12MACID = 3
+)
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPBE decrypts data encrypted with a PKCS#12 or a PBES2 password based encryption scheme.
+// errInvalidInput is returned if the padding is invalid, which usually means incorrect password.
+func decryptPBE(algorithm pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+	block, iv, err := pbeCipher(algorithm, password)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errInvalidInput
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, errInvalidInput
+	}
+	for _, b := range plain[len(plain)-padding:] {
+		if int(b) != padding {
+			return nil, errInvalidInput
+		}
+	}
+
+	return plain[:len(plain)-padding], nil
+}
+
+func pbeCipher(algorithm pkix.AlgorithmIdentifier, password string) (cipher.Block, []byte, error) {
+	if algorithm.Algorithm.Equal(oidPBES2) {
+		return pbes2Cipher(algorithm.Parameters.FullBytes, password)
+	}
+
+	var params pbeParams
+	if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, nil, fmt.Errorf("invalid PBE parameters: %s", err)
+	}
+	bmpPassword := bmpString(password)
+	derive := func(id byte, size int) []byte {
+		return pkcs12KDF(crypto.SHA1, bmpPassword, params.Salt, params.Iterations, id, size)
+	}
+
+	var block cipher.Block
+	var err error
+	switch {
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC):
+		block, err = des.NewTripleDESCipher(derive(pkcs12KeyID, 24))
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd128BitRC2CBC):
+		block, err = rc2.New(derive(pkcs12KeyID, 16), 128)
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+		block, err = rc2.New(derive(pkcs12KeyID, 5), 40)
+	default:
+		return nil, nil, fmt.Errorf("unsupported encryption algorithm: %s", algorithm.Algorithm)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return block, derive(pkcs12IVID, block.BlockSize()), nil
+}
+
+func pbes2Cipher(paramsDER []byte, password string) (cipher.Block, []byte, error) {
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(paramsDER, &params); err != nil {
+		return nil, nil, fmt.Errorf("invalid PBES2 parameters: %s", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, fmt.Errorf("unsupported key derivation function: %s", params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, nil, fmt.Errorf("invalid PBKDF2 parameters: %s", err)
+	}
+
+	prf := crypto.SHA1
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA1):
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = crypto.SHA256
+	case kdfParams.PRF.Algorithm.Equal(oidHMACWithSHA512):
+		prf = crypto.SHA512
+	default:
+		return nil, nil, fmt.Errorf("unsupported PBKDF2 function: %s", kdfParams.PRF.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, fmt.Errorf("invalid PBES2 IV: %s", err)
+	}
+
+	var keySize int
+	newCipher := aes.NewCipher
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keySize = 16
+	case scheme.Equal(oidAES192CBC):
+		keySize = 24
+	case scheme.Equal(oidAES256CBC):
+		keySize = 32
+	case scheme.Equal(oidDESEDE3CBC):
+		keySize = 24
+		newCipher = des.NewTripleDESCipher
+	default:
+		return nil, nil, fmt.Errorf("unsupported encryption scheme: %s", scheme)
+	}
+
+	block, err := newCipher(pbkdf2(prf.New, []byte(password), kdfParams.Salt, kdfParams.Iterations, keySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, nil, errors.New("invalid PBES2 IV length")
+	}
+
+	return block, iv, nil
+}
+
+// pbkdf2 derives a key with PBKDF2 (RFC 8018) using HMAC with the given hash function.
+func pbkdf2(newHash func() hash.Hash, password, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(newHash, password)
+	var key []byte
+	for block := uint32(1); len(key) < size; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:size]
+}
+
+// pkcs12KDF derives key material with the PKCS#12 key derivation function (RFC 7292, appendix B).
+func pkcs12KDF(h crypto.Hash, password, salt []byte, iterations int, id byte, size int) []byte {
+	v := h.New().BlockSize()
+	u := h.Size()
+
+	repeat := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(b)+v-1)/v))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+	i := append(repeat(salt), repeat(password)...)
+
+	var key []byte
+	for len(key) < size {
+		hasher := h.New()
+		hasher.Write(d)
+		hasher.Write(i)
+		a := hasher.Sum(nil)
+		for r := 1; r < iterations; r++ {
+			hasher.Reset()
+			hasher.Write(a)
+			a = hasher.Sum(a[:0])
+		}
+		key = append(key, a...)
+
+		if len(key) >= size {
+			break
+		}
+
+		// I_j = (I_j + B + 1) mod 2^(v*8) for every v byte block of I, where B is A repeated to v bytes.
+		b := new(big.Int).SetBytes(repeat(a[:u]))
+		b.Add(b, big.NewInt(1))
+		modulus := new(big.Int).Lsh(big.NewInt(1), uint(v*8))
+		for j := 0; j < len(i); j += v {
+			block := new(big.Int).SetBytes(i[j : j+v])
+			block.Add(block, b).Mod(block, modulus)
+			blockBytes := block.Bytes()
+			for k := range i[j : j+v] {
+				i[j+k] = 0
+			}
+			copy(i[j+v-len(blockBytes):j+v], blockBytes)
+		}
+	}
+
+	return key[:size]
+}
+
+// bmpString returns the password as a null terminated, big-endian UTF-16 string, as used by PKCS#12.
+func bmpString(s string) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(unit>>8), byte(unit))
+	}
+	return append(b, 0, 0)
+}
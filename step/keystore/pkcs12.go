@@ -0,0 +1,311 @@
+package keystore
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Certificate     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+)
+
+type pfx struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+// pkcs12Key is a private key bag and its identifying attributes.
+type pkcs12Key struct {
+	bag          safeBag
+	friendlyName string
+	localKeyID   string
+}
+
+func parsePKCS12(data []byte, password string) (*Keystore, error) {
+	var p pfx
+	rest, err := asn1.Unmarshal(data, &p)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("unknown keystore format, JKS or PKCS12 keystore expected")
+	}
+	if p.Version != 3 {
+		return nil, fmt.Errorf("unsupported PKCS12 version: %d", p.Version)
+	}
+	if !p.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, errors.New("PKCS12 keystores with public key integrity mode are not supported")
+	}
+
+	var authSafeData []byte
+	if _, err := asn1.Unmarshal(p.AuthSafe.Content.Bytes, &authSafeData); err != nil {
+		return nil, fmt.Errorf("invalid PKCS12 keystore: %s", err)
+	}
+	if len(p.MacData.Mac.Algorithm.Algorithm) > 0 {
+		if err := verifyMAC(p.MacData, authSafeData, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var authSafe []contentInfo
+	if _, err := asn1.Unmarshal(authSafeData, &authSafe); err != nil {
+		return nil, fmt.Errorf("invalid PKCS12 keystore: %s", err)
+	}
+
+	var keys []pkcs12Key
+	var certificates []*x509.Certificate
+	certificatesByKeyID := map[string]*x509.Certificate{}
+	for _, info := range authSafe {
+		bags, err := decodeSafeContents(info, password)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, bag := range bags {
+			friendlyName, localKeyID := bagAttributes(bag)
+			switch {
+			case bag.ID.Equal(oidPKCS8ShroudedKeyBag), bag.ID.Equal(oidKeyBag):
+				keys = append(keys, pkcs12Key{bag: bag, friendlyName: friendlyName, localKeyID: localKeyID})
+			case bag.ID.Equal(oidCertBag):
+				var cb certBag
+				if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil {
+					return nil, fmt.Errorf("invalid PKCS12 certificate: %s", err)
+				}
+				if !cb.ID.Equal(oidX509Certificate) {
+					continue
+				}
+				certificate, err := x509.ParseCertificate(cb.Data)
+				if err != nil {
+					return nil, fmt.Errorf("invalid PKCS12 certificate: %s", err)
+				}
+				certificates = append(certificates, certificate)
+				if localKeyID != "" {
+					certificatesByKeyID[localKeyID] = certificate
+				}
+			}
+		}
+	}
+
+	keystore := &Keystore{Format: FormatPKCS12}
+	for i, key := range keys {
+		alias := key.friendlyName
+		if alias == "" {
+			alias = strconv.Itoa(i + 1)
+		}
+
+		var chain []*x509.Certificate
+		if leaf, ok := certificatesByKeyID[key.localKeyID]; ok {
+			chain = certificateChain(leaf, certificates)
+		}
+
+		bag := key.bag
+		keystore.addKey(keyEntry{
+			alias:        alias,
+			certificates: chain,
+			decrypt: func(keyPassword string) (crypto.Signer, error) {
+				return decryptPKCS12Key(bag, keyPassword, password)
+			},
+		})
+	}
+
+	return keystore, nil
+}
+
+// verifyMAC checks the integrity of the keystore, which fails if the password is incorrect.
+func verifyMAC(m macData, content []byte, password string) error {
+	var h crypto.Hash
+	switch algorithm := m.Mac.Algorithm.Algorithm; {
+	case algorithm.Equal(oidSHA1):
+		h = crypto.SHA1
+	case algorithm.Equal(oidSHA256):
+		h = crypto.SHA256
+	case algorithm.Equal(oidSHA384):
+		h = crypto.SHA384
+	case algorithm.Equal(oidSHA512):
+		h = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported PKCS12 MAC algorithm: %s", algorithm)
+	}
+
+	key := pkcs12KDF(h, bmpString(password), m.MacSalt, m.Iterations, pkcs12MACID, h.Size())
+	mac := hmac.New(h.New, key)
+	mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil), m.Mac.Digest) {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func decodeSafeContents(info contentInfo, password string) ([]safeBag, error) {
+	var data []byte
+	switch {
+	case info.ContentType.Equal(oidDataContentType):
+		if _, err := asn1.Unmarshal(info.Content.Bytes, &data); err != nil {
+			return nil, fmt.Errorf("invalid PKCS12 content: %s", err)
+		}
+	case info.ContentType.Equal(oidEncryptedDataContentType):
+		var encrypted encryptedData
+		if _, err := asn1.Unmarshal(info.Content.Bytes, &encrypted); err != nil {
+			return nil, fmt.Errorf("invalid PKCS12 encrypted content: %s", err)
+		}
+		decrypted, err := decryptPBE(encrypted.EncryptedContentInfo.ContentEncryptionAlgorithm, encrypted.EncryptedContentInfo.EncryptedContent, password)
+		if err == errInvalidInput {
+			return nil, ErrInvalidPassword
+		} else if err != nil {
+			return nil, err
+		}
+		data = decrypted
+	default:
+		return nil, fmt.Errorf("unsupported PKCS12 content type: %s", info.ContentType)
+	}
+
+	var bags []safeBag
+	if _, err := asn1.Unmarshal(data, &bags); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	return bags, nil
+}
+
+func bagAttributes(bag safeBag) (friendlyName, localKeyID string) {
+	for _, attribute := range bag.Attributes {
+		switch {
+		case attribute.ID.Equal(oidFriendlyName):
+			var value asn1.RawValue
+			if _, err := asn1.Unmarshal(attribute.Value.Bytes, &value); err == nil {
+				friendlyName = decodeBMPString(value.Bytes)
+			}
+		case attribute.ID.Equal(oidLocalKeyID):
+			var value []byte
+			if _, err := asn1.Unmarshal(attribute.Value.Bytes, &value); err == nil {
+				localKeyID = hex.EncodeToString(value)
+			}
+		}
+	}
+
+	return friendlyName, localKeyID
+}
+
+// decryptPKCS12Key decrypts a key bag. The key password of PKCS12 keystores usually matches the store password,
+// which is tried if the key password is incorrect.
+func decryptPKCS12Key(bag safeBag, keyPassword, storePassword string) (crypto.Signer, error) {
+	if bag.ID.Equal(oidKeyBag) {
+		return parsePrivateKey(bag.Value.Bytes)
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(bag.Value.Bytes, &info); err != nil {
+		return nil, fmt.Errorf("invalid PKCS12 private key: %s", err)
+	}
+
+	var lastErr error
+	for _, password := range []string{keyPassword, storePassword} {
+		der, err := decryptPBE(info.Algorithm, info.EncryptedData, password)
+		if err == errInvalidInput {
+			lastErr = ErrInvalidKeyPassword
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		key, err := parsePrivateKey(der)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return key, nil
+	}
+
+	return nil, lastErr
+}
+
+// certificateChain orders the certificates issuing the leaf certificate, starting with the leaf.
+func certificateChain(leaf *x509.Certificate, certificates []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	for current := leaf; ; {
+		var issuer *x509.Certificate
+		for _, candidate := range certificates {
+			if !candidate.Equal(current) &&
+				string(candidate.RawSubject) == string(current.RawIssuer) {
+				issuer = candidate
+				break
+			}
+		}
+		if issuer == nil || len(chain) == len(certificates) {
+			return chain
+		}
+		chain = append(chain, issuer)
+		current = issuer
+	}
+}
+
+func decodeBMPString(b []byte) string {
+	var units []uint16
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	for len(units) > 0 && units[len(units)-1] == 0 {
+		units = units[:len(units)-1]
+	}
+
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,120 @@
+package manifest
+
+import "fmt"
+
+// Severities of the audit findings, in increasing order.
+const (
+	SeverityNote    = "note"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// exportRequiredSDK is the targetSdkVersion from which components with intent filters have to declare android:exported.
+const exportRequiredSDK = 31
+
+// Rule is a manifest security check.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    string
+}
+
+// Audit rules.
+var (
+	RuleDebuggableRelease = Rule{
+		ID:          "DebuggableRelease",
+		Description: "Release builds should not be debuggable.",
+		Severity:    SeverityError,
+	}
+	RuleAllowBackup = Rule{
+		ID:          "AllowBackup",
+		Description: "The app data can be backed up and restored with adb and cloud backups, set android:allowBackup=\"false\" or define backup rules.",
+		Severity:    SeverityWarning,
+	}
+	RuleCleartextTraffic = Rule{
+		ID:          "CleartextTraffic",
+		Description: "Cleartext HTTP traffic is allowed for every domain without a network security config.",
+		Severity:    SeverityWarning,
+	}
+	RuleImplicitExport = Rule{
+		ID:          "ImplicitExport",
+		Description: "Components with intent filters must declare android:exported when targeting Android 12 (API 31) or higher.",
+		Severity:    SeverityError,
+	}
+	RuleUnprotectedProvider = Rule{
+		ID:          "UnprotectedProvider",
+		Description: "Exported content providers should be protected with a permission.",
+		Severity:    SeverityWarning,
+	}
+
+	// Rules are the checks of the audit.
+	Rules = []Rule{RuleDebuggableRelease, RuleAllowBackup, RuleCleartextTraffic, RuleImplicitExport, RuleUnprotectedProvider}
+)
+
+// Finding is a security issue of the manifest.
+type Finding struct {
+	Rule    Rule
+	Message string
+	// Component is the class name of the affected component, empty for application level findings.
+	Component string
+}
+
+// Audit checks the manifest for security misconfigurations. Debuggable apps are only reported for release builds.
+func Audit(m Manifest, release bool) []Finding {
+	var findings []Finding
+
+	if release && m.Application.Debuggable == "true" {
+		findings = append(findings, Finding{Rule: RuleDebuggableRelease, Message: "The release build sets android:debuggable=\"true\"."})
+	}
+
+	if m.Application.AllowBackup != "false" {
+		message := "android:allowBackup is not set, it defaults to true."
+		if m.Application.AllowBackup != "" {
+			message = fmt.Sprintf("android:allowBackup is set to %s.", m.Application.AllowBackup)
+		}
+		findings = append(findings, Finding{Rule: RuleAllowBackup, Message: message})
+	}
+
+	if m.Application.NetworkSecurityConfig == "" {
+		if m.Application.UsesCleartextTraffic == "true" {
+			findings = append(findings, Finding{Rule: RuleCleartextTraffic, Message: "android:usesCleartextTraffic=\"true\" is set without android:networkSecurityConfig."})
+		} else if m.Application.UsesCleartextTraffic == "" && m.TargetSDK < 28 {
+			findings = append(findings, Finding{Rule: RuleCleartextTraffic, Message: fmt.Sprintf("Cleartext traffic is allowed by default with targetSdkVersion %d (lower than 28), without android:networkSecurityConfig.", m.TargetSDK)})
+		}
+	}
+
+	for _, component := range m.Components {
+		if m.TargetSDK >= exportRequiredSDK && component.Exported == "" && len(component.IntentFilters) > 0 {
+			findings = append(findings, Finding{
+				Rule:      RuleImplicitExport,
+				Message:   fmt.Sprintf("The %s %s has intent filters without an explicit android:exported, the app can not be installed on Android 12 or higher.", component.Type, component.Name),
+				Component: component.Name,
+			})
+		}
+
+		if component.Type == "provider" && providerExported(component, m.TargetSDK) &&
+			component.Permission == "" && (component.ReadPermission == "" || component.WritePermission == "") {
+			findings = append(findings, Finding{
+				Rule:      RuleUnprotectedProvider,
+				Message:   fmt.Sprintf("The exported provider %s is not protected with android:permission, or both android:readPermission and android:writePermission.", component.Name),
+				Component: component.Name,
+			})
+		}
+	}
+
+	return findings
+}
+
+// providerExported tells whether the provider is exported, providers are exported by default below API 17.
+func providerExported(provider Component, targetSDK int) bool {
+	if provider.Exported != "" {
+		return provider.Exported == "true"
+	}
+	return targetSDK < 17
+}
+
+// SeverityAtLeast tells whether the severity is at least the threshold.
+func SeverityAtLeast(severity, threshold string) bool {
+	rank := map[string]int{SeverityNote: 1, SeverityWarning: 2, SeverityError: 3}
+	return rank[threshold] > 0 && rank[severity] >= rank[threshold]
+}
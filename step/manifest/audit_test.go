@@ -0,0 +1,77 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	intentFilter := []IntentFilter{{Actions: []string{"android.intent.action.VIEW"}}}
+	m := Manifest{
+		TargetSDK:   34,
+		Application: Application{Debuggable: "true", UsesCleartextTraffic: "true"},
+		Components: []Component{
+			{Type: "activity", Name: "com.example.MainActivity", Exported: "true", IntentFilters: intentFilter},
+			{Type: "receiver", Name: "com.example.BootReceiver", IntentFilters: intentFilter},
+			{Type: "provider", Name: "com.example.DataProvider", Exported: "true", ReadPermission: "com.example.READ"},
+			{Type: "provider", Name: "com.example.ProtectedProvider", Exported: "true", Permission: "com.example.ACCESS"},
+			{Type: "provider", Name: "androidx.core.content.FileProvider"},
+		},
+	}
+
+	var rules, components []string
+	for _, finding := range Audit(m, true) {
+		rules = append(rules, finding.Rule.ID)
+		components = append(components, finding.Component)
+	}
+	assert.Equal(t, []string{"DebuggableRelease", "AllowBackup", "CleartextTraffic", "ImplicitExport", "UnprotectedProvider"}, rules)
+	assert.Equal(t, []string{"", "", "", "com.example.BootReceiver", "com.example.DataProvider"}, components)
+
+	rules = nil
+	for _, finding := range Audit(m, false) {
+		rules = append(rules, finding.Rule.ID)
+	}
+	assert.NotContains(t, rules, "DebuggableRelease")
+}
+
+func TestAudit_Defaults(t *testing.T) {
+	secure := Manifest{
+		TargetSDK:   34,
+		Application: Application{AllowBackup: "false"},
+		Components: []Component{
+			{Type: "activity", Name: "com.example.MainActivity", Exported: "true", IntentFilters: []IntentFilter{{Actions: []string{"android.intent.action.MAIN"}}}},
+			{Type: "provider", Name: "androidx.core.content.FileProvider"},
+		},
+	}
+	assert.Empty(t, Audit(secure, true))
+
+	legacy := Manifest{
+		TargetSDK:   16,
+		Application: Application{AllowBackup: "false"},
+		Components: []Component{
+			{Type: "receiver", Name: "com.example.BootReceiver", IntentFilters: []IntentFilter{{Actions: []string{"android.intent.action.BOOT_COMPLETED"}}}},
+			{Type: "provider", Name: "com.example.DataProvider"},
+		},
+	}
+	findings := Audit(legacy, true)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got: %v", findings)
+	}
+	assert.Equal(t, RuleCleartextTraffic, findings[0].Rule)
+	assert.Equal(t, "Cleartext traffic is allowed by default with targetSdkVersion 16 (lower than 28), without android:networkSecurityConfig.", findings[0].Message)
+	assert.Equal(t, RuleUnprotectedProvider, findings[1].Rule)
+
+	withConfig := legacy
+	withConfig.Application.NetworkSecurityConfig = "@xml/network_security_config"
+	withConfig.Components = nil
+	assert.Empty(t, Audit(withConfig, true))
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	assert.True(t, SeverityAtLeast(SeverityError, SeverityWarning))
+	assert.True(t, SeverityAtLeast(SeverityWarning, SeverityWarning))
+	assert.False(t, SeverityAtLeast(SeverityNote, SeverityWarning))
+	assert.True(t, SeverityAtLeast(SeverityNote, SeverityNote))
+	assert.False(t, SeverityAtLeast(SeverityError, "off"))
+}
@@ -0,0 +1,237 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is a changed value.
+type Change struct {
+	Name     string `json:"name"`
+	Baseline string `json:"baseline"`
+	Current  string `json:"current"`
+}
+
+// ComponentDiff lists the changes of a component present in both manifests.
+type ComponentDiff struct {
+	Component string   `json:"component"`
+	Changes   []string `json:"changes"`
+}
+
+// Diff is the difference of two manifests.
+type Diff struct {
+	SDK                []Change        `json:"sdk"`
+	AddedPermissions   []string        `json:"added_permissions"`
+	RemovedPermissions []string        `json:"removed_permissions"`
+	AddedFeatures      []string        `json:"added_features"`
+	RemovedFeatures    []string        `json:"removed_features"`
+	AddedComponents    []string        `json:"added_components"`
+	RemovedComponents  []string        `json:"removed_components"`
+	ChangedComponents  []ComponentDiff `json:"changed_components"`
+}
+
+// Compare returns the changes of the current manifest compared to the baseline.
+func Compare(baseline, current Manifest) Diff {
+	diff := Diff{SDK: []Change{}, ChangedComponents: []ComponentDiff{}}
+
+	for _, change := range []Change{
+		{"minSdkVersion", strconv.Itoa(baseline.MinSDK), strconv.Itoa(current.MinSDK)},
+		{"targetSdkVersion", strconv.Itoa(baseline.TargetSDK), strconv.Itoa(current.TargetSDK)},
+	} {
+		if change.Baseline != change.Current {
+			diff.SDK = append(diff.SDK, change)
+		}
+	}
+
+	diff.AddedPermissions, diff.RemovedPermissions = difference(baseline.Permissions, current.Permissions)
+	diff.AddedFeatures, diff.RemovedFeatures = difference(featureNames(baseline.Features), featureNames(current.Features))
+
+	baselineComponents := map[string]Component{}
+	for _, component := range baseline.Components {
+		baselineComponents[component.ID()] = component
+	}
+	currentComponents := map[string]Component{}
+	for _, component := range current.Components {
+		currentComponents[component.ID()] = component
+	}
+
+	diff.AddedComponents = []string{}
+	for _, component := range current.Components {
+		old, ok := baselineComponents[component.ID()]
+		if !ok {
+			diff.AddedComponents = append(diff.AddedComponents, describeComponent(component))
+			continue
+		}
+		if changes := componentChanges(old, component); len(changes) > 0 {
+			diff.ChangedComponents = append(diff.ChangedComponents, ComponentDiff{Component: component.ID(), Changes: changes})
+		}
+	}
+	diff.RemovedComponents = []string{}
+	for _, component := range baseline.Components {
+		if _, ok := currentComponents[component.ID()]; !ok {
+			diff.RemovedComponents = append(diff.RemovedComponents, describeComponent(component))
+		}
+	}
+
+	return diff
+}
+
+// Empty tells whether the manifests have no differences.
+func (d Diff) Empty() bool {
+	return len(d.SDK) == 0 && len(d.AddedPermissions) == 0 && len(d.RemovedPermissions) == 0 &&
+		len(d.AddedFeatures) == 0 && len(d.RemovedFeatures) == 0 && len(d.AddedComponents) == 0 &&
+		len(d.RemovedComponents) == 0 && len(d.ChangedComponents) == 0
+}
+
+// NewDangerousPermissions returns the added dangerous permissions.
+func (d Diff) NewDangerousPermissions() []string {
+	var permissions []string
+	for _, permission := range d.AddedPermissions {
+		if Dangerous(permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// Markdown returns the diff as a markdown document.
+func (d Diff) Markdown(baselineName, artifactName string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Manifest changes of %s\n\nCompared to: %s\n", artifactName, baselineName)
+
+	if d.Empty() {
+		b.WriteString("\nNo manifest changes.\n")
+		return b.String()
+	}
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for _, line := range lines {
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+	}
+
+	var sdk []string
+	for _, change := range d.SDK {
+		sdk = append(sdk, fmt.Sprintf("- %s: %s → %s", change.Name, change.Baseline, change.Current))
+	}
+	section("SDK levels", sdk)
+
+	var permissions []string
+	for _, permission := range d.AddedPermissions {
+		line := fmt.Sprintf("- Added: `%s`", permission)
+		if Dangerous(permission) {
+			line += " **(dangerous)**"
+		}
+		permissions = append(permissions, line)
+	}
+	permissions = append(permissions, listItems("Removed", d.RemovedPermissions)...)
+	section("Permissions", permissions)
+
+	section("Features", append(listItems("Added", d.AddedFeatures), listItems("Removed", d.RemovedFeatures)...))
+
+	components := append(listItems("Added", d.AddedComponents), listItems("Removed", d.RemovedComponents)...)
+	for _, changed := range d.ChangedComponents {
+		components = append(components, fmt.Sprintf("- Changed: `%s`", changed.Component))
+		for _, change := range changed.Changes {
+			components = append(components, "  - "+change)
+		}
+	}
+	section("Components", components)
+
+	return b.String()
+}
+
+func listItems(prefix string, values []string) []string {
+	var items []string
+	for _, value := range values {
+		items = append(items, fmt.Sprintf("- %s: `%s`", prefix, value))
+	}
+	return items
+}
+
+func componentChanges(baseline, current Component) []string {
+	var changes []string
+	if baseline.Exported != current.Exported {
+		changes = append(changes, fmt.Sprintf("exported: %s → %s", orUnset(baseline.Exported), orUnset(current.Exported)))
+	}
+	if baseline.Permission != current.Permission {
+		changes = append(changes, fmt.Sprintf("permission: %s → %s", orUnset(baseline.Permission), orUnset(current.Permission)))
+	}
+
+	added, removed := difference(filterStrings(baseline.IntentFilters), filterStrings(current.IntentFilters))
+	for _, filter := range added {
+		changes = append(changes, "intent filter added: "+filter)
+	}
+	for _, filter := range removed {
+		changes = append(changes, "intent filter removed: "+filter)
+	}
+	return changes
+}
+
+func describeComponent(component Component) string {
+	description := component.ID()
+	if component.Exported != "" {
+		description += " (exported: " + component.Exported + ")"
+	}
+	return description
+}
+
+func featureNames(features []Feature) []string {
+	var names []string
+	for _, feature := range features {
+		name := feature.Name
+		if !feature.Required {
+			name += " (not required)"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func filterStrings(filters []IntentFilter) []string {
+	var values []string
+	for _, filter := range filters {
+		values = append(values, filter.String())
+	}
+	return values
+}
+
+// difference returns the sorted values only present in current (added), and only present in baseline (removed).
+func difference(baseline, current []string) ([]string, []string) {
+	inBaseline := map[string]bool{}
+	for _, value := range baseline {
+		inBaseline[value] = true
+	}
+	inCurrent := map[string]bool{}
+	for _, value := range current {
+		inCurrent[value] = true
+	}
+
+	added, removed := []string{}, []string{}
+	for value := range inCurrent {
+		if !inBaseline[value] {
+			added = append(added, value)
+		}
+	}
+	for value := range inBaseline {
+		if !inCurrent[value] {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func orUnset(value string) string {
+	if value == "" {
+		return "unset"
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	baseline := decodedTestManifest(t)
+	current := decodedTestManifest(t)
+
+	assert.True(t, Compare(baseline, current).Empty())
+
+	current.MinSDK = 26
+	current.Permissions = []string{"android.permission.INTERNET", "android.permission.RECORD_AUDIO", "com.example.permission.C2D"}
+	current.Features = []Feature{{Name: "android.hardware.camera", Required: true}, {Name: "glEsVersion=0x00020000", Required: true}}
+	current.Components = []Component{
+		{
+			Type:     "activity",
+			Name:     "io.bitrise.sample.MainActivity",
+			Exported: "false",
+			IntentFilters: []IntentFilter{{
+				Actions: []string{"android.intent.action.MAIN"},
+			}},
+		},
+		{Type: "provider", Name: "androidx.core.content.FileProvider", Exported: "false"},
+		{Type: "receiver", Name: "io.bitrise.sample.BootReceiver", Exported: "true"},
+	}
+
+	diff := Compare(baseline, current)
+	assert.Equal(t, Diff{
+		SDK:                []Change{{Name: "minSdkVersion", Baseline: "24", Current: "26"}},
+		AddedPermissions:   []string{"android.permission.RECORD_AUDIO", "com.example.permission.C2D"},
+		RemovedPermissions: []string{"android.permission.CAMERA"},
+		AddedFeatures:      []string{"android.hardware.camera"},
+		RemovedFeatures:    []string{"android.hardware.camera (not required)"},
+		AddedComponents:    []string{"receiver io.bitrise.sample.BootReceiver (exported: true)"},
+		RemovedComponents:  []string{"service io.bitrise.sample.SyncService"},
+		ChangedComponents: []ComponentDiff{{
+			Component: "activity io.bitrise.sample.MainActivity",
+			Changes: []string{
+				"exported: true → false",
+				"intent filter added: action=android.intent.action.MAIN",
+				"intent filter removed: action=android.intent.action.VIEW category=android.intent.category.BROWSABLE data=scheme:https,host:bitrise.io",
+			},
+		}},
+	}, diff)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []string{"android.permission.RECORD_AUDIO"}, diff.NewDangerousPermissions())
+
+	assert.Equal(t, `# Manifest changes of app-release.apk
+
+Compared to: app-1.0.apk
+
+## SDK levels
+
+- minSdkVersion: 24 → 26
+
+## Permissions
+
+- Added: `+"`android.permission.RECORD_AUDIO`"+` **(dangerous)**
+- Added: `+"`com.example.permission.C2D`"+`
+- Removed: `+"`android.permission.CAMERA`"+`
+
+## Features
+
+- Added: `+"`android.hardware.camera`"+`
+- Removed: `+"`android.hardware.camera (not required)`"+`
+
+## Components
+
+- Added: `+"`receiver io.bitrise.sample.BootReceiver (exported: true)`"+`
+- Removed: `+"`service io.bitrise.sample.SyncService`"+`
+- Changed: `+"`activity io.bitrise.sample.MainActivity`"+`
+  - exported: true → false
+  - intent filter added: action=android.intent.action.MAIN
+  - intent filter removed: action=android.intent.action.VIEW category=android.intent.category.BROWSABLE data=scheme:https,host:bitrise.io
+`, diff.Markdown("app-1.0.apk", "app-release.apk"))
+}
+
+func TestDiff_Markdown_NoChanges(t *testing.T) {
+	m := decodedTestManifest(t)
+	assert.Equal(t, "# Manifest changes of app.apk\n\nCompared to: app-1.0.apk\n\nNo manifest changes.\n", Compare(m, m).Markdown("app-1.0.apk", "app.apk"))
+}
+
+func decodedTestManifest(t *testing.T) Manifest {
+	root, err := axml.Decode(axmltest.Encode(testManifest()))
+	if err != nil {
+		t.Fatalf("failed to decode manifest: %s", err)
+	}
+	return FromElement(root)
+}
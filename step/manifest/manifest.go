@@ -0,0 +1,259 @@
+// Package manifest reads the AndroidManifest.xml of APKs and AABs into a model of the permissions, features,
+// SDK levels and components of the app, and compares manifests.
+package manifest
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+)
+
+const (
+	apkManifestPath = "AndroidManifest.xml"
+	aabManifestPath = "base/manifest/AndroidManifest.xml"
+)
+
+// Component types.
+var componentTypes = []string{"activity", "activity-alias", "service", "receiver", "provider"}
+
+// Manifest is the content of an app manifest.
+type Manifest struct {
+	Package     string      `json:"package"`
+	VersionCode string      `json:"version_code,omitempty"`
+	VersionName string      `json:"version_name,omitempty"`
+	MinSDK      int         `json:"min_sdk"`
+	TargetSDK   int         `json:"target_sdk"`
+	Permissions []string    `json:"permissions"`
+	Features    []Feature   `json:"features"`
+	Application Application `json:"application"`
+	Components  []Component `json:"components"`
+}
+
+// Feature is a uses-feature declaration.
+type Feature struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+}
+
+// Application holds the security relevant application attributes, empty if not set.
+type Application struct {
+	Debuggable            string `json:"debuggable,omitempty"`
+	AllowBackup           string `json:"allow_backup,omitempty"`
+	UsesCleartextTraffic  string `json:"uses_cleartext_traffic,omitempty"`
+	NetworkSecurityConfig string `json:"network_security_config,omitempty"`
+}
+
+// Component is an activity, activity alias, service, broadcast receiver or content provider.
+type Component struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Exported is the value of the android:exported attribute, empty if not set.
+	Exported   string `json:"exported,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	// ReadPermission and WritePermission are the permissions of content providers.
+	ReadPermission  string         `json:"read_permission,omitempty"`
+	WritePermission string         `json:"write_permission,omitempty"`
+	IntentFilters   []IntentFilter `json:"intent_filters,omitempty"`
+}
+
+// IntentFilter is an intent filter of a component.
+type IntentFilter struct {
+	Actions    []string `json:"actions,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Data       []string `json:"data,omitempty"`
+}
+
+// ID returns the component type and name, like activity com.example.MainActivity.
+func (c Component) ID() string {
+	return c.Type + " " + c.Name
+}
+
+// String returns the actions, categories and data of the filter, like action=android.intent.action.VIEW data=scheme:https.
+func (f IntentFilter) String() string {
+	var parts []string
+	for _, action := range f.Actions {
+		parts = append(parts, "action="+action)
+	}
+	for _, category := range f.Categories {
+		parts = append(parts, "category="+category)
+	}
+	for _, data := range f.Data {
+		parts = append(parts, "data="+data)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Read reads the manifest of an APK, or the base module manifest of an AAB.
+func Read(pth string) (Manifest, error) {
+	r, err := zip.OpenReader(pth)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to open artifact: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	bundle := strings.EqualFold(filepath.Ext(pth), ".aab")
+	name := apkManifestPath
+	if bundle {
+		name = aabManifestPath
+	}
+
+	for _, file := range r.File {
+		if file.Name != name {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return Manifest{}, err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		var root *axml.Element
+		if bundle {
+			root, err = axml.DecodeProto(data)
+		} else {
+			root, err = axml.Decode(data)
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("failed to decode %s: %s", name, err)
+		}
+		return FromElement(root), nil
+	}
+
+	return Manifest{}, fmt.Errorf("no %s found in %s", name, filepath.Base(pth))
+}
+
+// FromElement creates the manifest model from the decoded manifest element.
+func FromElement(root *axml.Element) Manifest {
+	packageName, _ := root.Attr("", "package")
+	m := Manifest{
+		Package:     packageName.Value,
+		VersionCode: root.AndroidAttr("versionCode"),
+		VersionName: root.AndroidAttr("versionName"),
+		MinSDK:      1,
+		Permissions: []string{},
+		Features:    []Feature{},
+		Components:  []Component{},
+	}
+
+	for _, usesSDK := range root.ChildrenByName("uses-sdk") {
+		if attribute, ok := usesSDK.Attr(axml.AndroidNamespace, "minSdkVersion"); ok {
+			if value, ok := attribute.Int(); ok {
+				m.MinSDK = value
+			}
+		}
+		if attribute, ok := usesSDK.Attr(axml.AndroidNamespace, "targetSdkVersion"); ok {
+			if value, ok := attribute.Int(); ok {
+				m.TargetSDK = value
+			}
+		}
+	}
+	if m.TargetSDK == 0 {
+		m.TargetSDK = m.MinSDK
+	}
+
+	for _, tag := range []string{"uses-permission", "uses-permission-sdk-23"} {
+		for _, permission := range root.ChildrenByName(tag) {
+			if name := permission.AndroidAttr("name"); name != "" {
+				m.Permissions = append(m.Permissions, name)
+			}
+		}
+	}
+	m.Permissions = unique(m.Permissions)
+
+	for _, feature := range root.ChildrenByName("uses-feature") {
+		name := feature.AndroidAttr("name")
+		if name == "" {
+			if glES := feature.AndroidAttr("glEsVersion"); glES != "" {
+				name = "glEsVersion=" + glES
+			}
+		}
+		if name != "" {
+			m.Features = append(m.Features, Feature{Name: name, Required: feature.AndroidAttr("required") != "false"})
+		}
+	}
+
+	for _, application := range root.ChildrenByName("application") {
+		m.Application = Application{
+			Debuggable:            application.AndroidAttr("debuggable"),
+			AllowBackup:           application.AndroidAttr("allowBackup"),
+			UsesCleartextTraffic:  application.AndroidAttr("usesCleartextTraffic"),
+			NetworkSecurityConfig: application.AndroidAttr("networkSecurityConfig"),
+		}
+
+		for _, typ := range componentTypes {
+			for _, element := range application.ChildrenByName(typ) {
+				m.Components = append(m.Components, component(typ, element, m.Package))
+			}
+		}
+	}
+
+	return m
+}
+
+func component(typ string, element *axml.Element, packageName string) Component {
+	c := Component{
+		Type:       typ,
+		Name:       className(element.AndroidAttr("name"), packageName),
+		Exported:   element.AndroidAttr("exported"),
+		Permission: element.AndroidAttr("permission"),
+
+		ReadPermission:  element.AndroidAttr("readPermission"),
+		WritePermission: element.AndroidAttr("writePermission"),
+	}
+
+	for _, filter := range element.ChildrenByName("intent-filter") {
+		var f IntentFilter
+		for _, action := range filter.ChildrenByName("action") {
+			f.Actions = append(f.Actions, action.AndroidAttr("name"))
+		}
+		for _, category := range filter.ChildrenByName("category") {
+			f.Categories = append(f.Categories, category.AndroidAttr("name"))
+		}
+		for _, data := range filter.ChildrenByName("data") {
+			var attributes []string
+			for _, name := range []string{"scheme", "host", "port", "path", "pathPrefix", "pathPattern", "mimeType"} {
+				if value := data.AndroidAttr(name); value != "" {
+					attributes = append(attributes, name+":"+value)
+				}
+			}
+			f.Data = append(f.Data, strings.Join(attributes, ","))
+		}
+		c.IntentFilters = append(c.IntentFilters, f)
+	}
+
+	return c
+}
+
+// className resolves the class names relative to the package (.MainActivity).
+func className(name, packageName string) string {
+	if strings.HasPrefix(name, ".") {
+		return packageName + name
+	}
+	if !strings.Contains(name, ".") && packageName != "" {
+		return packageName + "." + name
+	}
+	return name
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,116 @@
+package manifest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	root := testManifest()
+	want := Manifest{
+		Package:     "io.bitrise.sample",
+		VersionCode: "42",
+		VersionName: "1.2.0",
+		MinSDK:      24,
+		TargetSDK:   34,
+		Permissions: []string{"android.permission.CAMERA", "android.permission.INTERNET"},
+		Features:    []Feature{{Name: "android.hardware.camera", Required: false}, {Name: "glEsVersion=0x00020000", Required: true}},
+		Application: Application{Debuggable: "true", AllowBackup: "false"},
+		Components: []Component{
+			{
+				Type:     "activity",
+				Name:     "io.bitrise.sample.MainActivity",
+				Exported: "true",
+				IntentFilters: []IntentFilter{{
+					Actions:    []string{"android.intent.action.VIEW"},
+					Categories: []string{"android.intent.category.BROWSABLE"},
+					Data:       []string{"scheme:https,host:bitrise.io"},
+				}},
+			},
+			{Type: "service", Name: "io.bitrise.sample.SyncService", Permission: "android.permission.BIND_JOB_SERVICE"},
+			{Type: "provider", Name: "androidx.core.content.FileProvider", Exported: "false"},
+		},
+	}
+
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "app.apk")
+	ziptest.Write(t, apkPath, []ziptest.Entry{{Name: "AndroidManifest.xml", Content: axmltest.Encode(root)}})
+	aabPath := filepath.Join(dir, "app.aab")
+	ziptest.Write(t, aabPath, []ziptest.Entry{{Name: "base/manifest/AndroidManifest.xml", Content: axmltest.EncodeProto(root)}})
+
+	for _, pth := range []string{apkPath, aabPath} {
+		m, err := Read(pth)
+		if err != nil {
+			t.Fatalf("Read(%s) error: %s", pth, err)
+		}
+		assert.Equal(t, want, m)
+	}
+
+	ziptest.Write(t, aabPath, []ziptest.Entry{{Name: "AndroidManifest.xml", Content: axmltest.Encode(root)}})
+	_, err := Read(aabPath)
+	assert.EqualError(t, err, "no base/manifest/AndroidManifest.xml found in app.aab")
+}
+
+func TestFromElement_Defaults(t *testing.T) {
+	m := FromElement(&axml.Element{Name: "manifest"})
+	assert.Equal(t, 1, m.MinSDK)
+	assert.Equal(t, 1, m.TargetSDK)
+	assert.Equal(t, []string{}, m.Permissions)
+	assert.Equal(t, []Component{}, m.Components)
+}
+
+func TestDangerous(t *testing.T) {
+	assert.True(t, Dangerous("android.permission.CAMERA"))
+	assert.True(t, Dangerous("android.permission.POST_NOTIFICATIONS"))
+	assert.False(t, Dangerous("android.permission.INTERNET"))
+	assert.False(t, Dangerous("com.example.permission.CAMERA"))
+}
+
+func testManifest() *axml.Element {
+	android := func(name, value string) axml.Attribute {
+		return axml.Attribute{Namespace: axml.AndroidNamespace, Name: name, Type: axml.TypeString, Value: value}
+	}
+	element := func(name string, attributes []axml.Attribute, children ...*axml.Element) *axml.Element {
+		return &axml.Element{Name: name, Attributes: attributes, Children: children}
+	}
+	named := func(tag, name string) *axml.Element {
+		return element(tag, []axml.Attribute{android("name", name)})
+	}
+
+	return element("manifest",
+		[]axml.Attribute{
+			{Name: "package", Type: axml.TypeString, Value: "io.bitrise.sample"},
+			{Namespace: axml.AndroidNamespace, Name: "versionCode", Type: axml.TypeIntDec, Data: 42},
+			android("versionName", "1.2.0"),
+		},
+		element("uses-sdk", []axml.Attribute{
+			{Namespace: axml.AndroidNamespace, Name: "minSdkVersion", Type: axml.TypeIntDec, Data: 24},
+			{Namespace: axml.AndroidNamespace, Name: "targetSdkVersion", Type: axml.TypeIntDec, Data: 34},
+		}),
+		named("uses-permission", "android.permission.INTERNET"),
+		named("uses-permission-sdk-23", "android.permission.CAMERA"),
+		named("uses-permission", "android.permission.INTERNET"),
+		element("uses-feature", []axml.Attribute{android("name", "android.hardware.camera"), {Namespace: axml.AndroidNamespace, Name: "required", Type: axml.TypeBoolean, Data: 0}}),
+		element("uses-feature", []axml.Attribute{{Namespace: axml.AndroidNamespace, Name: "glEsVersion", Type: axml.TypeString, Value: "0x00020000"}}),
+		element("application",
+			[]axml.Attribute{
+				{Namespace: axml.AndroidNamespace, Name: "debuggable", Type: axml.TypeBoolean, Data: 1},
+				{Namespace: axml.AndroidNamespace, Name: "allowBackup", Type: axml.TypeBoolean, Data: 0},
+			},
+			element("activity", []axml.Attribute{android("name", ".MainActivity"), android("exported", "true")},
+				element("intent-filter", nil,
+					named("action", "android.intent.action.VIEW"),
+					named("category", "android.intent.category.BROWSABLE"),
+					element("data", []axml.Attribute{android("scheme", "https"), android("host", "bitrise.io")}),
+				),
+			),
+			element("service", []axml.Attribute{android("name", "SyncService"), android("permission", "android.permission.BIND_JOB_SERVICE")}),
+			element("provider", []axml.Attribute{android("name", "androidx.core.content.FileProvider"), android("exported", "false")}),
+		),
+	)
+}
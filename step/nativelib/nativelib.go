@@ -0,0 +1,163 @@
+// Package nativelib inspects the native libraries (lib/<abi>/*.so) of APKs and AABs.
+package nativelib
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PageSize16K is the page size of the Android devices with 16 KB memory pages.
+const PageSize16K = 16384
+
+var (
+	apkLibraryRegexp = regexp.MustCompile(`^lib/([^/]+)/[^/]+\.so$`)
+	aabLibraryRegexp = regexp.MustCompile(`^[^/]+/lib/([^/]+)/[^/]+\.so$`)
+)
+
+// Library is a native library of an artifact.
+type Library struct {
+	ABI        string
+	Path       string
+	Compressed bool
+	// DataOffset is the offset of the library content in the artifact, the content of compressed libraries starts
+	// at the offset compressed.
+	DataOffset int64
+	// LoadAlignment is the smallest alignment of the LOAD segments.
+	LoadAlignment uint64
+	// Size is the uncompressed size of the library.
+	Size uint64
+	// DebugInfoSize is the size of the .debug_* sections.
+	DebugInfoSize uint64
+	// SymbolTableSize is the size of the static symbol table and its string table, not needed at runtime
+	// as the dynamic symbol table is kept.
+	SymbolTableSize uint64
+}
+
+// Inspect parses the native libraries of the APK or AAB.
+func Inspect(pth string) ([]Library, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact: %s", err)
+	}
+
+	libraryRegexp := apkLibraryRegexp
+	if strings.EqualFold(filepath.Ext(pth), ".aab") {
+		libraryRegexp = aabLibraryRegexp
+	}
+
+	var libraries []Library
+	for _, file := range r.File {
+		match := libraryRegexp.FindStringSubmatch(file.Name)
+		if match == nil {
+			continue
+		}
+
+		offset, err := file.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		library := Library{
+			ABI:        match[1],
+			Path:       file.Name,
+			Compressed: file.Method != zip.Store,
+			DataOffset: offset,
+			Size:       file.UncompressedSize64,
+		}
+
+		content, err := libraryContent(f, file, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", file.Name, err)
+		}
+		if err := library.parseELF(content); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", file.Name, err)
+		}
+
+		libraries = append(libraries, library)
+	}
+
+	return libraries, nil
+}
+
+// libraryContent returns a reader of the uncompressed library, stored libraries are read in place.
+func libraryContent(artifact io.ReaderAt, file *zip.File, offset int64) (io.ReaderAt, error) {
+	if file.Method == zip.Store {
+		return io.NewSectionReader(artifact, offset, int64(file.UncompressedSize64)), nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(content), nil
+}
+
+func (l *Library) parseELF(content io.ReaderAt) error {
+	f, err := elf.NewFile(content)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_LOAD {
+			continue
+		}
+		if l.LoadAlignment == 0 || prog.Align < l.LoadAlignment {
+			l.LoadAlignment = prog.Align
+		}
+	}
+
+	for _, section := range f.Sections {
+		if section.Type == elf.SHT_NOBITS {
+			continue
+		}
+		if strings.HasPrefix(section.Name, ".debug_") || strings.HasPrefix(section.Name, ".zdebug_") {
+			l.DebugInfoSize += section.FileSize
+		}
+		if section.Type == elf.SHT_SYMTAB {
+			l.SymbolTableSize += section.FileSize
+			if int(section.Link) < len(f.Sections) {
+				l.SymbolTableSize += f.Sections[section.Link].FileSize
+			}
+		}
+	}
+	return nil
+}
+
+// Unstripped tells whether the library contains debug info or a static symbol table.
+func (l Library) Unstripped() bool {
+	return l.StrippableSize() > 0
+}
+
+// StrippableSize is the size of the debug info and static symbol table, removed by stripping the library.
+func (l Library) StrippableSize() uint64 {
+	return l.DebugInfoSize + l.SymbolTableSize
+}
+
+// Is64Bit tells whether the library is built for a 64-bit ABI.
+func (l Library) Is64Bit() bool {
+	return l.ABI == "arm64-v8a" || l.ABI == "x86_64"
+}
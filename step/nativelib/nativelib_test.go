@@ -0,0 +1,123 @@
+package nativelib
+
+import (
+	"debug/elf"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/nativelib/nativelibtest"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "app-release.apk")
+	ziptest.Write(t, apkPath, []ziptest.Entry{
+		{Name: "lib/arm64-v8a/libaligned.so", Content: elfLibrary(16384, 4096), Stored: true, Align: PageSize16K},
+		{Name: "classes.dex", Content: []byte("dex")},
+		{Name: "lib/arm64-v8a/libcompressed.so", Content: elfLibrary(4096)},
+		{Name: "lib/x86_64/libmisplaced.so", Content: elfLibrary(16384), Stored: true},
+		{Name: "lib/armeabi-v7a/libold.so", Content: elfLibrary(4096)},
+		{Name: "assets/lib/arm64-v8a/libasset.so", Content: []byte("not a library")},
+	})
+
+	libraries, err := Inspect(apkPath)
+	if err != nil {
+		t.Fatalf("Inspect() error: %s", err)
+	}
+	if len(libraries) != 4 {
+		t.Fatalf("expected 4 libraries, got: %v", libraries)
+	}
+
+	assert.Equal(t, "arm64-v8a", libraries[0].ABI)
+	assert.Equal(t, "lib/arm64-v8a/libaligned.so", libraries[0].Path)
+	assert.Equal(t, uint64(4096), libraries[0].LoadAlignment)
+	assert.False(t, libraries[0].Compressed)
+	assert.Equal(t, int64(0), libraries[0].DataOffset%PageSize16K)
+	assert.True(t, libraries[1].Compressed)
+	assert.Equal(t, uint64(4096), libraries[1].LoadAlignment)
+	assert.Equal(t, uint64(16384), libraries[2].LoadAlignment)
+	assert.False(t, libraries[3].Is64Bit())
+
+	var problems []string
+	for _, problem := range PageSizeProblems(libraries, true) {
+		problems = append(problems, problem.Library.Path+": "+problem.Reason)
+	}
+	assert.Equal(t, []string{
+		"lib/arm64-v8a/libaligned.so: LOAD segments are aligned to 4096 bytes, at least 16384 is required",
+		"lib/arm64-v8a/libcompressed.so: LOAD segments are aligned to 4096 bytes, at least 16384 is required",
+		"lib/x86_64/libmisplaced.so: the uncompressed library is not 16 KB aligned in the APK (offset " + itoa(libraries[2].DataOffset) + ")",
+	}, problems)
+}
+
+func TestInspect_Bundle(t *testing.T) {
+	aabPath := filepath.Join(t.TempDir(), "app-release.aab")
+	ziptest.Write(t, aabPath, []ziptest.Entry{
+		{Name: "base/lib/arm64-v8a/libapp.so", Content: elfLibrary(16384)},
+		{Name: "feature/lib/x86_64/libfeature.so", Content: elfLibrary(16384), Stored: true},
+		{Name: "lib/arm64-v8a/libignored.so", Content: []byte("not a module")},
+	})
+
+	libraries, err := Inspect(aabPath)
+	if err != nil {
+		t.Fatalf("Inspect() error: %s", err)
+	}
+	assert.Equal(t, 2, len(libraries))
+	assert.Equal(t, "x86_64", libraries[1].ABI)
+	assert.Empty(t, PageSizeProblems(libraries, false))
+}
+
+func TestInspect_DebugInfo(t *testing.T) {
+	apkPath := filepath.Join(t.TempDir(), "app-release.apk")
+	unstripped := nativelibtest.Library([]uint64{16384}, []nativelibtest.Section{
+		{Name: ".dynsym", Type: elf.SHT_DYNSYM, Size: 48, Link: 2},
+		{Name: ".dynstr", Type: elf.SHT_STRTAB, Size: 16},
+		{Name: ".debug_info", Type: elf.SHT_PROGBITS, Size: 1000},
+		{Name: ".debug_line", Type: elf.SHT_PROGBITS, Size: 200},
+		{Name: ".symtab", Type: elf.SHT_SYMTAB, Size: 96, Link: 6},
+		{Name: ".strtab", Type: elf.SHT_STRTAB, Size: 32},
+		{Name: ".bss", Type: elf.SHT_NOBITS, Size: 0},
+	})
+	stripped := nativelibtest.Library([]uint64{16384}, []nativelibtest.Section{
+		{Name: ".dynsym", Type: elf.SHT_DYNSYM, Size: 48, Link: 2},
+		{Name: ".dynstr", Type: elf.SHT_STRTAB, Size: 16},
+	})
+	ziptest.Write(t, apkPath, []ziptest.Entry{
+		{Name: "lib/arm64-v8a/libunstripped.so", Content: unstripped},
+		{Name: "lib/arm64-v8a/libstripped.so", Content: stripped},
+	})
+
+	libraries, err := Inspect(apkPath)
+	if err != nil {
+		t.Fatalf("Inspect() error: %s", err)
+	}
+	if len(libraries) != 2 {
+		t.Fatalf("expected 2 libraries, got: %v", libraries)
+	}
+
+	assert.Equal(t, uint64(len(unstripped)), libraries[0].Size)
+	assert.Equal(t, uint64(1200), libraries[0].DebugInfoSize)
+	assert.Equal(t, uint64(128), libraries[0].SymbolTableSize)
+	assert.Equal(t, uint64(1328), libraries[0].StrippableSize())
+	assert.True(t, libraries[0].Unstripped())
+	assert.False(t, libraries[1].Unstripped())
+}
+
+func TestInspect_InvalidLibrary(t *testing.T) {
+	apkPath := filepath.Join(t.TempDir(), "app-release.apk")
+	ziptest.Write(t, apkPath, []ziptest.Entry{{Name: "lib/arm64-v8a/libapp.so", Content: []byte("not an ELF file")}})
+
+	_, err := Inspect(apkPath)
+	assert.Error(t, err)
+}
+
+// elfLibrary returns a 64-bit ELF shared object with LOAD segments of the given alignments.
+func elfLibrary(alignments ...uint64) []byte {
+	return nativelibtest.Library(alignments, nil)
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
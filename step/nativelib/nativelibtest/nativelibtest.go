@@ -0,0 +1,82 @@
+// Package nativelibtest builds ELF shared objects for tests.
+package nativelibtest
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+)
+
+// Section is a section of the test library, its content is zeroed.
+type Section struct {
+	Name string
+	Type elf.SectionType
+	Size int
+	// Link is the index of the linked section, the sections are indexed from 1.
+	Link uint32
+}
+
+// Library returns a 64-bit ELF shared object with LOAD segments of the given alignments and the given sections,
+// followed by the section name string table.
+func Library(alignments []uint64, sections []Section) []byte {
+	const headerSize, progSize, sectionSize = 64, 56, 64
+
+	names := []byte{0}
+	var nameOffsets []uint32
+	for _, section := range append(sections, Section{Name: ".shstrtab"}) {
+		nameOffsets = append(nameOffsets, uint32(len(names)))
+		names = append(append(names, section.Name...), 0)
+	}
+
+	dataOffset := uint64(headerSize + progSize*len(alignments))
+	var data []byte
+	headers := []elf.Section64{{}}
+	for i, section := range sections {
+		headers = append(headers, elf.Section64{
+			Name: nameOffsets[i],
+			Type: uint32(section.Type),
+			Off:  dataOffset + uint64(len(data)),
+			Size: uint64(section.Size),
+			Link: section.Link,
+		})
+		data = append(data, make([]byte, section.Size)...)
+	}
+	headers = append(headers, elf.Section64{
+		Name: nameOffsets[len(sections)],
+		Type: uint32(elf.SHT_STRTAB),
+		Off:  dataOffset + uint64(len(data)),
+		Size: uint64(len(names)),
+	})
+	data = append(data, names...)
+
+	header := elf.Header64{
+		Type:      uint16(elf.ET_DYN),
+		Machine:   uint16(elf.EM_AARCH64),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     headerSize,
+		Shoff:     dataOffset + uint64(len(data)),
+		Ehsize:    headerSize,
+		Phentsize: progSize,
+		Phnum:     uint16(len(alignments)),
+		Shentsize: sectionSize,
+		Shnum:     uint16(len(headers)),
+		Shstrndx:  uint16(len(headers) - 1),
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	// Writing fixed size values to a buffer does not fail.
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, header)
+	for i, alignment := range alignments {
+		prog := elf.Prog64{Type: uint32(elf.PT_LOAD), Flags: uint32(elf.PF_R), Vaddr: uint64(i) * alignment, Align: alignment}
+		_ = binary.Write(&buf, binary.LittleEndian, prog)
+	}
+	buf.Write(data)
+	for _, section := range headers {
+		_ = binary.Write(&buf, binary.LittleEndian, section)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,35 @@
+package nativelib
+
+import "fmt"
+
+// PageSizeProblem is the reason a library can not be loaded on devices with 16 KB memory pages.
+type PageSizeProblem struct {
+	Library Library
+	Reason  string
+}
+
+// PageSizeProblems returns the 64-bit libraries not supporting 16 KB memory pages: the LOAD segments have to be
+// aligned to 16 KB, and the uncompressed libraries of APKs have to be 16 KB aligned within the APK,
+// so that they can be mapped directly from the APK. 32-bit ABIs are not checked, as 16 KB page size devices are 64-bit.
+func PageSizeProblems(libraries []Library, apk bool) []PageSizeProblem {
+	var problems []PageSizeProblem
+	for _, library := range libraries {
+		if !library.Is64Bit() {
+			continue
+		}
+
+		if library.LoadAlignment < PageSize16K {
+			problems = append(problems, PageSizeProblem{
+				Library: library,
+				Reason:  fmt.Sprintf("LOAD segments are aligned to %d bytes, at least %d is required", library.LoadAlignment, PageSize16K),
+			})
+		}
+		if apk && !library.Compressed && library.DataOffset%PageSize16K != 0 {
+			problems = append(problems, PageSizeProblem{
+				Library: library,
+				Reason:  fmt.Sprintf("the uncompressed library is not 16 KB aligned in the APK (offset %d)", library.DataOffset),
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,137 @@
+// Package protobuf reads serialized Protocol Buffers messages without their schema,
+// like the aapt2 resource table and XML files of Android App Bundles.
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Wire types.
+const (
+	WireVarint  = 0
+	WireFixed64 = 1
+	WireBytes   = 2
+	WireFixed32 = 5
+)
+
+// Field is a field of a serialized message, the value is set according to the wire type.
+type Field struct {
+	Number int
+	Varint uint64
+	Bytes  []byte
+}
+
+// Parse splits a serialized message into its fields.
+func Parse(data []byte) ([]Field, error) {
+	var fields []Field
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		if n == 0 {
+			return nil, errors.New("invalid field key")
+		}
+		data = data[n:]
+
+		field := Field{Number: int(key >> 3)}
+		switch wireType := key & 7; wireType {
+		case WireVarint:
+			field.Varint, n = readVarint(data)
+			if n == 0 {
+				return nil, errors.New("invalid varint")
+			}
+			data = data[n:]
+		case WireFixed64, WireFixed32:
+			size := 8
+			if wireType == WireFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return nil, errors.New("truncated fixed size field")
+			}
+			field.Bytes, data = data[:size], data[size:]
+		case WireBytes:
+			length, n := readVarint(data)
+			if n == 0 || uint64(len(data)-n) < length {
+				return nil, errors.New("truncated length-delimited field")
+			}
+			data = data[n:]
+			field.Bytes, data = data[:length], data[length:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type: %d", wireType)
+		}
+
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// readVarint returns the decoded value and the number of bytes read, 0 if the varint is invalid.
+func readVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		value |= uint64(data[i]&0x7f) << (7 * uint(i))
+		if data[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// Messages returns the embedded messages of the given field number.
+func Messages(fields []Field, number int) ([][]Field, error) {
+	var msgs [][]Field
+	for _, field := range fields {
+		if field.Number != number {
+			continue
+		}
+		msg, err := Parse(field.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// String returns the last value of the given string field.
+func String(fields []Field, number int) string {
+	var value string
+	for _, field := range fields {
+		if field.Number == number {
+			value = string(field.Bytes)
+		}
+	}
+	return value
+}
+
+// Varint returns the last value of the given varint field.
+func Varint(fields []Field, number int) uint64 {
+	var value uint64
+	for _, field := range fields {
+		if field.Number == number {
+			value = field.Varint
+		}
+	}
+	return value
+}
+
+// AppendBytes appends a length-delimited field (string, bytes or embedded message) to the serialized message.
+func AppendBytes(b []byte, number int, value []byte) []byte {
+	b = appendVarint(b, uint64(number)<<3|WireBytes)
+	b = appendVarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// AppendVarint appends a varint field to the serialized message.
+func AppendVarint(b []byte, number int, value uint64) []byte {
+	b = appendVarint(b, uint64(number)<<3|WireVarint)
+	return appendVarint(b, value)
+}
+
+func appendVarint(b []byte, value uint64) []byte {
+	for value >= 0x80 {
+		b = append(b, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(b, byte(value))
+}
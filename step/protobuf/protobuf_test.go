@@ -0,0 +1,49 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	inner := AppendBytes(nil, 1, []byte("inner"))
+	msg := AppendVarint(nil, 1, 300)
+	msg = AppendBytes(msg, 2, []byte("first"))
+	msg = AppendBytes(msg, 2, []byte("second"))
+	msg = AppendBytes(msg, 3, inner)
+	msg = append(msg, 0x25, 1, 2, 3, 4)             // field 4, fixed32
+	msg = append(msg, 0x29, 1, 2, 3, 4, 5, 6, 7, 8) // field 5, fixed64
+
+	fields, err := Parse(msg)
+	if err != nil {
+		t.Fatalf("Parse() error: %s", err)
+	}
+
+	assert.Equal(t, 6, len(fields))
+	assert.Equal(t, uint64(300), Varint(fields, 1))
+	assert.Equal(t, "second", String(fields, 2))
+	assert.Equal(t, []byte{1, 2, 3, 4}, fields[4].Bytes)
+	assert.Equal(t, 8, len(fields[5].Bytes))
+
+	messages, err := Messages(fields, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, "inner", String(messages[0], 1))
+
+	_, err = Messages(fields, 2)
+	assert.Error(t, err)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"truncated key":    {0x80},
+		"truncated varint": {0x08, 0x80},
+		"truncated bytes":  {0x12, 0x05, 'a'},
+		"truncated fixed":  {0x25, 1, 2},
+		"group":            {0x0b},
+	} {
+		_, err := Parse(data)
+		assert.Error(t, err, name)
+	}
+}
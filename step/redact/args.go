@@ -0,0 +1,27 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sensitiveKeyRegexp matches property names holding secrets, like android.injected.signing.store.password.
+var sensitiveKeyRegexp = regexp.MustCompile(`(?i)(password|passwd|passphrase|secret|token|api[._-]?key|credential|private[._-]?key)`)
+
+// SensitiveArgValues returns the values of the -Pkey=value, -Dkey=value and --key=value arguments
+// whose name looks sensitive.
+func SensitiveArgValues(args []string) []string {
+	var values []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-P") && !strings.HasPrefix(arg, "-D") && !strings.HasPrefix(arg, "--") {
+			continue
+		}
+
+		parts := strings.SplitN(arg[2:], "=", 2)
+		if len(parts) == 2 && parts[1] != "" && sensitiveKeyRegexp.MatchString(parts[0]) {
+			values = append(values, parts[1])
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,67 @@
+// Package redact masks secret values in the text the step prints, like the Gradle command line.
+package redact
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mask replaces the secret values.
+const Mask = "[REDACTED]"
+
+// MinSecretLength is the length of the shortest secret value which is masked. Shorter values would mask
+// unrelated parts of the output (like every "1" of a one character value), so they are skipped.
+const MinSecretLength = 4
+
+// Redactor replaces the registered secret values with Mask.
+type Redactor struct {
+	secrets []string
+	skipped int
+}
+
+// New returns a Redactor for the given secrets, empty and too short values are ignored.
+func New(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+
+	return r
+}
+
+// Add registers additional secret values. The quoted form of each secret is registered too,
+// because printed command arguments are quoted with strconv.Quote. Values shorter than MinSecretLength
+// are not registered, they are counted by Skipped.
+func (r *Redactor) Add(secrets ...string) {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		if len(secret) < MinSecretLength {
+			r.skipped++
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+		if quoted := strings.Trim(strconv.Quote(secret), `"`); quoted != secret {
+			r.secrets = append(r.secrets, quoted)
+		}
+	}
+
+	// Longer secrets first, so a secret containing another one is masked as a whole.
+	sort.SliceStable(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// Skipped returns the number of non-empty secret values which were too short to be registered.
+func (r *Redactor) Skipped() int {
+	return r.skipped
+}
+
+// Redact returns s with every secret value masked.
+func (r *Redactor) Redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+
+	return s
+}
@@ -0,0 +1,41 @@
+package redact
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	r := New("", "s3cr3t", `pa"ss`, "s3cr3t-longer")
+
+	cmd := `gradlew "assembleRelease" "-Ppassword=s3cr3t" "-Pother=s3cr3t-longer" ` + strconv.Quote(`-PkeyPassword=pa"ss`)
+
+	assert.Equal(t, `gradlew "assembleRelease" "-Ppassword=[REDACTED]" "-Pother=[REDACTED]" "-PkeyPassword=[REDACTED]"`, r.Redact(cmd))
+}
+
+func TestRedactor_ShortSecrets(t *testing.T) {
+	r := New("1", "abc", "abcd")
+
+	assert.Equal(t, 2, r.Skipped())
+	assert.Equal(t, "gradlew -Pa=1 -Pb=abc -Pc=[REDACTED]", r.Redact("gradlew -Pa=1 -Pb=abc -Pc=abcd"))
+}
+
+func TestRedactor_NoSecrets(t *testing.T) {
+	assert.Equal(t, "gradlew assembleDebug", New().Redact("gradlew assembleDebug"))
+}
+
+func TestSensitiveArgValues(t *testing.T) {
+	args := []string{
+		"--stacktrace",
+		"-Pandroid.injected.signing.store.password=store-pass",
+		"-Pandroid.injected.signing.key.password=key-pass",
+		"-Pandroid.injected.signing.key.alias=upload",
+		"-DGITHUB_TOKEN=ghp_123",
+		"-PsentryAuthToken=",
+		"--api-key=abc",
+	}
+
+	assert.Equal(t, []string{"store-pass", "key-pass", "ghp_123", "abc"}, SensitiveArgValues(args))
+}
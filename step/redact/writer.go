@@ -0,0 +1,56 @@
+package redact
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Writer masks secrets in the output streamed through it. Output is processed line by line so that secrets split
+// between two writes are masked too; call Flush at the end to write the last incomplete line.
+type Writer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	redactor *Redactor
+	buf      bytes.Buffer
+}
+
+// NewWriter returns a Writer wrapping w.
+func NewWriter(w io.Writer, redactor *Redactor) *Writer {
+	return &Writer{w: w, redactor: redactor}
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+
+	idx := bytes.LastIndexByte(w.buf.Bytes(), '\n')
+	if idx == -1 {
+		return len(p), nil
+	}
+
+	lines := w.buf.Next(idx + 1)
+	if _, err := io.WriteString(w.w, w.redactor.Redact(string(lines))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the buffered incomplete line.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := io.WriteString(w.w, w.redactor.Redact(w.buf.String()))
+	w.buf.Reset()
+
+	return err
+}
@@ -0,0 +1,23 @@
+package redact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, New("s3cr3t"))
+
+	for _, chunk := range []string{"> Task :app:packageRelease\nsigning with s3", "cr3t\n", "done s3cr3t"} {
+		n, err := w.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, "> Task :app:packageRelease\nsigning with [REDACTED]\n", out.String())
+
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "> Task :app:packageRelease\nsigning with [REDACTED]\ndone [REDACTED]", out.String())
+}
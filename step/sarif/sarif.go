@@ -0,0 +1,115 @@
+// Package sarif writes Static Analysis Results Interchange Format (SARIF 2.1.0) logs,
+// the report format code scanning services import.
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const (
+	version = "2.1.0"
+	schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Levels of the results.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is a SARIF log file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the output of a single analysis tool run.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analysis tool.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the analysis tool component with the rules.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule is an analysis rule.
+type Rule struct {
+	ID                   string               `json:"id"`
+	ShortDescription     Message              `json:"shortDescription"`
+	DefaultConfiguration DefaultConfiguration `json:"defaultConfiguration"`
+}
+
+// DefaultConfiguration is the default level of the rule.
+type DefaultConfiguration struct {
+	Level string `json:"level"`
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a finding of the analysis.
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Location is the file and the logical location (for example a class) of the result.
+type Location struct {
+	PhysicalLocation PhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// PhysicalLocation is the file of the result.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+}
+
+// ArtifactLocation is the URI of the file, relative to the analysis root.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// LogicalLocation is a named logical location, like a class.
+type LogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// New returns a log with a single run of the tool.
+func New(driver Driver, results []Result) Log {
+	if driver.Rules == nil {
+		driver.Rules = []Rule{}
+	}
+	if results == nil {
+		results = []Result{}
+	}
+	return Log{
+		Schema:  schema,
+		Version: version,
+		Runs:    []Run{{Tool: Tool{Driver: driver}, Results: results}},
+	}
+}
+
+// Write writes the log as JSON.
+func (l Log) Write(pth string) error {
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pth, content, 0o644)
+}
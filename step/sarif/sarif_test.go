@@ -0,0 +1,46 @@
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog_Write(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "audit.sarif")
+	log := New(Driver{Name: "audit", Rules: []Rule{{ID: "Rule1", ShortDescription: Message{Text: "Description"}, DefaultConfiguration: DefaultConfiguration{Level: LevelError}}}}, []Result{{
+		RuleID:  "Rule1",
+		Level:   LevelError,
+		Message: Message{Text: "Finding"},
+		Locations: []Location{{
+			PhysicalLocation: PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: "app-release.apk"}},
+			LogicalLocations: []LogicalLocation{{FullyQualifiedName: "com.example.MainActivity"}},
+		}},
+	}})
+	if err := log.Write(pth); err != nil {
+		t.Fatalf("Write() error: %s", err)
+	}
+
+	content, err := os.ReadFile(pth)
+	assert.NoError(t, err)
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Equal(t, "2.1.0", decoded["version"])
+	assert.Equal(t, "https://json.schemastore.org/sarif-2.1.0.json", decoded["$schema"])
+
+	run := decoded["runs"].([]interface{})[0].(map[string]interface{})
+	result := run["results"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Rule1", result["ruleId"])
+	assert.Equal(t, "error", result["level"])
+	location := result["locations"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"artifactLocation": map[string]interface{}{"uri": "app-release.apk"}}, location["physicalLocation"])
+}
+
+func TestNew_Empty(t *testing.T) {
+	content, err := json.Marshal(New(Driver{Name: "audit"}, nil))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"$schema":"https://json.schemastore.org/sarif-2.1.0.json","version":"2.1.0","runs":[{"tool":{"driver":{"name":"audit","rules":[]}},"results":[]}]}`, string(content))
+}
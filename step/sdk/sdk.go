@@ -0,0 +1,274 @@
+// Package sdk checks that the Android SDK components required by a Gradle project
+// (compileSdk platform, build-tools and NDK) are installed before Gradle runs.
+package sdk
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/buildscript"
+)
+
+const (
+	localPropertiesFileName = "local.properties"
+	licenseRelPath          = "licenses/android-sdk-license"
+)
+
+var (
+	compileSdkRegexp = regexp.MustCompile(`(?m)^\s*compileSdk(?:Version)?\s*(?:=|\(|\s)\s*["']?(?:android-)?([\w.()\-]+)["']?\)?\s*$`)
+	buildToolsRegexp = regexp.MustCompile(`(?m)^\s*buildToolsVersion\s*(?:=|\(|\s)\s*["']?([\w.()\-]+)["']?\)?\s*$`)
+	ndkRegexp        = regexp.MustCompile(`(?m)^\s*ndkVersion\s*(?:=|\(|\s)\s*["']?([\w.()\-]+)["']?\)?\s*$`)
+	catalogRefRegexp = regexp.MustCompile(`^libs\.versions\.([\w.]+?)(?:\.get\(\))?(?:\.toInt\(\))?$`)
+	literalRegexp    = regexp.MustCompile(`^\d[\d.]*$`)
+	identifierRegexp = regexp.MustCompile(`^\w+$`)
+	ndkRevisionRe    = regexp.MustCompile(`(?m)^Pkg\.Revision\s*=\s*(\S+)`)
+)
+
+// staleLicenseHashes are the hashes of the former Android SDK license texts, these do not cover the current packages.
+var staleLicenseHashes = []string{
+	"8933bad161af4178b1185d1a37fbf41ea5269c55",
+	"d56f5187479451eabf01fb78af6dfcb131a6481e",
+}
+
+// Component is an SDK package required by the project, identified by its sdkmanager path
+// (for example platforms;android-34).
+type Component struct {
+	Package string
+	Source  string
+}
+
+// Root returns the Android SDK location: the sdk.dir of local.properties, $ANDROID_HOME or $ANDROID_SDK_ROOT.
+// An empty string is returned if none of them is set.
+func Root(projectDir string) (string, error) {
+	sdkDir, err := localProperty(filepath.Join(projectDir, localPropertiesFileName), "sdk.dir")
+	if err != nil {
+		return "", err
+	}
+	if sdkDir != "" {
+		return sdkDir, nil
+	}
+
+	for _, key := range []string{"ANDROID_HOME", "ANDROID_SDK_ROOT"} {
+		if value := os.Getenv(key); value != "" {
+			return value, nil
+		}
+	}
+
+	return "", nil
+}
+
+// ReadRequirements returns the platforms, build-tools and NDK versions declared in the module build scripts.
+// Values defined in the version catalog (libs.versions.x) or as extra properties (rootProject.ext.x)
+// are resolved, values that cannot be resolved are skipped.
+func ReadRequirements(projectDir string) ([]Component, error) {
+	scripts, err := buildscript.Find(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := buildscript.ReadCatalog(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := []struct {
+		re     *regexp.Regexp
+		prefix string
+		name   string
+	}{
+		{re: compileSdkRegexp, prefix: "platforms;android-", name: "compileSdk"},
+		{re: buildToolsRegexp, prefix: "build-tools;", name: "buildToolsVersion"},
+		{re: ndkRegexp, prefix: "ndk;", name: "ndkVersion"},
+	}
+
+	seen := map[string]bool{}
+	var components []Component
+	for _, script := range scripts {
+		if script.IsSettings() {
+			continue
+		}
+		code := script.Code()
+		for _, kind := range kinds {
+			for _, match := range kind.re.FindAllStringSubmatch(code, -1) {
+				value := resolve(trimCall(match[1]), scripts, catalog)
+				if value == "" {
+					continue
+				}
+				pkg := kind.prefix + value
+				if seen[pkg] {
+					continue
+				}
+				seen[pkg] = true
+
+				relPath, err := filepath.Rel(projectDir, script.Path)
+				if err != nil {
+					relPath = script.Path
+				}
+				components = append(components, Component{
+					Package: pkg,
+					Source:  fmt.Sprintf("%s in %s", kind.name, relPath),
+				})
+			}
+		}
+	}
+
+	return components, nil
+}
+
+// resolve returns the literal value of a build script expression: a literal, a version catalog reference
+// or a reference to a property assigned elsewhere (ext blocks, gradle.properties style extra properties).
+func resolve(value string, scripts []buildscript.Script, catalog buildscript.Catalog) string {
+	if literalRegexp.MatchString(value) {
+		return value
+	}
+
+	if match := catalogRefRegexp.FindStringSubmatch(value); match != nil {
+		return catalogVersion(catalog, match[1])
+	}
+
+	parts := strings.Split(value, ".")
+	name := parts[len(parts)-1]
+	if !identifierRegexp.MatchString(name) {
+		return ""
+	}
+	assignment := regexp.MustCompile(`(?m)\b` + name + `\s*=\s*["']?(\d[\d.]*)["']?`)
+	if resolved, _, ok := buildscript.FindFirst(scripts, assignment); ok {
+		return resolved
+	}
+
+	return ""
+}
+
+// trimCall removes the closing parenthesis of a method call style assignment: compileSdkVersion(34).
+func trimCall(value string) string {
+	for strings.HasSuffix(value, ")") && strings.Count(value, ")") > strings.Count(value, "(") {
+		value = strings.TrimSuffix(value, ")")
+	}
+
+	return value
+}
+
+// catalogVersion looks up a libs.versions accessor in the catalog. Accessors use dots where the
+// catalog keys may use dots, dashes or underscores (libs.versions.android.compileSdk -> android-compileSdk).
+func catalogVersion(catalog buildscript.Catalog, accessor string) string {
+	normalize := strings.NewReplacer("-", ".", "_", ".")
+	for key, version := range catalog.Versions {
+		if normalize.Replace(key) == accessor {
+			return version
+		}
+	}
+
+	return ""
+}
+
+// Check returns the problems found in the SDK: missing required components and unaccepted licenses.
+func Check(sdkRoot string, components []Component) []string {
+	var problems []string
+
+	if problem := checkLicense(sdkRoot); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	for _, component := range components {
+		if !isInstalled(sdkRoot, component.Package) {
+			problems = append(problems, fmt.Sprintf("%s (%s) is not installed", component.Package, component.Source))
+		}
+	}
+
+	return problems
+}
+
+// MissingPackages returns the sdkmanager paths of the components that are not installed.
+func MissingPackages(sdkRoot string, components []Component) []string {
+	var packages []string
+	for _, component := range components {
+		if !isInstalled(sdkRoot, component.Package) {
+			packages = append(packages, component.Package)
+		}
+	}
+	sort.Strings(packages)
+
+	return packages
+}
+
+func isInstalled(sdkRoot, pkg string) bool {
+	dir := filepath.Join(sdkRoot, strings.ReplaceAll(pkg, ";", string(filepath.Separator)))
+	if _, err := os.Stat(dir); err == nil {
+		return true
+	}
+
+	// Before side-by-side NDKs, the NDK was installed into ndk-bundle.
+	if version := strings.TrimPrefix(pkg, "ndk;"); version != pkg {
+		content, err := os.ReadFile(filepath.Join(sdkRoot, "ndk-bundle", "source.properties"))
+		if err != nil {
+			return false
+		}
+		match := ndkRevisionRe.FindStringSubmatch(string(content))
+		return match != nil && match[1] == version
+	}
+
+	return false
+}
+
+// checkLicense returns the problem found in the accepted license hashes, or an empty string if a license is
+// accepted. Any hash which is not a stale one is accepted: sdkmanager writes the hash of the license text it
+// currently asks to accept, and that changes with new license revisions.
+func checkLicense(sdkRoot string) string {
+	pth := filepath.Join(sdkRoot, licenseRelPath)
+	content, err := os.ReadFile(pth)
+	if err != nil || strings.TrimSpace(string(content)) == "" {
+		return fmt.Sprintf("The Android SDK licenses are not accepted (%s is missing or empty), run: sdkmanager --licenses", pth)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		hash := strings.TrimSpace(line)
+		if hash != "" && !isStaleLicenseHash(hash) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("The accepted Android SDK license in %s is stale, the current license is not accepted, run: sdkmanager --licenses", pth)
+}
+
+func isStaleLicenseHash(hash string) bool {
+	for _, staleHash := range staleLicenseHashes {
+		if hash == staleHash {
+			return true
+		}
+	}
+
+	return false
+}
+
+func localProperty(pth, key string) (string, error) {
+	f, err := os.Open(pth)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == key {
+			// Backslashes and colons are escaped in properties files (sdk.dir=C\:\\Android\\sdk).
+			value := strings.NewReplacer(`\:`, ":", `\\`, `\`).Replace(strings.TrimSpace(parts[1]))
+			return value, nil
+		}
+	}
+
+	return "", scanner.Err()
+}
@@ -0,0 +1,135 @@
+package sdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, pth, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	if err := os.WriteFile(pth, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestReadRequirements(t *testing.T) {
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, "settings.gradle"), "include ':app', ':lib', ':rn'")
+	writeFile(t, filepath.Join(projectDir, "build.gradle"), `buildscript {
+    ext {
+        rnCompileSdk = 33
+        rnNdkVersion = "23.1.7779620"
+    }
+}`)
+	writeFile(t, filepath.Join(projectDir, "gradle", "libs.versions.toml"), "[versions]\nandroid-compileSdk = \"35\"\n")
+	writeFile(t, filepath.Join(projectDir, "app", "build.gradle"), `android {
+    // compileSdk 30
+    compileSdk 34
+    buildToolsVersion "34.0.0"
+    ndkVersion '25.1.8937393'
+}`)
+	writeFile(t, filepath.Join(projectDir, "lib", "build.gradle.kts"), `android {
+    compileSdk = libs.versions.android.compileSdk.get().toInt()
+}`)
+	writeFile(t, filepath.Join(projectDir, "rn", "build.gradle"), `android {
+    compileSdkVersion rootProject.ext.rnCompileSdk
+    ndkVersion rootProject.ext.rnNdkVersion
+    buildToolsVersion findProperty("unknown")
+}`)
+
+	components, err := ReadRequirements(projectDir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Component{
+		{Package: "platforms;android-34", Source: "compileSdk in app/build.gradle"},
+		{Package: "build-tools;34.0.0", Source: "buildToolsVersion in app/build.gradle"},
+		{Package: "ndk;25.1.8937393", Source: "ndkVersion in app/build.gradle"},
+		{Package: "platforms;android-35", Source: "compileSdk in lib/build.gradle.kts"},
+		{Package: "platforms;android-33", Source: "compileSdk in rn/build.gradle"},
+		{Package: "ndk;23.1.7779620", Source: "ndkVersion in rn/build.gradle"},
+	}, components)
+}
+
+func TestCheck(t *testing.T) {
+	sdkRoot := t.TempDir()
+	writeFile(t, filepath.Join(sdkRoot, "platforms", "android-34", "android.jar"), "")
+	writeFile(t, filepath.Join(sdkRoot, "ndk-bundle", "source.properties"), "Pkg.Desc = Android NDK\nPkg.Revision = 21.4.7075529\n")
+	components := []Component{
+		{Package: "platforms;android-34", Source: "compileSdk in app/build.gradle"},
+		{Package: "build-tools;34.0.0", Source: "buildToolsVersion in app/build.gradle"},
+		{Package: "ndk;21.4.7075529", Source: "ndkVersion in app/build.gradle"},
+		{Package: "ndk;25.1.8937393", Source: "ndkVersion in lib/build.gradle"},
+	}
+
+	problems := Check(sdkRoot, components)
+
+	assert.Equal(t, []string{
+		"The Android SDK licenses are not accepted (" + filepath.Join(sdkRoot, "licenses", "android-sdk-license") + " is missing or empty), run: sdkmanager --licenses",
+		"build-tools;34.0.0 (buildToolsVersion in app/build.gradle) is not installed",
+		"ndk;25.1.8937393 (ndkVersion in lib/build.gradle) is not installed",
+	}, problems)
+	assert.Equal(t, []string{"build-tools;34.0.0", "ndk;25.1.8937393"}, MissingPackages(sdkRoot, components))
+
+	writeFile(t, filepath.Join(sdkRoot, "licenses", "android-sdk-license"), "\n24333f8a63b6825ea9c5514f83c2829b004d1fee")
+	assert.Len(t, Check(sdkRoot, components), 2)
+}
+
+func TestCheck_License(t *testing.T) {
+	tests := []struct {
+		name    string
+		license string
+		want    []string
+	}{
+		{
+			name:    "current license",
+			license: "\n8933bad161af4178b1185d1a37fbf41ea5269c55\r\n24333f8a63b6825ea9c5514f83c2829b004d1fee\n",
+			want:    nil,
+		},
+		{
+			name:    "stale license",
+			license: "\nd56f5187479451eabf01fb78af6dfcb131a6481e",
+			want:    []string{"The accepted Android SDK license in %s is stale, the current license is not accepted, run: sdkmanager --licenses"},
+		},
+		{
+			name:    "unknown license is accepted",
+			license: "\n0123456789abcdef0123456789abcdef01234567",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdkRoot := t.TempDir()
+			pth := filepath.Join(sdkRoot, "licenses", "android-sdk-license")
+			writeFile(t, pth, tt.license)
+
+			var want []string
+			for _, problem := range tt.want {
+				want = append(want, fmt.Sprintf(problem, pth))
+			}
+			assert.Equal(t, want, Check(sdkRoot, nil))
+		})
+	}
+}
+
+func TestRoot(t *testing.T) {
+	t.Setenv("ANDROID_HOME", "/opt/android-sdk")
+	t.Setenv("ANDROID_SDK_ROOT", "")
+	projectDir := t.TempDir()
+
+	root, err := Root(projectDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "/opt/android-sdk", root)
+
+	writeFile(t, filepath.Join(projectDir, "local.properties"), "## This file is automatically generated\nsdk.dir=C\\:\\\\Users\\\\dev\\\\Android\\\\Sdk\n")
+
+	root, err = Root(projectDir)
+	assert.NoError(t, err)
+	assert.Equal(t, `C:\Users\dev\Android\Sdk`, root)
+}
@@ -0,0 +1,55 @@
+package sizereport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TotalCategory is the name of the artifact file size in the comparison.
+const TotalCategory = "total"
+
+// Delta is the compressed size change of a category compared to the baseline.
+type Delta struct {
+	Name     string
+	Baseline int64
+	Current  int64
+}
+
+// Change returns the size difference, negative if the category shrank.
+func (d Delta) Change() int64 {
+	return d.Current - d.Baseline
+}
+
+// Load reads a size report JSON, or creates the size report of an APK or AAB.
+func Load(pth string) (Report, error) {
+	if !strings.HasSuffix(strings.ToLower(pth), ".json") {
+		return Analyze(pth)
+	}
+
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return Report{}, err
+	}
+	var report Report
+	if err := json.Unmarshal(content, &report); err != nil {
+		return Report{}, fmt.Errorf("failed to parse size report: %s", err)
+	}
+	return report, nil
+}
+
+// Compare returns the compressed size changes of the categories of both reports, in the order of the current report
+// followed by the categories only the baseline has. The last delta is the change of the artifact file size.
+func Compare(baseline, current Report) []Delta {
+	var deltas []Delta
+	for _, category := range current.Categories {
+		deltas = append(deltas, Delta{Name: category.Name, Baseline: baseline.Category(category.Name).Compressed, Current: category.Compressed})
+	}
+	for _, category := range baseline.Categories {
+		if current.Category(category.Name) == (Size{}) {
+			deltas = append(deltas, Delta{Name: category.Name, Baseline: category.Compressed})
+		}
+	}
+	return append(deltas, Delta{Name: TotalCategory, Baseline: baseline.FileSize, Current: current.FileSize})
+}
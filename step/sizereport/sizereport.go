@@ -0,0 +1,156 @@
+// Package sizereport breaks down the size of APK and AAB artifacts by content: dex files, resource table,
+// resources, assets, native libraries per ABI and other files. The sizes are read from the zip central directory.
+package sizereport
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Categories of the artifact content. The native libraries are categorized per ABI: lib/<abi>.
+const (
+	CategoryDex           = "dex"
+	CategoryResourceTable = "resources.arsc"
+	CategoryResources     = "res"
+	CategoryAssets        = "assets"
+	CategoryOther         = "other"
+
+	nativeLibCategoryPrefix = "lib/"
+)
+
+// Size is the compressed and uncompressed size of zip entries.
+type Size struct {
+	Compressed   int64 `json:"compressed"`
+	Uncompressed int64 `json:"uncompressed"`
+}
+
+// Category is the size of a content category.
+type Category struct {
+	Name string `json:"name"`
+	Size
+}
+
+// Report is the size breakdown of an artifact.
+type Report struct {
+	Artifact   string     `json:"artifact"`
+	FileSize   int64      `json:"file_size"`
+	Total      Size       `json:"total"`
+	Categories []Category `json:"categories"`
+}
+
+// Category returns the size of the named category, zero if the artifact has no such content.
+func (r Report) Category(name string) Size {
+	for _, category := range r.Categories {
+		if category.Name == name {
+			return category.Size
+		}
+	}
+	return Size{}
+}
+
+// Analyze creates the size report of an APK or AAB.
+func Analyze(pth string) (Report, error) {
+	info, err := os.Stat(pth)
+	if err != nil {
+		return Report{}, err
+	}
+
+	r, err := zip.OpenReader(pth)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to open artifact: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	bundle := strings.HasSuffix(strings.ToLower(pth), ".aab")
+	sizes := map[string]Size{}
+	report := Report{Artifact: info.Name(), FileSize: info.Size()}
+	for _, file := range r.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+
+		name := file.Name
+		if bundle {
+			name = moduleRelativePath(name)
+		}
+
+		category := categoryOf(name)
+		size := sizes[category]
+		size.Compressed += int64(file.CompressedSize64)
+		size.Uncompressed += int64(file.UncompressedSize64)
+		sizes[category] = size
+
+		report.Total.Compressed += int64(file.CompressedSize64)
+		report.Total.Uncompressed += int64(file.UncompressedSize64)
+	}
+
+	for _, name := range sortedCategories(sizes) {
+		report.Categories = append(report.Categories, Category{Name: name, Size: sizes[name]})
+	}
+	return report, nil
+}
+
+// moduleRelativePath strips the module directory of a bundle entry (base/dex/classes.dex -> dex/classes.dex),
+// so that the bundle content is categorized like the APK content.
+func moduleRelativePath(name string) string {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return name
+	}
+	rel := name[i+1:]
+
+	switch {
+	case strings.HasPrefix(rel, "dex/"):
+		return strings.TrimPrefix(rel, "dex/")
+	case rel == "resources.pb":
+		return "resources.arsc"
+	case strings.HasPrefix(rel, "res/"), strings.HasPrefix(rel, "assets/"), strings.HasPrefix(rel, "lib/"):
+		return rel
+	}
+	return name
+}
+
+func categoryOf(name string) string {
+	switch {
+	case strings.HasPrefix(name, "classes") && strings.HasSuffix(name, ".dex") && !strings.Contains(name, "/"):
+		return CategoryDex
+	case name == "resources.arsc":
+		return CategoryResourceTable
+	case strings.HasPrefix(name, "res/"):
+		return CategoryResources
+	case strings.HasPrefix(name, "assets/"):
+		return CategoryAssets
+	case strings.HasPrefix(name, nativeLibCategoryPrefix):
+		parts := strings.Split(name, "/")
+		if len(parts) > 2 {
+			return nativeLibCategoryPrefix + parts[1]
+		}
+	}
+	return CategoryOther
+}
+
+// sortedCategories orders the categories as: dex, resource table, resources, assets, native libraries by ABI, other.
+func sortedCategories(sizes map[string]Size) []string {
+	order := map[string]int{CategoryDex: 0, CategoryResourceTable: 1, CategoryResources: 2, CategoryAssets: 3, CategoryOther: 5}
+	rank := func(name string) int {
+		if r, ok := order[name]; ok {
+			return r
+		}
+		return 4
+	}
+
+	var names []string
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if rank(names[i]) != rank(names[j]) {
+			return rank(names[i]) < rank(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,154 @@
+package sizereport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "app-release.apk")
+	writeArtifact(t, apkPath, map[string]int{
+		"AndroidManifest.xml":                10,
+		"classes.dex":                        1000,
+		"classes2.dex":                       500,
+		"resources.arsc":                     300,
+		"res/drawable/icon.png":              200,
+		"assets/fonts/font.ttf":              400,
+		"lib/arm64-v8a/libapp.so":            800,
+		"lib/x86_64/libapp.so":               900,
+		"META-INF/MANIFEST.MF":               20,
+		"kotlin/collections.kotlin_builtins": 5,
+	})
+
+	report, err := Analyze(apkPath)
+	if err != nil {
+		t.Fatalf("Analyze() error: %s", err)
+	}
+
+	assert.Equal(t, "app-release.apk", report.Artifact)
+	info, err := os.Stat(apkPath)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), report.FileSize)
+
+	var names []string
+	for _, category := range report.Categories {
+		names = append(names, category.Name)
+	}
+	assert.Equal(t, []string{"dex", "resources.arsc", "res", "assets", "lib/arm64-v8a", "lib/x86_64", "other"}, names)
+
+	assert.Equal(t, int64(1500), report.Category(CategoryDex).Uncompressed)
+	assert.Equal(t, int64(35), report.Category(CategoryOther).Uncompressed)
+	assert.Equal(t, int64(900), report.Category("lib/x86_64").Uncompressed)
+	assert.Equal(t, Size{}, report.Category("lib/armeabi-v7a"))
+	assert.Equal(t, int64(4135), report.Total.Uncompressed)
+	assert.True(t, report.Category(CategoryDex).Compressed < report.Category(CategoryDex).Uncompressed)
+
+	var compressed int64
+	for _, category := range report.Categories {
+		compressed += category.Compressed
+	}
+	assert.Equal(t, report.Total.Compressed, compressed)
+}
+
+func TestAnalyze_Bundle(t *testing.T) {
+	aabPath := filepath.Join(t.TempDir(), "app-release.aab")
+	writeArtifact(t, aabPath, map[string]int{
+		"BundleConfig.pb":                   10,
+		"base/manifest/AndroidManifest.xml": 10,
+		"base/dex/classes.dex":              1000,
+		"base/resources.pb":                 300,
+		"base/res/drawable/icon.png":        200,
+		"base/assets/fonts/font.ttf":        400,
+		"base/lib/arm64-v8a/libapp.so":      800,
+		"feature/dex/classes.dex":           100,
+		"feature/root/extra.txt":            30,
+	})
+
+	report, err := Analyze(aabPath)
+	if err != nil {
+		t.Fatalf("Analyze() error: %s", err)
+	}
+
+	assert.Equal(t, int64(1100), report.Category(CategoryDex).Uncompressed)
+	assert.Equal(t, int64(300), report.Category(CategoryResourceTable).Uncompressed)
+	assert.Equal(t, int64(200), report.Category(CategoryResources).Uncompressed)
+	assert.Equal(t, int64(400), report.Category(CategoryAssets).Uncompressed)
+	assert.Equal(t, int64(800), report.Category("lib/arm64-v8a").Uncompressed)
+	assert.Equal(t, int64(50), report.Category(CategoryOther).Uncompressed)
+}
+
+func TestAnalyze_InvalidArtifact(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "app-release.apk")
+	assert.NoError(t, os.WriteFile(pth, []byte("not a zip"), 0o600))
+
+	_, err := Analyze(pth)
+	assert.Error(t, err)
+}
+
+// writeArtifact writes a zip with entries of compressible content of the given sizes.
+func writeArtifact(t *testing.T, pth string, entries map[string]int) {
+	files := map[string]string{}
+	for name, size := range entries {
+		files[name] = strings.Repeat("a", size)
+	}
+	ziptest.Write(t, pth, ziptest.Files(files))
+}
+
+func TestCompare(t *testing.T) {
+	baseline := Report{
+		FileSize: 1000,
+		Categories: []Category{
+			{Name: CategoryDex, Size: Size{Compressed: 500}},
+			{Name: "lib/x86", Size: Size{Compressed: 300}},
+		},
+	}
+	current := Report{
+		FileSize: 1500,
+		Categories: []Category{
+			{Name: CategoryDex, Size: Size{Compressed: 450}},
+			{Name: CategoryAssets, Size: Size{Compressed: 1000}},
+		},
+	}
+
+	deltas := Compare(baseline, current)
+	assert.Equal(t, []Delta{
+		{Name: CategoryDex, Baseline: 500, Current: 450},
+		{Name: CategoryAssets, Baseline: 0, Current: 1000},
+		{Name: "lib/x86", Baseline: 300, Current: 0},
+		{Name: TotalCategory, Baseline: 1000, Current: 1500},
+	}, deltas)
+	assert.Equal(t, int64(-50), deltas[0].Change())
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "app-release.apk")
+	writeArtifact(t, apkPath, map[string]int{"classes.dex": 1000})
+
+	report, err := Analyze(apkPath)
+	if err != nil {
+		t.Fatalf("Analyze() error: %s", err)
+	}
+
+	reportPath := filepath.Join(dir, "app-release-size-report.json")
+	content, err := json.Marshal(report)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(reportPath, content, 0o600))
+
+	for _, pth := range []string{apkPath, reportPath} {
+		loaded, err := Load(pth)
+		assert.NoError(t, err)
+		assert.Equal(t, report, loaded)
+	}
+
+	assert.NoError(t, os.WriteFile(reportPath, []byte("{"), 0o600))
+	_, err = Load(reportPath)
+	assert.Error(t, err)
+}
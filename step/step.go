@@ -1,12 +1,22 @@
 package step
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/bitrise-io/go-android/gradle"
 	"github.com/bitrise-io/go-steputils/stepconf"
@@ -14,8 +24,27 @@ import (
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/apksig"
 	"github.com/bitrise-steplib/bitrise-step-android-build/step/buildcache"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/bundlesize"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/bundletool"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/daemonlogs"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/dex"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/dryrun"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradleargs"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/initscript"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/jdk"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/keystore"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/manifest"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/nativelib"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/redact"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/sarif"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/sdk"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/sizereport"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/zipalign"
 	"github.com/kballard/go-shellquote"
+	glob "github.com/ryanuber/go-glob"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -28,8 +57,51 @@ type Input struct {
 	Module          string `env:"module"`
 	BuildType       string `env:"build_type,opt[apk,aab]"`
 	Arguments       string `env:"arguments"`
+	DryRun          bool   `env:"dry_run,opt[yes,no]"`
 	CacheLevel      string `env:"cache_level"` // Deprecated
 	DeployDir       string `env:"BITRISE_DEPLOY_DIR,dir"`
+
+	GradleProperties   string `env:"gradle_properties"`
+	SecretEnvs         string `env:"secret_envs"`
+	Offline            bool   `env:"offline,opt[yes,no]"`
+	BuildCache         bool   `env:"build_cache,opt[yes,no]"`
+	ConfigurationCache bool   `env:"configuration_cache,opt[yes,no]"`
+	Parallel           bool   `env:"parallel,opt[yes,no]"`
+	MaxWorkers         string `env:"max_workers"`
+	WarningMode        string `env:"warning_mode"`
+
+	VersionCode       string `env:"version_code"`
+	VersionCodeOffset string `env:"version_code_offset"`
+	VersionName       string `env:"version_name"`
+
+	KeystorePath      string          `env:"keystore_path"`
+	KeystorePassword  stepconf.Secret `env:"keystore_password"`
+	KeyAlias          string          `env:"key_alias"`
+	KeyPassword       stepconf.Secret `env:"key_password"`
+	PostBuildSigning  bool            `env:"post_build_signing,opt[yes,no]"`
+	ExpiryWarningDays string          `env:"keystore_expiry_warning_days"`
+
+	BundletoolPath           string `env:"bundletool_path"`
+	BundletoolUniversalAPK   bool   `env:"bundletool_universal_apk,opt[yes,no]"`
+	BundletoolDeviceSpecPath string `env:"bundletool_device_spec_path"`
+
+	MaxArtifactSize       string `env:"max_artifact_size"`
+	SizeBaselinePath      string `env:"size_baseline_path"`
+	MaxSizeIncrease       string `env:"max_size_increase"`
+	CategorySizeIncreases string `env:"category_size_increases"`
+
+	BaselineAppPath         string `env:"baseline_app_path"`
+	NewDangerousPermissions string `env:"new_dangerous_permissions,opt[fail,warn]"`
+	ManifestAuditFailLevel  string `env:"manifest_audit_fail_level,opt[error,warning,note,off]"`
+
+	PageSizeCheck string `env:"page_size_check,opt[fail,warn,off]"`
+
+	SelectJDK            bool   `env:"select_jdk,opt[yes,no]"`
+	GradlewPath          string `env:"gradlew_path"`
+	SystemGradleFallback bool   `env:"system_gradle_fallback,opt[yes,no]"`
+	WrapperValidation    string `env:"wrapper_validation,opt[fail,warn,off]"`
+	SDKCheck             string `env:"sdk_check,opt[fail,warn,off]"`
+	WrapperChecksumsPath string `env:"wrapper_checksums_path"`
 }
 
 // Config ...
@@ -42,23 +114,113 @@ type Config struct {
 	AppPathPattern string
 	AppType        string
 	Arguments      []string
+	DryRun         bool
+
+	GradleProperties []gradleargs.Property
+	SecretEnvs       []string
+
+	Offline            bool
+	BuildCache         bool
+	ConfigurationCache bool
+	Parallel           bool
+	MaxWorkers         int
+	WarningMode        string
+
+	VersionCode int
+	VersionName string
+
+	Signing          *SigningConfig
+	PostBuildSigning bool
+
+	Bundletool *BundletoolConfig
+
+	SizeBudget *SizeBudget
+
+	ManifestBaseline       *ManifestBaseline
+	ManifestAuditFailLevel string
+
+	PageSizeCheck string
+
+	GradleVersion gradlewrapper.Version
+
+	SelectJDK            bool
+	GradlewPath          string
+	SystemGradleFallback bool
+	WrapperValidation    string
+	WrapperChecksums     []string
+	SDKCheck             string
 
 	DeployDir string
 }
 
+// SigningConfig is the keystore used to sign the release variants.
+type SigningConfig struct {
+	KeystorePath     string
+	KeystorePassword string
+	KeyAlias         string
+	KeyPassword      string
+}
+
+// BundletoolConfig describes the APKs generated from the exported AABs.
+type BundletoolConfig struct {
+	JarPath        string
+	UniversalAPK   bool
+	DeviceSpecPath string
+	Signing        *SigningConfig
+}
+
+// SizeBudget limits the size of the exported artifacts and their growth compared to a baseline.
+type SizeBudget struct {
+	// MaxArtifactSize is the maximum file size, 0 means no limit.
+	MaxArtifactSize int64
+	Baseline        *sizereport.Report
+	BaselineName    string
+	// MaxIncrease is the maximum growth of the total and of each category compared to the baseline,
+	// negative means no limit.
+	MaxIncrease int64
+	// CategoryMaxIncreases overrides MaxIncrease for the named categories.
+	CategoryMaxIncreases map[string]int64
+}
+
+// ManifestBaseline is the manifest of an earlier build the manifests of the exported artifacts are compared with.
+type ManifestBaseline struct {
+	Manifest manifest.Manifest
+	Name     string
+	// NewDangerousPermissions is the policy for dangerous permissions missing from the baseline.
+	NewDangerousPermissions string
+}
+
 // Result ...
 type Result struct {
-	appFiles     []gradle.Artifact
-	appType      string
-	mappingFiles []gradle.Artifact
+	appFiles         []gradle.Artifact
+	appType          string
+	mappingFiles     []gradle.Artifact
+	dryRun           bool
+	versionCode      int
+	versionName      string
+	variants         []string
+	signing          *SigningConfig
+	bundletool       *BundletoolConfig
+	sizeBudget       *SizeBudget
+	sizeReports      map[string]sizereport.Report
+	manifestBaseline *ManifestBaseline
+	auditFailLevel   string
+	pageSizeCheck    string
+}
+
+// gradleInvocation holds the environment and the arguments the step adds to the Gradle command.
+type gradleInvocation struct {
+	env  []string
+	args []string
 }
 
 // AndroidBuild ...
 type AndroidBuild struct {
-	inputParser stepconf.InputParser
-	logger      log.Logger
-	cmdFactory  command.Factory
-	detect      func(context.Context, log.Logger) buildcache.Detection
+	inputParser           stepconf.InputParser
+	logger                log.Logger
+	cmdFactory            command.Factory
+	detect                func(context.Context, log.Logger) buildcache.Detection
+	fetchWrapperChecksums gradlewrapper.ChecksumFetcher
 }
 
 // GradleProjectWrapper ...
@@ -76,17 +238,49 @@ const (
 	aabEnvKey     = "BITRISE_AAB_PATH"
 	aabListEnvKey = "BITRISE_AAB_PATH_LIST"
 
+	aabDownloadSizeEnvKey = "BITRISE_AAB_DOWNLOAD_SIZE_PATH"
+	sizeReportEnvKey      = "BITRISE_APP_SIZE_REPORT_PATH"
+	manifestDiffEnvKey    = "BITRISE_MANIFEST_DIFF_PATH"
+	manifestAuditEnvKey   = "BITRISE_MANIFEST_AUDIT_PATH"
+
 	mappingFileEnvKey  = "BITRISE_MAPPING_PATH"
 	mappingFilePattern = "*build/*/mapping.txt"
+
+	apkSignedListEnvKey        = "BITRISE_APK_SIGNED_LIST"
+	apkSigningSchemeListEnvKey = "BITRISE_APK_SIGNING_SCHEME_LIST"
+	apkCertSHA256ListEnvKey    = "BITRISE_APK_CERT_SHA256_LIST"
+	apkZipalignedListEnvKey    = "BITRISE_APK_ZIPALIGNED_LIST"
+
+	universalAPKEnvKey     = "BITRISE_UNIVERSAL_APK_PATH"
+	universalAPKListEnvKey = "BITRISE_UNIVERSAL_APK_PATH_LIST"
+	apksEnvKey             = "BITRISE_APKS_PATH"
+	apksListEnvKey         = "BITRISE_APKS_PATH_LIST"
+
+	versionCodeEnvKey = "BITRISE_APP_VERSION_CODE"
+	versionNameEnvKey = "BITRISE_APP_VERSION_NAME"
+
+	// maxVersionCode is the greatest version code Google Play accepts.
+	maxVersionCode = 2100000000
+
+	daemonLogsZipName = "gradle-daemon-logs.zip"
+
+	problemsReportRelPath = "build/reports/problems/problems-report.html"
+)
+
+// Policies of the pre-flight checks.
+const (
+	policyFail = "fail"
+	policyOff  = "off"
 )
 
 // NewAndroidBuild ...
 func NewAndroidBuild(inputParser stepconf.InputParser, logger log.Logger, cmdFactory command.Factory) *AndroidBuild {
 	return &AndroidBuild{
-		inputParser: inputParser,
-		logger:      logger,
-		cmdFactory:  cmdFactory,
-		detect:      buildcache.Detect,
+		inputParser:           inputParser,
+		logger:                logger,
+		cmdFactory:            cmdFactory,
+		detect:                buildcache.Detect,
+		fetchWrapperChecksums: gradlewrapper.FetchOfficialChecksums,
 	}
 }
 
@@ -97,17 +291,96 @@ func (a AndroidBuild) ProcessConfig() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
-	stepconf.Print(input)
 
 	args, err := shellquote.Split(input.Arguments)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to parse arguments: %s", err)
 	}
 
+	gradleProperties, err := gradleargs.ParseProperties(input.GradleProperties, os.LookupEnv)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse gradle_properties: %s", err)
+	}
+
+	secretEnvs := parseSecretEnvs(input.SecretEnvs)
+	stepconf.Print(redactedInput(input, a.redactor(Config{Arguments: args, GradleProperties: gradleProperties, SecretEnvs: secretEnvs})))
+
 	if input.CacheLevel != "" {
 		a.logger.Warnf("The cache_level Input (branch-based legacy caching) is deprecated, please use dedicated Key-based caching Steps instead.")
 	}
 
+	var maxWorkers int
+	if input.MaxWorkers != "" {
+		maxWorkers, err = strconv.Atoi(input.MaxWorkers)
+		if err != nil || maxWorkers < 1 {
+			return Config{}, fmt.Errorf("max_workers should be a positive number, got: %s", input.MaxWorkers)
+		}
+	}
+
+	switch input.WarningMode {
+	case "", "all", "summary", "none", "fail":
+	default:
+		return Config{}, fmt.Errorf("warning_mode should be one of all, summary, none, fail or empty, got: %s", input.WarningMode)
+	}
+
+	versionCode, err := parseVersionCode(input.VersionCode, input.VersionCodeOffset)
+	if err != nil {
+		return Config{}, err
+	}
+
+	signing, err := parseSigningConfig(input)
+	if err != nil {
+		return Config{}, err
+	}
+	if input.PostBuildSigning && signing == nil {
+		return Config{}, fmt.Errorf("post_build_signing requires keystore_path to be set")
+	}
+	if signing != nil {
+		expiryWarningDays, err := strconv.Atoi(input.ExpiryWarningDays)
+		if err != nil || expiryWarningDays < 0 {
+			return Config{}, fmt.Errorf("keystore_expiry_warning_days should be a non-negative number, got: %s", input.ExpiryWarningDays)
+		}
+		if err := a.checkSigningKeystore(*signing, expiryWarningDays, time.Now()); err != nil {
+			return Config{}, err
+		}
+	}
+
+	bundletoolConfig, err := parseBundletoolConfig(input, signing)
+	if err != nil {
+		return Config{}, err
+	}
+	if bundletoolConfig != nil && input.BuildType != aabAppType {
+		a.logger.Warnf("bundletool_path is ignored, APKs are generated only when build_type is aab")
+		bundletoolConfig = nil
+	}
+
+	sizeBudget, err := parseSizeBudget(input)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var manifestBaseline *ManifestBaseline
+	if input.BaselineAppPath != "" {
+		baseline, err := manifest.Read(input.BaselineAppPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read the manifest of the baseline app (%s): %s", input.BaselineAppPath, err)
+		}
+		manifestBaseline = &ManifestBaseline{
+			Manifest:                baseline,
+			Name:                    filepath.Base(input.BaselineAppPath),
+			NewDangerousPermissions: input.NewDangerousPermissions,
+		}
+	}
+
+	wrapperChecksums := gradlewrapper.BundledChecksums()
+	if input.WrapperChecksumsPath != "" {
+		checksums, err := gradlewrapper.ReadChecksumsFile(input.WrapperChecksumsPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read Gradle wrapper checksums file: %s", err)
+		}
+		wrapperChecksums = append(wrapperChecksums, checksums...)
+	}
+
 	return Config{
 		ProjectLocation: input.ProjectLocation,
 		AppPathPattern:  input.AppPathPattern,
@@ -115,7 +388,42 @@ func (a AndroidBuild) ProcessConfig() (Config, error) {
 		Module:          input.Module,
 		AppType:         input.BuildType,
 		Arguments:       args,
+		DryRun:          input.DryRun,
 		DeployDir:       input.DeployDir,
+
+		GradleProperties: gradleProperties,
+		SecretEnvs:       secretEnvs,
+
+		Offline:            input.Offline,
+		BuildCache:         input.BuildCache,
+		ConfigurationCache: input.ConfigurationCache,
+		Parallel:           input.Parallel,
+		MaxWorkers:         maxWorkers,
+		WarningMode:        input.WarningMode,
+
+		VersionCode: versionCode,
+		VersionName: input.VersionName,
+
+		Signing:          signing,
+		PostBuildSigning: input.PostBuildSigning,
+
+		Bundletool: bundletoolConfig,
+
+		SizeBudget: sizeBudget,
+
+		ManifestBaseline:       manifestBaseline,
+		ManifestAuditFailLevel: input.ManifestAuditFailLevel,
+
+		PageSizeCheck: input.PageSizeCheck,
+
+		GradleVersion: a.readGradleVersion(input.ProjectLocation, input.GradlewPath),
+
+		SelectJDK:            input.SelectJDK,
+		GradlewPath:          input.GradlewPath,
+		SystemGradleFallback: input.SystemGradleFallback,
+		WrapperValidation:    input.WrapperValidation,
+		WrapperChecksums:     wrapperChecksums,
+		SDKCheck:             input.SDKCheck,
 	}, nil
 }
 
@@ -126,9 +434,52 @@ func (a AndroidBuild) Run(cfg Config) (Result, error) {
 		return Result{}, fmt.Errorf("failed to open Gradle project: %s", err)
 	}
 
+	var invocation gradleInvocation
+	if cfg.SelectJDK {
+		javaHome, err := a.selectJavaHome(cfg)
+		if err != nil {
+			return Result{}, err
+		}
+		if javaHome != "" {
+			invocation.env = append(invocation.env, "JAVA_HOME="+javaHome)
+		}
+	}
+
+	if err := a.checkAndroidSDK(cfg); err != nil {
+		return Result{}, err
+	}
+
+	initScriptPaths, cleanupInitScripts, err := initscript.Write(initScripts(cfg))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to write Gradle init scripts: %s", err)
+	}
+	defer func() {
+		if err := cleanupInitScripts(); err != nil {
+			a.logger.Warnf("Failed to remove Gradle init scripts: %s", err)
+		}
+	}()
+	invocation.args = append(invocation.args, initscript.Args(initScriptPaths)...)
+	if cfg.Signing != nil && !cfg.PostBuildSigning {
+		invocation.env = append(invocation.env,
+			initscript.KeystorePathEnvKey+"="+cfg.Signing.KeystorePath,
+			initscript.KeystorePasswordEnvKey+"="+cfg.Signing.KeystorePassword,
+			initscript.KeyAliasEnvKey+"="+cfg.Signing.KeyAlias,
+			initscript.KeyPasswordEnvKey+"="+cfg.Signing.KeyPassword,
+		)
+	}
+
+	if cfg.DryRun {
+		return a.executeDryRun(context.Background(), cfg, invocation)
+	}
+
 	started := time.Now()
 
-	if err := a.executeGradleBuild(context.Background(), cfg); err != nil {
+	err = a.executeGradleBuild(context.Background(), cfg, invocation)
+	a.exportProblemsReport(cfg)
+	if err != nil {
+		if logsPath := a.collectDaemonLogs(cfg, started); logsPath != "" {
+			return Result{}, fmt.Errorf("%s\nGradle daemon logs and JVM crash reports are available at: %s", err, logsPath)
+		}
 		return Result{}, err
 	}
 
@@ -157,21 +508,39 @@ func (a AndroidBuild) Run(cfg Config) (Result, error) {
 		}
 	}
 
+	sizeReports := a.printSizeReports(filteredArtifacts)
+
 	if len(filteredArtifacts) == 0 {
 		a.logger.Warnf("No app artifacts found with patterns:\n%s", cfg.AppPathPattern)
 		a.logger.Warnf("If you have changed default APK, AAB export path in your gradle files then you might need to change app_path_pattern accordingly.")
 	}
 
 	return Result{
-		appFiles:     filteredArtifacts,
-		appType:      cfg.AppType,
-		mappingFiles: mappings,
+		appFiles:         filteredArtifacts,
+		appType:          cfg.AppType,
+		mappingFiles:     mappings,
+		versionCode:      cfg.VersionCode,
+		versionName:      cfg.VersionName,
+		variants:         cfg.Variants,
+		signing:          postBuildSigning(cfg),
+		bundletool:       cfg.Bundletool,
+		sizeBudget:       cfg.SizeBudget,
+		sizeReports:      sizeReports,
+		manifestBaseline: cfg.ManifestBaseline,
+		auditFailLevel:   cfg.ManifestAuditFailLevel,
+		pageSizeCheck:    cfg.PageSizeCheck,
 	}, nil
 }
 
 // Export ...
 func (a AndroidBuild) Export(result Result, deployDir string) error {
-	exportedArtifactPaths, err := a.exportArtifacts(result.appFiles, deployDir)
+	if result.dryRun {
+		a.logger.Println()
+		a.logger.Printf("Dry run, no artifacts to export")
+		return nil
+	}
+
+	exportedArtifactPaths, sourcePaths, err := a.exportArtifacts(result.appFiles, deployDir)
 	if err != nil {
 		return fmt.Errorf("failed to export artifact: %v", err)
 	}
@@ -180,6 +549,13 @@ func (a AndroidBuild) Export(result Result, deployDir string) error {
 		return fmt.Errorf("could not export any app artifacts")
 	}
 
+	if result.signing != nil {
+		exportedArtifactPaths, err = a.signArtifacts(exportedArtifactPaths, result.appType, *result.signing)
+		if err != nil {
+			return err
+		}
+	}
+
 	lastExportedArtifact := exportedArtifactPaths[len(exportedArtifactPaths)-1]
 
 	// Use the correct env key for the selected build type
@@ -212,18 +588,94 @@ func (a AndroidBuild) Export(result Result, deployDir string) error {
 	}
 	a.logger.Printf("  Env    [ $%s = %s ]", envKey, paths)
 
+	sizeViolations, err := a.exportSizeReports(exportedArtifactPaths, sourcePaths, result.sizeReports, result.sizeBudget)
+	if err != nil {
+		return err
+	}
+
+	manifests := a.readManifests(exportedArtifactPaths)
+
+	var newDangerousPermissions []string
+	if result.manifestBaseline != nil {
+		newDangerousPermissions, err = a.exportManifestDiffs(exportedArtifactPaths, manifests, *result.manifestBaseline)
+		if err != nil {
+			return err
+		}
+	}
+
+	auditFailures, err := a.exportManifestAudits(exportedArtifactPaths, manifests, result.variants, result.auditFailLevel)
+	if err != nil {
+		return err
+	}
+
+	nativeLibraries := a.inspectNativeLibraries(exportedArtifactPaths)
+
+	var pageSizeProblems []string
+	if result.pageSizeCheck != policyOff {
+		pageSizeProblems = a.checkPageSizes(exportedArtifactPaths, nativeLibraries, result.appType, result.pageSizeCheck)
+	}
+
+	a.checkNativeDebugInfo(exportedArtifactPaths, nativeLibraries, result.variants)
+
+	if result.appType == apkAppType {
+		if err := a.exportSignatures(exportedArtifactPaths, result.variants); err != nil {
+			return err
+		}
+		if err := a.exportAlignments(exportedArtifactPaths); err != nil {
+			return err
+		}
+		a.checkDexFiles(exportedArtifactPaths, result.mappingFiles)
+	}
+
+	if result.appType == aabAppType {
+		if err := a.exportDownloadSizes(exportedArtifactPaths); err != nil {
+			return err
+		}
+	}
+
+	if result.appType == aabAppType && result.bundletool != nil {
+		if err := a.exportBundletoolAPKs(exportedArtifactPaths, *result.bundletool); err != nil {
+			return err
+		}
+	}
+
+	if err := a.exportVersion(result); err != nil {
+		return err
+	}
+
+	if err := a.exportMappingFiles(result.mappingFiles, deployDir); err != nil {
+		return err
+	}
+
+	if len(sizeViolations) > 0 {
+		return fmt.Errorf("size budget exceeded:\n%s", strings.Join(sizeViolations, "\n"))
+	}
+	if len(pageSizeProblems) > 0 && result.pageSizeCheck == policyFail {
+		return fmt.Errorf("native libraries do not support 16 KB page size:\n%s", strings.Join(pageSizeProblems, "\n"))
+	}
+	if len(auditFailures) > 0 {
+		return fmt.Errorf("manifest audit found issues with at least %s severity:\n%s", result.auditFailLevel, strings.Join(auditFailures, "\n"))
+	}
+	if len(newDangerousPermissions) > 0 && result.manifestBaseline.NewDangerousPermissions == policyFail {
+		return fmt.Errorf("new dangerous permissions compared to %s: %s", result.manifestBaseline.Name, strings.Join(newDangerousPermissions, ", "))
+	}
+	return nil
+}
+
+// exportMappingFiles exports the obfuscation mapping files to the deploy dir.
+func (a AndroidBuild) exportMappingFiles(mappingFiles []gradle.Artifact, deployDir string) error {
 	a.logger.Println()
 
 	a.logger.Infof("Export mapping files:")
 	a.logger.Println()
 
-	if len(result.mappingFiles) == 0 {
+	if len(mappingFiles) == 0 {
 		a.logger.Printf("No mapping files found with pattern: %s", mappingFilePattern)
 		a.logger.Printf("You might have changed default mapping file export path in your gradle files or obfuscation is not enabled in your project.")
 		return nil
 	}
 
-	exportedArtifactPaths, err = a.exportArtifacts(result.mappingFiles, deployDir)
+	exportedArtifactPaths, _, err := a.exportArtifacts(mappingFiles, deployDir)
 	if err != nil {
 		return fmt.Errorf("failed to export artifact: %v", err)
 	}
@@ -232,7 +684,7 @@ func (a AndroidBuild) Export(result Result, deployDir string) error {
 		return fmt.Errorf("could not export any mapping.txt")
 	}
 
-	lastExportedArtifact = exportedArtifactPaths[len(exportedArtifactPaths)-1]
+	lastExportedArtifact := exportedArtifactPaths[len(exportedArtifactPaths)-1]
 
 	a.logger.Println()
 	if err := tools.ExportEnvironmentWithEnvman(mappingFileEnvKey, lastExportedArtifact); err != nil {
@@ -272,6 +724,154 @@ func gradleTaskName(appType, module, variant string) (string, error) {
 	return task, nil
 }
 
+// gradleArguments adapts the arguments input to the project's Gradle version (deprecated options are translated,
+// unknown ones are removed), then merges the options of the typed inputs into it. Options already present in the
+// arguments input and options not supported by the Gradle version are dropped with a warning.
+// --console=plain is added unless the arguments input selects a console.
+func (a AndroidBuild) gradleArguments(cfg Config) []string {
+	args, changes := gradleargs.Adapt(cfg.Arguments, cfg.GradleVersion)
+	for _, change := range changes {
+		a.logger.Warnf("Gradle arguments: %s", change)
+	}
+
+	// The plain console keeps the log free of progress bar artifacts, unless the arguments select a console.
+	var options []gradleargs.Option
+	plainConsole := gradleargs.PlainConsole()
+	plainConsoleAdded := false
+	if console := gradleargs.Find(args, plainConsole.Flag); console != "" {
+		a.logger.Printf("Gradle arguments: %s is set, %s is not added", console, plainConsole)
+	} else {
+		options = append(options, plainConsole)
+		plainConsoleAdded = true
+	}
+	if cfg.Offline {
+		options = append(options, gradleargs.Offline())
+	}
+	if cfg.BuildCache {
+		options = append(options, gradleargs.BuildCache())
+	}
+	if cfg.ConfigurationCache {
+		options = append(options, gradleargs.ConfigurationCache())
+	}
+	if cfg.Parallel {
+		options = append(options, gradleargs.Parallel())
+	}
+	if cfg.MaxWorkers > 0 {
+		options = append(options, gradleargs.MaxWorkers(cfg.MaxWorkers))
+	}
+	if cfg.WarningMode != "" {
+		options = append(options, gradleargs.WarningMode(cfg.WarningMode))
+	}
+
+	args, dropped := gradleargs.Merge(args, options, cfg.GradleVersion)
+	for _, d := range dropped {
+		if d.Option.Flag == plainConsole.Flag {
+			a.logger.Printf("Gradle arguments: %s is not added: %s", d.Option, d.Reason)
+			plainConsoleAdded = false
+			continue
+		}
+		a.logger.Warnf("Ignoring %s: %s", d.Option, d.Reason)
+	}
+	if plainConsoleAdded {
+		a.logger.Printf("Gradle arguments: added %s to keep the log readable", plainConsole)
+	}
+
+	for _, property := range cfg.GradleProperties {
+		args = append(args, property.Arg())
+	}
+
+	return args
+}
+
+// redactor masks the secrets in the printed command and the Gradle output: the secret Gradle properties,
+// the values of sensitive looking arguments (like -Pandroid.injected.signing.store.password) and the values
+// of the environment variables listed in the secret_envs input.
+func (a AndroidBuild) redactor(cfg Config) *redact.Redactor {
+	r := redact.New(redact.SensitiveArgValues(cfg.Arguments)...)
+	for _, property := range cfg.GradleProperties {
+		if property.Secret {
+			r.Add(property.Value)
+		}
+	}
+	for _, key := range cfg.SecretEnvs {
+		r.Add(os.Getenv(key))
+	}
+	if cfg.Signing != nil {
+		r.Add(cfg.Signing.KeystorePassword)
+		r.Add(cfg.Signing.KeyPassword)
+	}
+
+	return r
+}
+
+// redactedInput returns a copy of the input for printing, with the secret values of the inputs which are not
+// stepconf.Secret masked.
+func redactedInput(input Input, redactor *redact.Redactor) Input {
+	input.Arguments = redactor.Redact(input.Arguments)
+	input.GradleProperties = redactor.Redact(input.GradleProperties)
+
+	return input
+}
+
+// readGradleVersion returns the Gradle version of the project's wrapper, or the zero version if it is unknown
+// (no wrapper, or the system Gradle is used).
+func (a AndroidBuild) readGradleVersion(projectLocation, gradlewPath string) gradlewrapper.Version {
+	if gradlewPath == "" {
+		pth, err := gradlewrapper.Locate(projectLocation)
+		if err != nil {
+			return gradlewrapper.Version{}
+		}
+		gradlewPath = pth
+	}
+
+	version, err := gradlewrapper.ReadVersion(gradlewPath)
+	if err != nil {
+		a.logger.Warnf("Failed to read the Gradle version: %s", err)
+		return gradlewrapper.Version{}
+	}
+	a.logger.Printf("Gradle version: %s", version)
+
+	return version
+}
+
+// exportProblemsReport copies the problems report generated by Gradle 8.12+ into the deploy dir.
+func (a AndroidBuild) exportProblemsReport(cfg Config) {
+	if !gradleargs.SupportsProblemsReport(cfg.GradleVersion) {
+		return
+	}
+
+	reportPath := filepath.Join(cfg.ProjectLocation, problemsReportRelPath)
+	if exists, err := pathutil.IsPathExists(reportPath); err != nil || !exists {
+		return
+	}
+
+	dst := filepath.Join(cfg.DeployDir, filepath.Base(reportPath))
+	if err := command.CopyFile(reportPath, dst); err != nil {
+		a.logger.Warnf("Failed to export the Gradle problems report: %s", err)
+		return
+	}
+	a.logger.Printf("Gradle problems report: $BITRISE_DEPLOY_DIR/%s", filepath.Base(dst))
+}
+
+func gradleTasks(cfg Config) ([]string, error) {
+	variants := cfg.Variants
+	if len(variants) == 0 {
+		// Without a selected variant the task builds all variants.
+		variants = []string{""}
+	}
+
+	var tasks []string
+	for _, variant := range variants {
+		taskName, err := gradleTaskName(cfg.AppType, cfg.Module, variant)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, taskName)
+	}
+
+	return tasks, nil
+}
+
 func (a AndroidBuild) getArtifacts(gradleProject GradleProjectWrapper, started time.Time, patterns []string, includeModule bool) (artifacts []gradle.Artifact, err error) {
 	for _, pattern := range patterns {
 		afs, err := gradleProject.FindArtifacts(started, pattern, includeModule)
@@ -294,34 +894,42 @@ func (a AndroidBuild) getArtifacts(gradleProject GradleProjectWrapper, started t
 	return
 }
 
-func (a AndroidBuild) executeGradleBuild(ctx context.Context, cfg Config) error {
+func (a AndroidBuild) executeGradleBuild(ctx context.Context, cfg Config, invocation gradleInvocation) error {
 	a.logger.Infof("Run build:")
 
-	var tasks []string
-	for _, variant := range cfg.Variants {
-		taskName, err := gradleTaskName(cfg.AppType, cfg.Module, variant)
-		if err != nil {
-			return err
-		}
-		tasks = append(tasks, taskName)
+	tasks, err := gradleTasks(cfg)
+	if err != nil {
+		return err
 	}
 
-	cmdArgs := append(tasks, cfg.Arguments...)
-	cmdOpts := command.Opts{
-		Dir:    cfg.ProjectLocation,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	}
-	absPath, err := filepath.Abs(cfg.ProjectLocation)
+	gradlewPath, err := a.gradleExecutable(ctx, cfg)
 	if err != nil {
 		return err
 	}
-	gradlewPath := filepath.Join(absPath, "gradlew")
+
+	redactor := a.redactor(cfg)
+	if skipped := redactor.Skipped(); skipped > 0 {
+		a.logger.Warnf("%d secret value(s) are shorter than %d characters and are not redacted from the build log", skipped, redact.MinSecretLength)
+	}
+	stdout := redact.NewWriter(os.Stdout, redactor)
+	stderr := redact.NewWriter(os.Stderr, redactor)
+	defer func() {
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+	}()
+
+	cmdArgs := append(append(tasks, a.gradleArguments(cfg)...), invocation.args...)
+	cmdOpts := command.Opts{
+		Dir:    cfg.ProjectLocation,
+		Stdout: stdout,
+		Stderr: stderr,
+		Env:    invocation.env,
+	}
 
 	cmd := a.buildGradleCommand(ctx, gradlewPath, cmdArgs, &cmdOpts)
 
 	a.logger.Println()
-	a.logger.Donef("$ " + cmd.PrintableCommandArgs())
+	a.logger.Donef("$ " + redactor.Redact(cmd.PrintableCommandArgs()))
 	a.logger.Println()
 
 	if err := cmd.Run(); err != nil {
@@ -331,30 +939,346 @@ func (a AndroidBuild) executeGradleBuild(ctx context.Context, cfg Config) error
 	return nil
 }
 
-// buildGradleCommand constructs the gradle invocation, transparently wrapping
-// it in `bitrise-build-cache react-native run --` when the Bitrise Build Cache
-// CLI is installed and React Native build cache is active on this machine.
-// When no wrap is needed (CLI absent, probe failed, or RN cache not activated),
-// the original `gradlew ...` command is returned unchanged.
-func (a AndroidBuild) buildGradleCommand(ctx context.Context, gradlewPath string, cmdArgs []string, cmdOpts *command.Opts) command.Command {
-	det := a.detect(ctx, a.logger)
-	if !det.ReactNativeEnabled {
-		a.logger.Debugf("Bitrise Build Cache: no RN wrap (cli=%v enabled=%v)", det.CLIPath != "", det.ReactNativeEnabled)
+// executeDryRun runs the build tasks with --dry-run, prints the resolved task graph and the artifacts
+// the build is expected to produce, and whether the app_path_pattern input would find them.
+func (a AndroidBuild) executeDryRun(ctx context.Context, cfg Config, invocation gradleInvocation) (Result, error) {
+	a.logger.Infof("Dry run:")
 
-		return a.cmdFactory.Create(gradlewPath, cmdArgs, cmdOpts)
+	tasks, err := gradleTasks(cfg)
+	if err != nil {
+		return Result{}, err
 	}
 
-	a.logger.Infof("Bitrise Build Cache: React Native cache active — wrapping gradle with %s", det.CLIPath)
+	gradlewPath, err := a.gradleExecutable(ctx, cfg)
+	if err != nil {
+		return Result{}, err
+	}
 
-	wrapped := append([]string{"react-native", "run", "--", gradlewPath}, cmdArgs...)
+	var output bytes.Buffer
+	redactor := a.redactor(cfg)
+	cmdArgs := append(append(append(tasks, "--dry-run"), a.gradleArguments(cfg)...), invocation.args...)
+	cmdOpts := command.Opts{
+		Dir:    cfg.ProjectLocation,
+		Stdout: &output,
+		Stderr: &output,
+		Env:    invocation.env,
+	}
+	cmd := a.buildGradleCommand(ctx, gradlewPath, cmdArgs, &cmdOpts)
 
-	return a.cmdFactory.Create(det.CLIPath, wrapped, cmdOpts)
-}
+	a.logger.Println()
+	a.logger.Donef("$ " + redactor.Redact(cmd.PrintableCommandArgs()))
+	a.logger.Println()
 
-func (a AndroidBuild) printAppSearchInfo(appArtifacts []gradle.Artifact, appPathPatterns []string) {
-	var artPaths []string
-	for _, a := range appArtifacts {
-		artPaths = append(artPaths, a.Name)
+	if err := cmd.Run(); err != nil {
+		a.logger.Printf(redactor.Redact(output.String()))
+		return Result{}, fmt.Errorf("dry run failed: %v", err)
+	}
+
+	graph := dryrun.ParseTaskGraph(output.String())
+	a.logger.Donef("Task graph (%d tasks):", len(graph))
+	a.logger.Printf(strings.Join(graph, "\n"))
+	a.logger.Println()
+
+	absProjectLocation, err := filepath.Abs(cfg.ProjectLocation)
+	if err != nil {
+		return Result{}, err
+	}
+
+	appPathPatterns := strings.Split(cfg.AppPathPattern, "\n")
+	planned := dryrun.PlannedArtifacts(absProjectLocation, graph, cfg.AppType)
+	if len(planned) == 0 {
+		a.logger.Warnf("No %s producing task found in the task graph", cfg.AppType)
+	} else {
+		a.logger.Donef("Expected artifacts:")
+	}
+	for _, artifact := range planned {
+		if matchesAnyPattern(artifact.Path, appPathPatterns) {
+			a.logger.Printf("  %s (%s)", artifact.Path, artifact.Task)
+		} else {
+			a.logger.Warnf("  %s (%s) does not match any of the app_path_pattern patterns", artifact.Path, artifact.Task)
+		}
+	}
+
+	return Result{appType: cfg.AppType, dryRun: true}, nil
+}
+
+func matchesAnyPattern(pth string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if glob.Glob(pattern, pth) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectJavaHome checks the active JDK against the Java version required by the project (derived from the
+// Android Gradle Plugin version). If the active JDK is too old, a compatible installed JDK is returned to be used
+// as JAVA_HOME. An empty path means the active JDK can be used.
+// The toolchain settings are only reported: Gradle provisions the toolchain, the daemon can run on an older JDK.
+func (a AndroidBuild) selectJavaHome(cfg Config) (string, error) {
+	if toolchain, err := jdk.ToolchainVersion(cfg.ProjectLocation); err != nil {
+		a.logger.Debugf("Failed to read the Java toolchain settings: %s", err)
+	} else if toolchain.Version > 0 {
+		a.logger.Printf("Java toolchain: %s, Gradle provisions it for the compilation", toolchain.Reason)
+	}
+
+	requirement, err := jdk.RequiredVersion(cfg.ProjectLocation)
+	if err != nil {
+		a.logger.Warnf("Failed to determine the required Java version: %s", err)
+		return "", nil
+	}
+	if requirement.Version == 0 {
+		a.logger.Debugf("Could not determine the required Java version, using the active JDK")
+		return "", nil
+	}
+
+	if active, err := a.activeJavaVersion(); err != nil {
+		a.logger.Warnf("Failed to determine the active Java version, using the active JDK: %s", err)
+		return "", nil
+	} else if active >= requirement.Version {
+		a.logger.Printf("Active JDK: Java %d (%s)", active, requirement.Reason)
+		return "", nil
+	} else {
+		a.logger.Warnf("Active JDK is Java %d, but %s", active, requirement.Reason)
+	}
+
+	installations := jdk.FindInstallations(jdk.SearchPaths())
+	selected, ok := jdk.Select(installations, requirement.Version)
+	if !ok {
+		var found []string
+		for _, installation := range installations {
+			found = append(found, fmt.Sprintf("Java %d (%s)", installation.Version, installation.Home))
+		}
+		if len(found) == 0 {
+			found = append(found, "none")
+		}
+		return "", fmt.Errorf("%s, but no compatible JDK is installed. Installed JDKs: %s. Install Java %d or set JAVA_HOME to a compatible JDK", requirement.Reason, strings.Join(found, ", "), requirement.Version)
+	}
+
+	a.logger.Donef("Using Java %d for the build: JAVA_HOME=%s", selected.Version, selected.Home)
+
+	return selected.Home, nil
+}
+
+// checkAndroidSDK verifies that the SDK platforms, build-tools and NDK versions declared in the build scripts
+// are installed and the SDK licenses are accepted. All problems are reported at once, while Gradle would reveal them
+// one by one, each after a long configuration phase.
+func (a AndroidBuild) checkAndroidSDK(cfg Config) error {
+	if cfg.SDKCheck == policyOff {
+		return nil
+	}
+
+	sdkRoot, err := sdk.Root(cfg.ProjectLocation)
+	if err != nil {
+		a.logger.Warnf("Failed to determine the Android SDK location: %s", err)
+		return nil
+	}
+	if sdkRoot == "" {
+		a.logger.Warnf("Android SDK location is not set (ANDROID_HOME or sdk.dir in local.properties), skipping the SDK check")
+		return nil
+	}
+
+	components, err := sdk.ReadRequirements(cfg.ProjectLocation)
+	if err != nil {
+		a.logger.Warnf("Failed to read the required Android SDK components: %s", err)
+		return nil
+	}
+
+	problems := sdk.Check(sdkRoot, components)
+	if len(problems) == 0 {
+		a.logger.Donef("Required Android SDK components are installed")
+		return nil
+	}
+
+	message := fmt.Sprintf("Android SDK (%s) problems:\n- %s", sdkRoot, strings.Join(problems, "\n- "))
+	if missing := sdk.MissingPackages(sdkRoot, components); len(missing) > 0 {
+		message += fmt.Sprintf("\nInstall the missing components with: sdkmanager \"%s\"", strings.Join(missing, "\" \""))
+	}
+
+	if cfg.SDKCheck == policyFail {
+		return errors.New(message)
+	}
+	a.logger.Warnf("%s", message)
+
+	return nil
+}
+
+func (a AndroidBuild) activeJavaVersion() (int, error) {
+	javaPath := "java"
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		javaPath = filepath.Join(javaHome, "bin", "java")
+	}
+
+	out, err := a.cmdFactory.Create(javaPath, []string{"-version"}, nil).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("%s -version failed: %s", javaPath, err)
+	}
+
+	return jdk.ParseVersionOutput(out)
+}
+
+// gradleExecutable returns the Gradle executable to run: the gradlew_path input if set, otherwise the first gradlew
+// found in the project dir or its parents up to the repository root. The missing executable bit of the wrapper script
+// is fixed, and the wrapper jar is validated. If no wrapper exists and the fallback is enabled, gradle on $PATH is used.
+func (a AndroidBuild) gradleExecutable(ctx context.Context, cfg Config) (string, error) {
+	var gradlewPath string
+	if cfg.GradlewPath != "" {
+		absPath, err := filepath.Abs(cfg.GradlewPath)
+		if err != nil {
+			return "", err
+		}
+		if exists, err := pathutil.IsPathExists(absPath); err != nil {
+			return "", err
+		} else if !exists {
+			return "", fmt.Errorf("gradlew not found at the configured gradlew_path: %s", cfg.GradlewPath)
+		}
+		gradlewPath = absPath
+	} else {
+		pth, err := gradlewrapper.Locate(cfg.ProjectLocation)
+		if errors.Is(err, gradlewrapper.ErrNotFound) {
+			return a.systemGradle(cfg)
+		} else if err != nil {
+			return "", fmt.Errorf("failed to find gradlew: %s", err)
+		}
+		gradlewPath = pth
+	}
+
+	absProjectLocation, err := filepath.Abs(cfg.ProjectLocation)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Dir(gradlewPath) != absProjectLocation {
+		a.logger.Printf("Using Gradle wrapper: %s", gradlewPath)
+	}
+
+	if fixed, err := gradlewrapper.EnsureExecutable(gradlewPath); err != nil {
+		return "", fmt.Errorf("failed to make gradlew executable: %s", err)
+	} else if fixed {
+		a.logger.Warnf("%s was not executable, added the executable permission. Consider committing it with: git update-index --chmod=+x gradlew", gradlewPath)
+	}
+
+	if err := a.validateGradleWrapper(ctx, cfg, gradlewPath); err != nil {
+		return "", err
+	}
+
+	return gradlewPath, nil
+}
+
+func (a AndroidBuild) systemGradle(cfg Config) (string, error) {
+	if !cfg.SystemGradleFallback {
+		return "", fmt.Errorf("gradlew not found in %s or its parent directories, set the gradlew_path input or enable the system Gradle fallback", cfg.ProjectLocation)
+	}
+
+	gradlePath, err := exec.LookPath("gradle")
+	if err != nil {
+		return "", fmt.Errorf("gradlew not found in %s or its parent directories and gradle is not available on $PATH", cfg.ProjectLocation)
+	}
+	a.logger.Warnf("gradlew not found in %s or its parent directories, falling back to system Gradle: %s", cfg.ProjectLocation, gradlePath)
+
+	return gradlePath, nil
+}
+
+// validateGradleWrapper makes sure the wrapper jar executed by gradlew is an official Gradle release
+// and not something committed by a (forked) PR contributor. Depending on the policy a mismatch fails the step
+// or only prints a warning.
+func (a AndroidBuild) validateGradleWrapper(ctx context.Context, cfg Config, gradlewPath string) error {
+	if cfg.WrapperValidation == policyOff {
+		return nil
+	}
+
+	jarPath := gradlewrapper.JarPath(gradlewPath)
+	if exists, err := pathutil.IsPathExists(jarPath); err != nil {
+		return err
+	} else if !exists {
+		a.logger.Warnf("Gradle wrapper jar not found at %s, skipping wrapper validation", jarPath)
+		return nil
+	}
+
+	// The jar usually belongs to the Gradle version of the wrapper properties, its checksum is looked up first,
+	// the checksums of every released version are only fetched if that does not match.
+	var fetchers []gradlewrapper.ChecksumFetcher
+	if !cfg.GradleVersion.IsZero() && a.fetchWrapperChecksums != nil {
+		fetchers = append(fetchers, gradlewrapper.VersionChecksumFetcher(cfg.GradleVersion))
+	}
+	fetchers = append(fetchers, a.fetchWrapperChecksums)
+
+	checksum, err := gradlewrapper.Validate(ctx, jarPath, cfg.WrapperChecksums, fetchers...)
+	if err == nil {
+		a.logger.Donef("Gradle wrapper jar validated (sha256: %s)", checksum)
+		return nil
+	}
+
+	if cfg.WrapperValidation == policyFail {
+		return fmt.Errorf("Gradle wrapper validation failed: %s", err)
+	}
+	a.logger.Warnf("Gradle wrapper validation failed: %s", err)
+
+	return nil
+}
+
+// buildGradleCommand constructs the gradle invocation, transparently wrapping
+// it in `bitrise-build-cache react-native run --` when the Bitrise Build Cache
+// CLI is installed and React Native build cache is active on this machine.
+// When no wrap is needed (CLI absent, probe failed, or RN cache not activated),
+// the original `gradlew ...` command is returned unchanged.
+func (a AndroidBuild) buildGradleCommand(ctx context.Context, gradlewPath string, cmdArgs []string, cmdOpts *command.Opts) command.Command {
+	det := a.detect(ctx, a.logger)
+	if !det.ReactNativeEnabled {
+		a.logger.Debugf("Bitrise Build Cache: no RN wrap (cli=%v enabled=%v)", det.CLIPath != "", det.ReactNativeEnabled)
+
+		return a.cmdFactory.Create(gradlewPath, cmdArgs, cmdOpts)
+	}
+
+	a.logger.Infof("Bitrise Build Cache: React Native cache active — wrapping gradle with %s", det.CLIPath)
+
+	wrapped := append([]string{"react-native", "run", "--", gradlewPath}, cmdArgs...)
+
+	return a.cmdFactory.Create(det.CLIPath, wrapped, cmdOpts)
+}
+
+// collectDaemonLogs zips the Gradle daemon logs and JVM crash reports written since the build started
+// into the deploy dir and returns the path of the zip. An empty path is returned if no logs were found.
+// Failing to collect the logs never fails the step, the original build error is more important.
+func (a AndroidBuild) collectDaemonLogs(cfg Config, started time.Time) string {
+	gradleUserHome, err := daemonlogs.GradleUserHome()
+	if err != nil {
+		a.logger.Warnf("Failed to determine Gradle user home: %s", err)
+		return ""
+	}
+
+	absProjectLocation, err := filepath.Abs(cfg.ProjectLocation)
+	if err != nil {
+		a.logger.Warnf("Failed to determine project location: %s", err)
+		return ""
+	}
+
+	logs, err := daemonlogs.Find(gradleUserHome, absProjectLocation, started)
+	if err != nil {
+		a.logger.Warnf("Failed to find Gradle daemon logs: %s", err)
+		return ""
+	}
+	if len(logs) == 0 {
+		return ""
+	}
+
+	zipPath := filepath.Join(cfg.DeployDir, daemonLogsZipName)
+	if err := daemonlogs.Zip(logs, zipPath); err != nil {
+		a.logger.Warnf("Failed to zip Gradle daemon logs: %s", err)
+		return ""
+	}
+
+	a.logger.Println()
+	a.logger.Infof("Collected Gradle daemon logs:")
+	a.logger.Printf(strings.Join(logs, "\n"))
+
+	return zipPath
+}
+
+func (a AndroidBuild) printAppSearchInfo(appArtifacts []gradle.Artifact, appPathPatterns []string) {
+	var artPaths []string
+	for _, a := range appArtifacts {
+		artPaths = append(artPaths, a.Name)
 	}
 
 	a.logger.Donef("Used patterns for generated artifact search:")
@@ -365,45 +1289,1161 @@ func (a AndroidBuild) printAppSearchInfo(appArtifacts []gradle.Artifact, appPath
 	a.logger.Println()
 }
 
-func (a AndroidBuild) exportArtifacts(artifacts []gradle.Artifact, deployDir string) ([]string, error) {
-	var paths []string
+// printSizeReports prints the size breakdown of the artifacts and returns the size reports by artifact path.
+func (a AndroidBuild) printSizeReports(artifacts []gradle.Artifact) map[string]sizereport.Report {
+	reports := map[string]sizereport.Report{}
 	for _, artifact := range artifacts {
-		exists, err := pathutil.IsPathExists(filepath.Join(deployDir, artifact.Name))
+		report, err := sizereport.Analyze(artifact.Path)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check path, error: %v", err)
+			a.logger.Warnf("Failed to analyze the size of %s: %s", artifact.Name, err)
+			continue
 		}
+		reports[artifact.Path] = report
 
-		artifactName := filepath.Base(artifact.Path)
+		a.logger.Donef("Size of %s:", artifact.Name)
+		for _, line := range sizeReportTable(report) {
+			a.logger.Printf(line)
+		}
+		a.logger.Println()
+	}
+	return reports
+}
 
-		if exists {
-			timestamp := time.Now().Format("20060102150405")
-			ext := filepath.Ext(artifact.Name)
-			name := strings.TrimSuffix(filepath.Base(artifact.Name), ext)
-			artifact.Name = fmt.Sprintf("%s-%s%s", name, timestamp, ext)
+// sizeReportTable returns the lines of the size report as a table.
+func sizeReportTable(report sizereport.Report) []string {
+	const row = "  %-16s %12s %14s"
+	lines := []string{fmt.Sprintf(row, "Category", "Compressed", "Uncompressed")}
+	for _, category := range report.Categories {
+		lines = append(lines, fmt.Sprintf(row, category.Name, formatBytes(category.Compressed), formatBytes(category.Uncompressed)))
+	}
+	lines = append(lines, fmt.Sprintf(row, "Total", formatBytes(report.Total.Compressed), formatBytes(report.Total.Uncompressed)))
+	lines = append(lines, fmt.Sprintf("  File size: %s", formatBytes(report.FileSize)))
+	return lines
+}
+
+// exportSizeReports writes the size report JSON of each exported artifact next to it,
+// and returns the reasons the artifacts exceed the size budget.
+// The reports of the built artifacts are reused for their exported copies (sourcePaths maps an exported path
+// to the built artifact path), the artifacts modified after the build, for example by signing, are analyzed again.
+func (a AndroidBuild) exportSizeReports(artifactPaths []string, sourcePaths map[string]string, reports map[string]sizereport.Report, budget *SizeBudget) ([]string, error) {
+	var lastReportPath string
+	var violations []string
+	for _, pth := range artifactPaths {
+		report, ok := reports[sourcePaths[pth]]
+		if ok {
+			report.Artifact = filepath.Base(pth)
+		} else {
+			var err error
+			report, err = sizereport.Analyze(pth)
+			if err != nil {
+				a.logger.Warnf("Failed to analyze the size of %s: %s", filepath.Base(pth), err)
+				continue
+			}
 		}
 
-		a.logger.Printf("  Export [ %s => $BITRISE_DEPLOY_DIR/%s ]", artifactName, artifact.Name)
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode size report: %s", err)
+		}
+		reportPath := strings.TrimSuffix(pth, filepath.Ext(pth)) + "-size-report.json"
+		if err := os.WriteFile(reportPath, content, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write size report: %s", err)
+		}
+		lastReportPath = reportPath
+
+		if budget != nil {
+			if budget.Baseline != nil {
+				a.logger.Println()
+				a.logger.Infof("Size changes of %s compared to %s:", report.Artifact, budget.BaselineName)
+				for _, line := range sizeDeltaTable(sizereport.Compare(*budget.Baseline, report)) {
+					a.logger.Printf(line)
+				}
+			}
+			violations = append(violations, sizeBudgetViolations(report, *budget)...)
+		}
+	}
 
-		if err := artifact.Export(deployDir); err != nil {
-			a.logger.Warnf("failed to export artifact (%s), error: %v", artifact.Path, err)
+	if lastReportPath != "" {
+		if err := tools.ExportEnvironmentWithEnvman(sizeReportEnvKey, lastReportPath); err != nil {
+			return nil, fmt.Errorf("failed to export environment variable: %s", sizeReportEnvKey)
+		}
+		a.logger.Printf("  Env    [ $%s = $BITRISE_DEPLOY_DIR/%s ]", sizeReportEnvKey, filepath.Base(lastReportPath))
+	}
+
+	return violations, nil
+}
+
+// sizeDeltaTable returns the lines of the compressed size changes as a table.
+func sizeDeltaTable(deltas []sizereport.Delta) []string {
+	const row = "  %-16s %12s %12s %12s"
+	lines := []string{fmt.Sprintf(row, "Category", "Baseline", "Current", "Change")}
+	for _, delta := range deltas {
+		change := formatBytes(delta.Change())
+		if delta.Change() > 0 {
+			change = "+" + change
+		}
+		lines = append(lines, fmt.Sprintf(row, delta.Name, formatBytes(delta.Baseline), formatBytes(delta.Current), change))
+	}
+	return lines
+}
+
+// sizeBudgetViolations returns the reasons the artifact exceeds the size budget.
+func sizeBudgetViolations(report sizereport.Report, budget SizeBudget) []string {
+	var violations []string
+	if budget.MaxArtifactSize > 0 && report.FileSize > budget.MaxArtifactSize {
+		violations = append(violations, fmt.Sprintf("%s is %s, larger than the max_artifact_size of %s", report.Artifact, formatBytes(report.FileSize), formatBytes(budget.MaxArtifactSize)))
+	}
+
+	if budget.Baseline == nil {
+		return violations
+	}
+	for _, delta := range sizereport.Compare(*budget.Baseline, report) {
+		maxIncrease, inputName := budget.MaxIncrease, "max_size_increase"
+		if categoryMaxIncrease, ok := budget.CategoryMaxIncreases[delta.Name]; ok {
+			maxIncrease, inputName = categoryMaxIncrease, "category_size_increases"
+		}
+		if maxIncrease >= 0 && delta.Change() > maxIncrease {
+			violations = append(violations, fmt.Sprintf("%s of %s grew by %s, more than the %s of %s", delta.Name, report.Artifact, formatBytes(delta.Change()), inputName, formatBytes(maxIncrease)))
+		}
+	}
+	return violations
+}
+
+// readManifests returns the manifest of each artifact by artifact path. The artifacts whose manifest failed to be read
+// are left out with a warning.
+func (a AndroidBuild) readManifests(artifactPaths []string) map[string]manifest.Manifest {
+	manifests := map[string]manifest.Manifest{}
+	for _, pth := range artifactPaths {
+		m, err := manifest.Read(pth)
+		if err != nil {
+			a.logger.Warnf("Failed to read the manifest of %s: %s", filepath.Base(pth), err)
+			continue
+		}
+		manifests[pth] = m
+	}
+	return manifests
+}
+
+// exportManifestDiffs writes the manifest changes of each exported artifact compared to the baseline next to it,
+// as markdown and JSON, and returns the new dangerous permissions.
+func (a AndroidBuild) exportManifestDiffs(artifactPaths []string, manifests map[string]manifest.Manifest, baseline ManifestBaseline) ([]string, error) {
+	a.logger.Println()
+	a.logger.Infof("Manifest changes compared to %s:", baseline.Name)
+
+	var lastDiffPath string
+	var newDangerousPermissions []string
+	for _, pth := range artifactPaths {
+		name := filepath.Base(pth)
+		current, ok := manifests[pth]
+		if !ok {
 			continue
 		}
 
-		paths = append(paths, filepath.Join(deployDir, artifact.Name))
+		diff := manifest.Compare(baseline.Manifest, current)
+		markdown := diff.Markdown(baseline.Name, name)
+		for _, line := range strings.Split(strings.TrimSpace(markdown), "\n") {
+			a.logger.Printf("  %s", line)
+		}
+		a.logger.Println()
+
+		for _, permission := range diff.NewDangerousPermissions() {
+			if baseline.NewDangerousPermissions != policyFail {
+				a.logger.Warnf("%s requests the new dangerous permission: %s", name, permission)
+			}
+			newDangerousPermissions = append(newDangerousPermissions, permission)
+		}
+
+		content, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode manifest diff: %s", err)
+		}
+		base := strings.TrimSuffix(pth, filepath.Ext(pth)) + "-manifest-diff"
+		if err := os.WriteFile(base+".json", content, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write manifest diff: %s", err)
+		}
+		if err := os.WriteFile(base+".md", []byte(markdown), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write manifest diff: %s", err)
+		}
+		lastDiffPath = base + ".md"
+	}
+
+	if lastDiffPath != "" {
+		if err := tools.ExportEnvironmentWithEnvman(manifestDiffEnvKey, lastDiffPath); err != nil {
+			return nil, fmt.Errorf("failed to export environment variable: %s", manifestDiffEnvKey)
+		}
+		a.logger.Printf("  Env    [ $%s = $BITRISE_DEPLOY_DIR/%s ]", manifestDiffEnvKey, filepath.Base(lastDiffPath))
 	}
-	return paths, nil
+
+	return newDangerousPermissions, nil
 }
 
-// parseVariants returns the list of variants from the raw step input string.
-// The variants are primarily split by linebreaks, but the step used to split by the "\n" substring in the past,
-// so we also handle that for backwards compatibility.
-func parseVariants(input string) []string {
-	var variants []string
+// exportManifestAudits writes the manifest security audit of each exported artifact next to it as a SARIF log,
+// and returns the findings at or above the fail level.
+func (a AndroidBuild) exportManifestAudits(artifactPaths []string, manifests map[string]manifest.Manifest, variants []string, failLevel string) ([]string, error) {
+	a.logger.Println()
+	a.logger.Infof("Manifest security audit:")
+
+	var rules []sarif.Rule
+	for _, rule := range manifest.Rules {
+		rules = append(rules, sarif.Rule{
+			ID:                   rule.ID,
+			ShortDescription:     sarif.Message{Text: rule.Description},
+			DefaultConfiguration: sarif.DefaultConfiguration{Level: rule.Severity},
+		})
+	}
 
-	for _, line := range strings.Split(input, "\n") {
-		variantsPerLine := strings.Split(line, `\n`)
-		variants = append(variants, variantsPerLine...)
+	var lastLogPath string
+	var failures []string
+	for _, pth := range artifactPaths {
+		name := filepath.Base(pth)
+		m, ok := manifests[pth]
+		if !ok {
+			continue
+		}
+
+		findings := manifest.Audit(m, releaseArtifact(name, variants))
+		if len(findings) == 0 {
+			a.logger.Printf("  %s: no issues found", name)
+		}
+
+		var results []sarif.Result
+		for _, finding := range findings {
+			line := fmt.Sprintf("%s: [%s] %s: %s", name, finding.Rule.Severity, finding.Rule.ID, finding.Message)
+			if finding.Rule.Severity == manifest.SeverityNote {
+				a.logger.Printf("  %s", line)
+			} else {
+				a.logger.Warnf("  %s", line)
+			}
+			if manifest.SeverityAtLeast(finding.Rule.Severity, failLevel) {
+				failures = append(failures, line)
+			}
+
+			location := sarif.Location{PhysicalLocation: sarif.PhysicalLocation{ArtifactLocation: sarif.ArtifactLocation{URI: name}}}
+			if finding.Component != "" {
+				location.LogicalLocations = []sarif.LogicalLocation{{FullyQualifiedName: finding.Component}}
+			}
+			results = append(results, sarif.Result{
+				RuleID:    finding.Rule.ID,
+				Level:     finding.Rule.Severity,
+				Message:   sarif.Message{Text: finding.Message},
+				Locations: []sarif.Location{location},
+			})
+		}
+
+		logPath := strings.TrimSuffix(pth, filepath.Ext(pth)) + "-manifest-audit.sarif"
+		if err := sarif.New(sarif.Driver{Name: "android-manifest-audit", Rules: rules}, results).Write(logPath); err != nil {
+			return nil, fmt.Errorf("failed to write manifest audit: %s", err)
+		}
+		lastLogPath = logPath
 	}
 
-	return variants
+	if lastLogPath != "" {
+		if err := tools.ExportEnvironmentWithEnvman(manifestAuditEnvKey, lastLogPath); err != nil {
+			return nil, fmt.Errorf("failed to export environment variable: %s", manifestAuditEnvKey)
+		}
+		a.logger.Printf("  Env    [ $%s = $BITRISE_DEPLOY_DIR/%s ]", manifestAuditEnvKey, filepath.Base(lastLogPath))
+	}
+
+	return failures, nil
+}
+
+// inspectNativeLibraries returns the native libraries of each artifact by artifact path. The artifacts failed to be
+// inspected are left out with a warning.
+func (a AndroidBuild) inspectNativeLibraries(artifactPaths []string) map[string][]nativelib.Library {
+	librariesByArtifact := map[string][]nativelib.Library{}
+	for _, pth := range artifactPaths {
+		libraries, err := nativelib.Inspect(pth)
+		if err != nil {
+			a.logger.Warnf("Failed to inspect the native libraries of %s: %s", filepath.Base(pth), err)
+			continue
+		}
+		librariesByArtifact[pth] = libraries
+	}
+	return librariesByArtifact
+}
+
+// checkPageSizes reports the native libraries of the artifacts per ABI that do not support 16 KB memory pages,
+// and returns the problems.
+func (a AndroidBuild) checkPageSizes(artifactPaths []string, librariesByArtifact map[string][]nativelib.Library, appType, policy string) []string {
+	a.logger.Println()
+	a.logger.Infof("16 KB page size support:")
+
+	var problems []string
+	for _, pth := range artifactPaths {
+		name := filepath.Base(pth)
+		libraries, ok := librariesByArtifact[pth]
+		if !ok {
+			continue
+		}
+		if len(libraries) == 0 {
+			a.logger.Printf("  %s: no native libraries", name)
+			continue
+		}
+
+		a.logger.Printf("  %s:", name)
+		byABI := map[string][]nativelib.Library{}
+		var abis []string
+		for _, library := range libraries {
+			if _, ok := byABI[library.ABI]; !ok {
+				abis = append(abis, library.ABI)
+			}
+			byABI[library.ABI] = append(byABI[library.ABI], library)
+		}
+		sort.Strings(abis)
+
+		for _, abi := range abis {
+			abiLibraries := byABI[abi]
+			if !abiLibraries[0].Is64Bit() {
+				a.logger.Printf("    %s: 32-bit ABI, not checked", abi)
+				continue
+			}
+
+			abiProblems := nativelib.PageSizeProblems(abiLibraries, appType == apkAppType)
+			if len(abiProblems) == 0 {
+				a.logger.Printf("    %s: %d libraries, all support 16 KB page size", abi, len(abiLibraries))
+				continue
+			}
+
+			a.logger.Printf("    %s: %d libraries, problems:", abi, len(abiLibraries))
+			for _, problem := range abiProblems {
+				line := fmt.Sprintf("%s: %s: %s", name, problem.Library.Path, problem.Reason)
+				if policy == policyFail {
+					a.logger.Errorf("      %s", line)
+				} else {
+					a.logger.Warnf("      %s", line)
+				}
+				problems = append(problems, line)
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkNativeDebugInfo reports the size of the debug info and static symbol tables in the native libraries of the artifacts
+// per ABI and library, and warns if an artifact of a release variant ships unstripped libraries.
+func (a AndroidBuild) checkNativeDebugInfo(artifactPaths []string, librariesByArtifact map[string][]nativelib.Library, variants []string) {
+	a.logger.Println()
+	a.logger.Infof("Native library debug info:")
+
+	for _, pth := range artifactPaths {
+		name := filepath.Base(pth)
+		libraries, ok := librariesByArtifact[pth]
+		if !ok {
+			continue
+		}
+
+		var unstripped []nativelib.Library
+		var wasted uint64
+		for _, library := range libraries {
+			if library.Unstripped() {
+				unstripped = append(unstripped, library)
+				wasted += library.StrippableSize()
+			}
+		}
+		if len(unstripped) == 0 {
+			a.logger.Printf("  %s: no unstripped native libraries", name)
+			continue
+		}
+
+		a.logger.Printf("  %s: %d of %d native libraries are unstripped", name, len(unstripped), len(libraries))
+		for _, line := range debugInfoTable(unstripped) {
+			a.logger.Printf("%s", line)
+		}
+		if releaseArtifact(name, variants) {
+			a.logger.Warnf("  %s is a release artifact shipping %s of native debug info, strip the libraries and upload the symbols to the crash reporter instead", name, formatBytes(int64(wasted)))
+		}
+	}
+}
+
+// debugInfoTable returns the debug info and symbol table sizes of the libraries as a table, grouped by ABI.
+func debugInfoTable(libraries []nativelib.Library) []string {
+	const row = "    %-40s %12s %12s %12s"
+
+	byABI := map[string][]nativelib.Library{}
+	var abis []string
+	for _, library := range libraries {
+		if _, ok := byABI[library.ABI]; !ok {
+			abis = append(abis, library.ABI)
+		}
+		byABI[library.ABI] = append(byABI[library.ABI], library)
+	}
+	sort.Strings(abis)
+
+	lines := []string{fmt.Sprintf(row, "ABI / library", "Debug info", "Symbols", "Size")}
+	for _, abi := range abis {
+		var total nativelib.Library
+		var libraryLines []string
+		for _, library := range byABI[abi] {
+			total.DebugInfoSize += library.DebugInfoSize
+			total.SymbolTableSize += library.SymbolTableSize
+			total.Size += library.Size
+			libraryLines = append(libraryLines, fmt.Sprintf(row, "  "+path.Base(library.Path), formatBytes(int64(library.DebugInfoSize)),
+				formatBytes(int64(library.SymbolTableSize)), formatBytes(int64(library.Size))))
+		}
+		lines = append(lines, fmt.Sprintf(row, abi, formatBytes(int64(total.DebugInfoSize)), formatBytes(int64(total.SymbolTableSize)), formatBytes(int64(total.Size))))
+		lines = append(lines, libraryLines...)
+	}
+	return lines
+}
+
+// exportArtifacts copies the artifacts to the deploy dir and returns the exported paths, and the built artifact path
+// of each exported path.
+func (a AndroidBuild) exportArtifacts(artifacts []gradle.Artifact, deployDir string) ([]string, map[string]string, error) {
+	var paths []string
+	sourcePaths := map[string]string{}
+	for _, artifact := range artifacts {
+		exists, err := pathutil.IsPathExists(filepath.Join(deployDir, artifact.Name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check path, error: %v", err)
+		}
+
+		artifactName := filepath.Base(artifact.Path)
+
+		if exists {
+			timestamp := time.Now().Format("20060102150405")
+			ext := filepath.Ext(artifact.Name)
+			name := strings.TrimSuffix(filepath.Base(artifact.Name), ext)
+			artifact.Name = fmt.Sprintf("%s-%s%s", name, timestamp, ext)
+		}
+
+		a.logger.Printf("  Export [ %s => $BITRISE_DEPLOY_DIR/%s ]", artifactName, artifact.Name)
+
+		if err := artifact.Export(deployDir); err != nil {
+			a.logger.Warnf("failed to export artifact (%s), error: %v", artifact.Path, err)
+			continue
+		}
+
+		pth := filepath.Join(deployDir, artifact.Name)
+		paths = append(paths, pth)
+		sourcePaths[pth] = artifact.Path
+	}
+	return paths, sourcePaths, nil
+}
+
+// parseSecretEnvs returns the environment variable names of the secret_envs input,
+// separated by linebreaks or the | character.
+func parseSecretEnvs(input string) []string {
+	var keys []string
+	for _, key := range strings.FieldsFunc(input, func(r rune) bool { return r == '\n' || r == '|' }) {
+		if key = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(key), "$")); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// parseVariants returns the list of variants from the raw step input string, empty if no variant is selected.
+// The variants are primarily split by linebreaks, but the step used to split by the "\n" substring in the past,
+// so we also handle that for backwards compatibility.
+func parseVariants(input string) []string {
+	var variants []string
+
+	for _, line := range strings.Split(input, "\n") {
+		for _, variant := range strings.Split(line, `\n`) {
+			if strings.TrimSpace(variant) != "" {
+				variants = append(variants, variant)
+			}
+		}
+	}
+
+	return variants
+}
+
+// signArtifacts signs the unsigned APKs with the keystore, and returns the artifact paths with the signed APKs.
+func (a AndroidBuild) signArtifacts(paths []string, appType string, signing SigningConfig) ([]string, error) {
+	a.logger.Println()
+	a.logger.Infof("Sign APKs:")
+
+	if appType != apkAppType {
+		a.logger.Warnf("Post-build signing supports APKs only, the %s artifacts are not signed", appType)
+		return paths, nil
+	}
+
+	key, err := loadSigningKey(signing)
+	if err != nil {
+		return nil, err
+	}
+	signer := apksig.Signer{PrivateKey: key.PrivateKey, Certificates: key.Certificates}
+
+	var signedPaths []string
+	for _, pth := range paths {
+		if report, err := apksig.Verify(pth); err == nil && report.Signed() {
+			a.logger.Printf("  %s is already signed", filepath.Base(pth))
+			signedPaths = append(signedPaths, pth)
+			continue
+		}
+
+		signedPath := signedAPKPath(pth)
+		if err := apksig.Sign(pth, signedPath, signer); err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %s", filepath.Base(pth), err)
+		}
+		a.logger.Printf("  Sign   [ %s => $BITRISE_DEPLOY_DIR/%s ]", filepath.Base(pth), filepath.Base(signedPath))
+		signedPaths = append(signedPaths, signedPath)
+	}
+
+	return signedPaths, nil
+}
+
+// loadSigningKey opens the keystore and decrypts the signing key.
+func loadSigningKey(signing SigningConfig) (keystore.Key, error) {
+	store, err := keystore.Open(signing.KeystorePath, signing.KeystorePassword)
+	if err != nil {
+		return keystore.Key{}, fmt.Errorf("failed to open keystore: %s", err)
+	}
+
+	key, err := store.Key(signing.KeyAlias, signing.KeyPassword)
+	if err != nil {
+		return keystore.Key{}, fmt.Errorf("failed to read signing key: %s", err)
+	}
+
+	return key, nil
+}
+
+// signedAPKPath returns the path of the signed APK next to the unsigned one, for example
+// app-release-unsigned.apk is signed to app-release-signed.apk.
+func signedAPKPath(pth string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(pth, filepath.Ext(pth)), "-unsigned")
+	return base + "-signed.apk"
+}
+
+// checkSigningKeystore opens the keystore and decrypts the signing key before the build, so that an incorrect
+// password or alias fails the Step early, and checks the validity period of the signing certificate.
+func (a AndroidBuild) checkSigningKeystore(signing SigningConfig, expiryWarningDays int, now time.Time) error {
+	store, err := keystore.Open(signing.KeystorePath, signing.KeystorePassword)
+	if errors.Is(err, keystore.ErrInvalidPassword) {
+		return fmt.Errorf("keystore_password is incorrect for the keystore: %s", signing.KeystorePath)
+	} else if err != nil {
+		return fmt.Errorf("failed to open keystore (%s): %s", signing.KeystorePath, err)
+	}
+
+	key, err := store.Key(signing.KeyAlias, signing.KeyPassword)
+	if errors.Is(err, keystore.ErrAliasNotFound) {
+		return fmt.Errorf("key_alias (%s) not found in the keystore, available aliases: %s", signing.KeyAlias, strings.Join(store.Aliases(), ", "))
+	} else if errors.Is(err, keystore.ErrInvalidKeyPassword) {
+		return fmt.Errorf("key_password is incorrect for the key: %s", signing.KeyAlias)
+	} else if err != nil {
+		return fmt.Errorf("failed to read signing key (%s): %s", signing.KeyAlias, err)
+	}
+
+	certificate := key.Certificates[0]
+	warning, err := checkCertificateValidity(certificate, now, expiryWarningDays)
+	if err != nil {
+		return fmt.Errorf("signing certificate of the key %s %s", signing.KeyAlias, err)
+	}
+	if warning != "" {
+		a.logger.Warnf("Signing certificate of the key %s %s", signing.KeyAlias, warning)
+		return nil
+	}
+
+	a.logger.Donef("Signing key %s found, its certificate is valid until %s", signing.KeyAlias, certificate.NotAfter.Format("2006-01-02"))
+
+	return nil
+}
+
+// checkCertificateValidity returns an error if the certificate is not valid at the given time,
+// and a warning if it expires within the given number of days.
+func checkCertificateValidity(certificate *x509.Certificate, now time.Time, expiryWarningDays int) (string, error) {
+	const dateLayout = "2006-01-02"
+
+	if now.Before(certificate.NotBefore) {
+		return "", fmt.Errorf("is not valid before %s", certificate.NotBefore.Format(dateLayout))
+	}
+	if now.After(certificate.NotAfter) {
+		return "", fmt.Errorf("expired on %s", certificate.NotAfter.Format(dateLayout))
+	}
+
+	if remaining := certificate.NotAfter.Sub(now); remaining < time.Duration(expiryWarningDays)*24*time.Hour {
+		return fmt.Sprintf("expires on %s (in %d days)", certificate.NotAfter.Format(dateLayout), int(remaining.Hours()/24)), nil
+	}
+
+	return "", nil
+}
+
+// postBuildSigning returns the signing configuration used in Export, nil if the APKs are not signed after the build.
+func postBuildSigning(cfg Config) *SigningConfig {
+	if !cfg.PostBuildSigning {
+		return nil
+	}
+	return cfg.Signing
+}
+
+// exportSignatures verifies the signatures of the exported APKs and exports whether they are signed,
+// the signature schemes and the signer certificate fingerprints, in the order of the exported APK list.
+func (a AndroidBuild) exportSignatures(apkPaths []string, variants []string) error {
+	a.logger.Println()
+	a.logger.Infof("APK signatures:")
+
+	var signed, schemes, certs []string
+	for _, pth := range apkPaths {
+		name := filepath.Base(pth)
+		report, err := apksig.Verify(pth)
+		if err != nil {
+			a.logger.Warnf("  %s: %s", name, err)
+			report = apksig.Report{}
+		} else if report.Signed() {
+			a.logger.Printf("  %s: signed (%s), certificate SHA-256: %s", name, report.SchemeNames(), strings.Join(report.CertSHA256, ", "))
+		} else {
+			a.logger.Printf("  %s: unsigned", name)
+		}
+
+		if !report.Signed() && releaseArtifact(name, variants) {
+			a.logger.Warnf("  %s is a release artifact, but it is not signed", name)
+		}
+
+		signed = append(signed, strconv.FormatBool(report.Signed()))
+		schemes = append(schemes, report.SchemeNames())
+		certs = append(certs, strings.Join(report.CertSHA256, ","))
+	}
+
+	for _, output := range []struct {
+		key    string
+		values []string
+	}{
+		{apkSignedListEnvKey, signed},
+		{apkSigningSchemeListEnvKey, schemes},
+		{apkCertSHA256ListEnvKey, certs},
+	} {
+		value := strings.Join(output.values, "|")
+		if err := tools.ExportEnvironmentWithEnvman(output.key, value); err != nil {
+			return fmt.Errorf("failed to export environment variable: %s", output.key)
+		}
+		a.logger.Printf("  Env    [ $%s = %s ]", output.key, value)
+	}
+
+	return nil
+}
+
+// exportAlignments verifies the alignment of the exported APKs, like zipalign -c -p 4, and exports whether they are aligned,
+// in the order of the exported APK list. Misaligned APKs are rejected at install or upload time.
+func (a AndroidBuild) exportAlignments(apkPaths []string) error {
+	a.logger.Println()
+	a.logger.Infof("APK alignment:")
+
+	var aligned []string
+	for _, pth := range apkPaths {
+		name := filepath.Base(pth)
+		misalignments, err := zipalign.Verify(pth)
+		if err != nil {
+			a.logger.Warnf("  %s: %s", name, err)
+			aligned = append(aligned, strconv.FormatBool(false))
+			continue
+		}
+
+		if len(misalignments) == 0 {
+			a.logger.Printf("  %s: aligned", name)
+		} else {
+			a.logger.Warnf("  %s: %d misaligned uncompressed entries:", name, len(misalignments))
+			for _, misalignment := range misalignments {
+				a.logger.Warnf("    %s", misalignment)
+			}
+		}
+		aligned = append(aligned, strconv.FormatBool(len(misalignments) == 0))
+	}
+
+	value := strings.Join(aligned, "|")
+	if err := tools.ExportEnvironmentWithEnvman(apkZipalignedListEnvKey, value); err != nil {
+		return fmt.Errorf("failed to export environment variable: %s", apkZipalignedListEnvKey)
+	}
+	a.logger.Printf("  Env    [ $%s = %s ]", apkZipalignedListEnvKey, value)
+
+	return nil
+}
+
+// checkDexFiles reports the method, field and class counts of the DEX files of the exported APKs, and warns if
+// the minification of the DEX files does not match the mapping files: a variant with a mapping file is expected
+// to be minified by R8, and a minified variant is expected to produce a mapping file.
+func (a AndroidBuild) checkDexFiles(apkPaths []string, mappingFiles []gradle.Artifact) {
+	a.logger.Println()
+	a.logger.Infof("DEX files:")
+
+	for _, pth := range apkPaths {
+		name := filepath.Base(pth)
+		files, err := dex.Inspect(pth)
+		if err != nil {
+			a.logger.Warnf("  %s: failed to inspect DEX files: %s", name, err)
+			continue
+		}
+		if len(files) == 0 {
+			a.logger.Printf("  %s: no DEX files", name)
+			continue
+		}
+
+		a.logger.Printf("  %s:", name)
+		for _, line := range dexTable(files) {
+			a.logger.Printf("%s", line)
+		}
+
+		ratio := int(dex.ShortNameRatio(files)*100 + 0.5)
+		minified := dex.Minified(files)
+		if minified {
+			a.logger.Printf("    Minified: yes (%d%% short class names)", ratio)
+		} else {
+			a.logger.Printf("    Minified: no (%d%% short class names)", ratio)
+		}
+
+		variant, hasMapping := mappingVariant(name, mappingFiles)
+		if hasMapping && !minified {
+			a.logger.Warnf("  The %s variant produced a mapping file, but %s does not look minified, check the R8 configuration", variant, name)
+		} else if !hasMapping && minified {
+			a.logger.Warnf("  %s looks minified, but no mapping file was found for it, crashes can not be deobfuscated", name)
+		}
+	}
+}
+
+// dexTable returns the method, field and class counts of the DEX files as a table.
+func dexTable(files []dex.File) []string {
+	const row = "    %-16s %10s %10s %10s"
+	lines := []string{fmt.Sprintf(row, "DEX file", "Methods", "Fields", "Classes")}
+	var methods, fields, classes int
+	for _, file := range files {
+		lines = append(lines, fmt.Sprintf(row, file.Name, strconv.Itoa(file.Methods), strconv.Itoa(file.Fields), strconv.Itoa(file.Classes)))
+		methods += file.Methods
+		fields += file.Fields
+		classes += file.Classes
+	}
+	if len(files) > 1 {
+		lines = append(lines, fmt.Sprintf(row, "Total", strconv.Itoa(methods), strconv.Itoa(fields), strconv.Itoa(classes)))
+	}
+	return lines
+}
+
+// mappingVariant returns the variant of the mapping file belonging to the artifact. The mapping files are stored
+// in a directory named after the variant (build/outputs/mapping/demoRelease/mapping.txt), the longest variant
+// matching the artifact name is selected (app-demo-release.apk belongs to demoRelease rather than release).
+func mappingVariant(artifactName string, mappingFiles []gradle.Artifact) (string, bool) {
+	name := strings.ToLower(artifactName)
+	var variant string
+	for _, mappingFile := range mappingFiles {
+		candidate := filepath.Base(filepath.Dir(mappingFile.Path))
+		if strings.Contains(name, kebabCase(candidate)) && len(candidate) > len(variant) {
+			variant = candidate
+		}
+	}
+
+	return variant, variant != ""
+}
+
+// releaseVariant returns the selected release variant the artifact was built for, based on the artifact name
+// (for example app-demo-release-unsigned.apk belongs to the demoRelease variant).
+func releaseVariant(artifactName string, variants []string) (string, bool) {
+	name := strings.ToLower(artifactName)
+	for _, variant := range variants {
+		if !strings.HasSuffix(strings.ToLower(variant), "release") {
+			continue
+		}
+		if strings.Contains(name, kebabCase(variant)) {
+			return variant, true
+		}
+	}
+
+	return "", false
+}
+
+// releaseArtifact tells whether the artifact belongs to a release variant. If no variant is selected, every variant is built
+// and the artifact name tells the build type.
+func releaseArtifact(artifactName string, variants []string) bool {
+	if len(variants) == 0 {
+		return strings.Contains(strings.ToLower(artifactName), "release")
+	}
+	_, ok := releaseVariant(artifactName, variants)
+	return ok
+}
+
+// kebabCase converts a camel case variant name to the form used in artifact names, for example demoRelease to demo-release.
+func kebabCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteRune('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// exportVersion exports the overridden version code and name of the app.
+func (a AndroidBuild) exportVersion(result Result) error {
+	if result.versionCode > 0 {
+		value := strconv.Itoa(result.versionCode)
+		if err := tools.ExportEnvironmentWithEnvman(versionCodeEnvKey, value); err != nil {
+			return fmt.Errorf("failed to export environment variable: %s", versionCodeEnvKey)
+		}
+		a.logger.Printf("  Env    [ $%s = %s ]", versionCodeEnvKey, value)
+	}
+
+	if result.versionName != "" {
+		if err := tools.ExportEnvironmentWithEnvman(versionNameEnvKey, result.versionName); err != nil {
+			return fmt.Errorf("failed to export environment variable: %s", versionNameEnvKey)
+		}
+		a.logger.Printf("  Env    [ $%s = %s ]", versionNameEnvKey, result.versionName)
+	}
+
+	return nil
+}
+
+// initScripts returns the Gradle init scripts applying the configuration which is not passed as Gradle arguments.
+func initScripts(cfg Config) []initscript.Script {
+	var scripts []initscript.Script
+	if cfg.VersionCode > 0 || cfg.VersionName != "" {
+		scripts = append(scripts, initscript.VersionOverride(cfg.VersionCode, cfg.VersionName))
+	}
+	if cfg.Signing != nil && !cfg.PostBuildSigning {
+		scripts = append(scripts, initscript.Signing(cfg.Variants))
+	}
+
+	return scripts
+}
+
+// parseVersionCode returns the version code of the version_code input increased by the version_code_offset input.
+// Zero means the version code is not overridden.
+func parseVersionCode(versionCode, offset string) (int, error) {
+	if versionCode == "" {
+		if offset != "" {
+			return 0, fmt.Errorf("version_code_offset requires version_code to be set")
+		}
+		return 0, nil
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(versionCode))
+	if err != nil || code < 0 {
+		return 0, fmt.Errorf("version_code should be a non-negative number, got: %s", versionCode)
+	}
+
+	if offset != "" {
+		offsetValue, err := strconv.Atoi(strings.TrimSpace(offset))
+		if err != nil {
+			return 0, fmt.Errorf("version_code_offset should be a number, got: %s", offset)
+		}
+		code += offsetValue
+	}
+
+	if code < 1 || code > maxVersionCode {
+		return 0, fmt.Errorf("version code should be between 1 and %d, got: %d", maxVersionCode, code)
+	}
+
+	return code, nil
+}
+
+// parseSigningConfig returns the signing configuration of the keystore inputs, nil if no keystore is provided.
+func parseSigningConfig(input Input) (*SigningConfig, error) {
+	if input.KeystorePath == "" {
+		if input.KeyAlias != "" || input.KeystorePassword != "" || input.KeyPassword != "" {
+			return nil, fmt.Errorf("keystore_path is required when signing inputs are provided")
+		}
+		return nil, nil
+	}
+
+	keystorePath, err := filepath.Abs(input.KeystorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand keystore path: %s", err)
+	}
+	if info, err := os.Stat(keystorePath); err != nil || info.IsDir() {
+		return nil, fmt.Errorf("keystore_path should be a local keystore file, got: %s", input.KeystorePath)
+	}
+
+	if input.KeystorePassword == "" {
+		return nil, fmt.Errorf("keystore_password is required when keystore_path is provided")
+	}
+	if input.KeyAlias == "" {
+		return nil, fmt.Errorf("key_alias is required when keystore_path is provided")
+	}
+
+	// The key password usually matches the keystore password (PKCS#12 keystores do not support different ones).
+	keyPassword := string(input.KeyPassword)
+	if keyPassword == "" {
+		keyPassword = string(input.KeystorePassword)
+	}
+
+	return &SigningConfig{
+		KeystorePath:     keystorePath,
+		KeystorePassword: string(input.KeystorePassword),
+		KeyAlias:         input.KeyAlias,
+		KeyPassword:      keyPassword,
+	}, nil
+}
+
+// parseBundletoolConfig returns nil if no bundletool jar is provided.
+// The generated APKs are signed with the step's keystore when one is given, otherwise with the debug keystore.
+func parseBundletoolConfig(input Input, signing *SigningConfig) (*BundletoolConfig, error) {
+	if input.BundletoolPath == "" {
+		if input.BundletoolDeviceSpecPath != "" {
+			return nil, fmt.Errorf("bundletool_path is required when bundletool_device_spec_path is provided")
+		}
+		return nil, nil
+	}
+
+	jarPath, err := filepath.Abs(input.BundletoolPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand bundletool path: %s", err)
+	}
+	if info, err := os.Stat(jarPath); err != nil || info.IsDir() {
+		return nil, fmt.Errorf("bundletool_path should be a local bundletool jar file, got: %s", input.BundletoolPath)
+	}
+
+	var deviceSpecPath string
+	if input.BundletoolDeviceSpecPath != "" {
+		deviceSpecPath, err = filepath.Abs(input.BundletoolDeviceSpecPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand device spec path: %s", err)
+		}
+		if info, err := os.Stat(deviceSpecPath); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("bundletool_device_spec_path should be a local device spec JSON file, got: %s", input.BundletoolDeviceSpecPath)
+		}
+	}
+
+	if !input.BundletoolUniversalAPK && deviceSpecPath == "" {
+		return nil, fmt.Errorf("bundletool_path is provided, but neither bundletool_universal_apk nor bundletool_device_spec_path is set")
+	}
+
+	return &BundletoolConfig{
+		JarPath:        jarPath,
+		UniversalAPK:   input.BundletoolUniversalAPK,
+		DeviceSpecPath: deviceSpecPath,
+		Signing:        signing,
+	}, nil
+}
+
+// exportBundletoolAPKs generates a universal APK and/or a device specific APK set of each AAB into the deploy dir.
+func (a AndroidBuild) exportBundletoolAPKs(aabPaths []string, cfg BundletoolConfig) error {
+	a.logger.Println()
+	a.logger.Infof("Generate APKs with bundletool:")
+
+	var signing *bundletool.Signing
+	if cfg.Signing != nil {
+		signing = &bundletool.Signing{
+			KeystorePath:     cfg.Signing.KeystorePath,
+			KeystorePassword: cfg.Signing.KeystorePassword,
+			KeyAlias:         cfg.Signing.KeyAlias,
+			KeyPassword:      cfg.Signing.KeyPassword,
+		}
+	} else {
+		debugSigning, err := bundletool.DebugSigning()
+		if err != nil {
+			return fmt.Errorf("failed to look up the debug keystore: %s", err)
+		}
+		if debugSigning == nil {
+			a.logger.Warnf("No keystore provided and no debug keystore found, the generated APKs will not be signed")
+		}
+		signing = debugSigning
+	}
+
+	tool := bundletool.New(cfg.JarPath, a.cmdFactory)
+
+	var universalAPKPaths, apksPaths []string
+	for _, aabPath := range aabPaths {
+		base := strings.TrimSuffix(aabPath, filepath.Ext(aabPath))
+
+		if cfg.UniversalAPK {
+			pth := base + "-universal.apk"
+			if err := tool.BuildUniversalAPK(aabPath, pth, signing); err != nil {
+				return fmt.Errorf("failed to generate universal APK from %s: %s", filepath.Base(aabPath), err)
+			}
+			a.logger.Printf("  Export [ %s => $BITRISE_DEPLOY_DIR/%s ]", filepath.Base(aabPath), filepath.Base(pth))
+			universalAPKPaths = append(universalAPKPaths, pth)
+		}
+
+		if cfg.DeviceSpecPath != "" {
+			pth := base + ".apks"
+			if err := tool.BuildAPKSet(aabPath, cfg.DeviceSpecPath, pth, signing); err != nil {
+				return fmt.Errorf("failed to generate APK set from %s: %s", filepath.Base(aabPath), err)
+			}
+			a.logger.Printf("  Export [ %s => $BITRISE_DEPLOY_DIR/%s ]", filepath.Base(aabPath), filepath.Base(pth))
+			apksPaths = append(apksPaths, pth)
+		}
+	}
+
+	for _, output := range []struct {
+		key, listKey string
+		paths        []string
+	}{
+		{universalAPKEnvKey, universalAPKListEnvKey, universalAPKPaths},
+		{apksEnvKey, apksListEnvKey, apksPaths},
+	} {
+		if len(output.paths) == 0 {
+			continue
+		}
+
+		last := output.paths[len(output.paths)-1]
+		if err := tools.ExportEnvironmentWithEnvman(output.key, last); err != nil {
+			return fmt.Errorf("failed to export environment variable: %s", output.key)
+		}
+		a.logger.Printf("  Env    [ $%s = $BITRISE_DEPLOY_DIR/%s ]", output.key, filepath.Base(last))
+
+		if err := tools.ExportEnvironmentWithEnvman(output.listKey, strings.Join(output.paths, "|")); err != nil {
+			return fmt.Errorf("failed to export environment variable: %s", output.listKey)
+		}
+		a.logger.Printf("  Env    [ $%s = %s ]", output.listKey, strings.Join(output.paths, "|"))
+	}
+
+	return nil
+}
+
+// exportDownloadSizes writes the estimated download size matrix of each AAB next to it.
+// A bundle that cannot be analyzed does not fail the step.
+func (a AndroidBuild) exportDownloadSizes(aabPaths []string) error {
+	a.logger.Println()
+	a.logger.Infof("Estimated download sizes:")
+
+	var lastReportPath string
+	for _, aabPath := range aabPaths {
+		name := filepath.Base(aabPath)
+		matrix, err := bundlesize.Estimate(aabPath)
+		if err != nil {
+			a.logger.Warnf("  %s: failed to estimate download size: %s", name, err)
+			continue
+		}
+
+		a.logger.Printf("  %s: %s - %s (%d device configurations)", name, formatBytes(matrix.Min), formatBytes(matrix.Max), len(matrix.Sizes))
+
+		content, err := json.MarshalIndent(matrix, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode download size matrix: %s", err)
+		}
+		reportPath := strings.TrimSuffix(aabPath, filepath.Ext(aabPath)) + "-download-size.json"
+		if err := os.WriteFile(reportPath, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write download size matrix: %s", err)
+		}
+		lastReportPath = reportPath
+	}
+
+	if lastReportPath == "" {
+		return nil
+	}
+	if err := tools.ExportEnvironmentWithEnvman(aabDownloadSizeEnvKey, lastReportPath); err != nil {
+		return fmt.Errorf("failed to export environment variable: %s", aabDownloadSizeEnvKey)
+	}
+	a.logger.Printf("  Env    [ $%s = $BITRISE_DEPLOY_DIR/%s ]", aabDownloadSizeEnvKey, filepath.Base(lastReportPath))
+
+	return nil
+}
+
+// formatBytes returns the size in a human readable form, with binary units.
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit && size > -unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	prefixes := "KMGT"
+	i := -1
+	for (value >= unit || value <= -unit) && i < len(prefixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, prefixes[i])
+}
+
+// parseSizeBudget returns nil if no size limit or baseline is provided.
+func parseSizeBudget(input Input) (*SizeBudget, error) {
+	if input.MaxArtifactSize == "" && input.SizeBaselinePath == "" {
+		if input.MaxSizeIncrease != "" || input.CategorySizeIncreases != "" {
+			return nil, fmt.Errorf("size_baseline_path is required when max_size_increase or category_size_increases is provided")
+		}
+		return nil, nil
+	}
+
+	budget := SizeBudget{MaxIncrease: -1}
+	if input.MaxArtifactSize != "" {
+		size, err := parseSize(input.MaxArtifactSize)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("max_artifact_size should be a positive size (for example 150MB), got: %s", input.MaxArtifactSize)
+		}
+		budget.MaxArtifactSize = size
+	}
+
+	if input.SizeBaselinePath != "" {
+		baseline, err := sizereport.Load(input.SizeBaselinePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the size baseline (%s): %s", input.SizeBaselinePath, err)
+		}
+		budget.Baseline = &baseline
+		budget.BaselineName = filepath.Base(input.SizeBaselinePath)
+	} else if input.MaxSizeIncrease != "" || input.CategorySizeIncreases != "" {
+		return nil, fmt.Errorf("size_baseline_path is required when max_size_increase or category_size_increases is provided")
+	}
+
+	if input.MaxSizeIncrease != "" {
+		size, err := parseSize(input.MaxSizeIncrease)
+		if err != nil {
+			return nil, fmt.Errorf("max_size_increase should be a size (for example 500KB), got: %s", input.MaxSizeIncrease)
+		}
+		budget.MaxIncrease = size
+	}
+
+	categoryMaxIncreases, err := parseCategorySizes(input.CategorySizeIncreases)
+	if err != nil {
+		return nil, fmt.Errorf("category_size_increases: %s", err)
+	}
+	budget.CategoryMaxIncreases = categoryMaxIncreases
+
+	return &budget, nil
+}
+
+// parseCategorySizes parses newline separated category=size pairs, for example dex=1MB.
+func parseCategorySizes(value string) (map[string]int64, error) {
+	var sizes map[string]int64
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		split := strings.SplitN(line, "=", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("should be a category=size pair (for example dex=1MB), got: %s", line)
+		}
+		category := strings.TrimSpace(split[0])
+		if !isSizeCategory(category) {
+			return nil, fmt.Errorf("unknown category: %s", category)
+		}
+		size, err := parseSize(split[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s should be a size (for example 500KB), got: %s", category, strings.TrimSpace(split[1]))
+		}
+
+		if sizes == nil {
+			sizes = map[string]int64{}
+		}
+		sizes[category] = size
+	}
+	return sizes, nil
+}
+
+func isSizeCategory(name string) bool {
+	switch name {
+	case sizereport.CategoryDex, sizereport.CategoryResourceTable, sizereport.CategoryResources,
+		sizereport.CategoryAssets, sizereport.CategoryOther, sizereport.TotalCategory:
+		return true
+	}
+	abi := strings.TrimPrefix(name, "lib/")
+	return abi != name && abi != "" && !strings.Contains(abi, "/")
+}
+
+var sizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([KMG]i?B|B)?$`)
+
+// parseSize parses a size in bytes, or with a decimal (KB, MB, GB) or binary (KiB, MiB, GiB) unit.
+func parseSize(value string) (int64, error) {
+	match := sizeRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	multipliers := map[string]float64{
+		"": 1, "B": 1,
+		"KB": 1e3, "MB": 1e6, "GB": 1e9,
+		"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30,
+	}
+	return int64(number * multipliers[match[2]]), nil
 }
@@ -2,7 +2,11 @@ package step
 
 import (
 	"context"
+	"crypto/x509"
+	"debug/elf"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -11,8 +15,20 @@ import (
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/env"
 	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/apksig"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/axml/axmltest"
 	"github.com/bitrise-steplib/bitrise-step-android-build/step/buildcache"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/dex"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradleargs"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/gradlewrapper"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/manifest"
 	"github.com/bitrise-steplib/bitrise-step-android-build/step/mocks"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/nativelib"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/nativelib/nativelibtest"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/sizereport"
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/kballard/go-shellquote"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -195,3 +211,576 @@ func Test_gradleTaskName(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseVariants(t *testing.T) {
+	assert.Empty(t, parseVariants(""))
+	assert.Equal(t, []string{"demoRelease", "release"}, parseVariants("demoRelease\nrelease\n"))
+	assert.Equal(t, []string{"demoRelease", "release"}, parseVariants(`demoRelease\nrelease`))
+	assert.Equal(t, []string{"release"}, parseVariants("release\n"))
+	assert.Empty(t, parseVariants("\n"))
+}
+
+func Test_gradleTasks(t *testing.T) {
+	tasks, err := gradleTasks(Config{AppType: "apk", Module: "app", Variants: parseVariants("")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{":app:assemble"}, tasks)
+
+	tasks, err = gradleTasks(Config{AppType: "aab", Variants: parseVariants("demoRelease\nrelease")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bundleDemoRelease", "bundleRelease"}, tasks)
+
+	// A trailing line break does not add the task of every variant.
+	tasks, err = gradleTasks(Config{AppType: "apk", Variants: parseVariants("release\n")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"assembleRelease"}, tasks)
+
+	tasks, err = gradleTasks(Config{AppType: "apk", Variants: parseVariants("\n")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"assemble"}, tasks)
+}
+
+func Test_initScripts_SigningAllReleaseBuildTypes(t *testing.T) {
+	cfg := Config{Variants: parseVariants(""), Signing: &SigningConfig{KeystorePath: "release.jks"}}
+
+	scripts := initScripts(cfg)
+	if len(scripts) != 1 {
+		t.Fatalf("expected the signing init script, got: %v", scripts)
+	}
+	// No selected variant signs every non-debuggable build type, including release.
+	assert.Contains(t, scripts[0].Content, "def signedVariants = []\n")
+	assert.Contains(t, scripts[0].Content, "signedVariants.isEmpty() ||")
+}
+
+func Test_gradleArguments(t *testing.T) {
+	step := createStep()
+	cfg := Config{
+		Arguments:          []string{"--console=rich", "--configuration-cache", "--stacktrace"},
+		BuildCache:         true,
+		ConfigurationCache: true,
+		MaxWorkers:         2,
+		GradleVersion:      gradlewrapper.MustParseVersion("6.1"),
+	}
+
+	args := step.gradleArguments(cfg)
+
+	assert.Equal(t, []string{"--console=rich", "--stacktrace", "--build-cache", "--max-workers=2"}, args)
+}
+
+func Test_gradleArguments_PlainConsole(t *testing.T) {
+	step := createStep()
+	version := gradlewrapper.MustParseVersion("8.5")
+
+	assert.Equal(t, []string{"--stacktrace", "--console=plain"}, step.gradleArguments(Config{Arguments: []string{"--stacktrace"}, GradleVersion: version}))
+	assert.Equal(t, []string{"--console", "verbose"}, step.gradleArguments(Config{Arguments: []string{"--console", "verbose"}, GradleVersion: version}))
+	assert.Equal(t, []string{"--stacktrace"}, step.gradleArguments(Config{Arguments: []string{"--stacktrace"}, GradleVersion: gradlewrapper.MustParseVersion("3.5")}))
+}
+
+func Test_redactor(t *testing.T) {
+	t.Setenv("KEYSTORE_PASSWORD", "env-secret")
+	step := createStep()
+	cfg := Config{
+		Arguments: []string{"-Pandroid.injected.signing.store.password=arg-secret", "-PversionName=1.0"},
+		GradleProperties: []gradleargs.Property{
+			{Key: "apiKey", Value: "property-secret", Secret: true},
+			{Key: "flavor", Value: "demo"},
+		},
+		SecretEnvs: parseSecretEnvs("$KEYSTORE_PASSWORD\nUNDEFINED_ENV"),
+	}
+
+	got := step.redactor(cfg).Redact(`gradlew "-Pandroid.injected.signing.store.password=arg-secret" "-PversionName=1.0" "-PapiKey=property-secret" "-Pflavor=demo" env-secret`)
+
+	assert.Equal(t, `gradlew "-Pandroid.injected.signing.store.password=[REDACTED]" "-PversionName=1.0" "-PapiKey=[REDACTED]" "-Pflavor=demo" [REDACTED]`, got)
+}
+
+func Test_redactedInput(t *testing.T) {
+	input := Input{GradleProperties: "secret:signingPassword=s3cr3t-value\nversionSuffix=beta"}
+	properties, err := gradleargs.ParseProperties(input.GradleProperties, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatalf("failed to parse properties: %s", err)
+	}
+
+	redacted := redactedInput(input, createStep().redactor(Config{GradleProperties: properties}))
+
+	assert.Equal(t, "secret:signingPassword=[REDACTED]\nversionSuffix=beta", redacted.GradleProperties)
+	assert.Equal(t, "secret:signingPassword=s3cr3t-value\nversionSuffix=beta", input.GradleProperties)
+}
+
+func Test_redactedInput_SensitiveArguments(t *testing.T) {
+	input := Input{Arguments: `--stacktrace -Pandroid.injected.signing.store.password="store pass" -PapiKey=abc123 -PversionName=1.0`}
+	args, err := shellquote.Split(input.Arguments)
+	if err != nil {
+		t.Fatalf("failed to split arguments: %s", err)
+	}
+
+	redacted := redactedInput(input, createStep().redactor(Config{Arguments: args}))
+
+	assert.Equal(t, `--stacktrace -Pandroid.injected.signing.store.password="[REDACTED]" -PapiKey=[REDACTED] -PversionName=1.0`, redacted.Arguments)
+}
+
+func Test_parseVersionCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		versionCode string
+		offset      string
+		want        int
+		wantErr     bool
+	}{
+		{name: "not overridden", want: 0},
+		{name: "build number", versionCode: "42", want: 42},
+		{name: "build number with offset", versionCode: "42", offset: "1000", want: 1042},
+		{name: "negative offset", versionCode: "1042", offset: "-1000", want: 42},
+		{name: "offset without version code", offset: "1000", wantErr: true},
+		{name: "invalid version code", versionCode: "1.0", wantErr: true},
+		{name: "invalid offset", versionCode: "42", offset: "x", wantErr: true},
+		{name: "zero version code", versionCode: "0", wantErr: true},
+		{name: "too large version code", versionCode: "2100000000", offset: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVersionCode(tt.versionCode, tt.offset)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseSigningConfig(t *testing.T) {
+	keystorePath := filepath.Join(t.TempDir(), "release.jks")
+	if err := os.WriteFile(keystorePath, []byte("keystore"), 0o600); err != nil {
+		t.Fatalf("failed to write keystore: %s", err)
+	}
+
+	signing, err := parseSigningConfig(Input{})
+	assert.NoError(t, err)
+	assert.Nil(t, signing)
+
+	signing, err = parseSigningConfig(Input{KeystorePath: keystorePath, KeystorePassword: "store-pass", KeyAlias: "upload"})
+	assert.NoError(t, err)
+	assert.Equal(t, &SigningConfig{
+		KeystorePath:     keystorePath,
+		KeystorePassword: "store-pass",
+		KeyAlias:         "upload",
+		KeyPassword:      "store-pass",
+	}, signing)
+
+	_, err = parseSigningConfig(Input{KeyAlias: "upload"})
+	assert.Error(t, err)
+
+	_, err = parseSigningConfig(Input{KeystorePath: keystorePath, KeyAlias: "upload"})
+	assert.Error(t, err)
+
+	_, err = parseSigningConfig(Input{KeystorePath: filepath.Join(t.TempDir(), "missing.jks"), KeystorePassword: "store-pass", KeyAlias: "upload"})
+	assert.Error(t, err)
+}
+
+func Test_parseBundletoolConfig(t *testing.T) {
+	dir := t.TempDir()
+	jarPath := filepath.Join(dir, "bundletool-all.jar")
+	specPath := filepath.Join(dir, "device-spec.json")
+	for _, pth := range []string{jarPath, specPath} {
+		if err := os.WriteFile(pth, []byte("content"), 0o600); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+	signing := &SigningConfig{KeystorePath: "/keystores/upload.jks", KeystorePassword: "store-pass", KeyAlias: "upload", KeyPassword: "store-pass"}
+
+	cfg, err := parseBundletoolConfig(Input{BundletoolUniversalAPK: true}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = parseBundletoolConfig(Input{BundletoolPath: jarPath, BundletoolUniversalAPK: true, BundletoolDeviceSpecPath: specPath}, signing)
+	assert.NoError(t, err)
+	assert.Equal(t, &BundletoolConfig{JarPath: jarPath, UniversalAPK: true, DeviceSpecPath: specPath, Signing: signing}, cfg)
+
+	_, err = parseBundletoolConfig(Input{BundletoolDeviceSpecPath: specPath}, nil)
+	assert.Error(t, err)
+
+	_, err = parseBundletoolConfig(Input{BundletoolPath: filepath.Join(dir, "missing.jar"), BundletoolUniversalAPK: true}, nil)
+	assert.Error(t, err)
+
+	_, err = parseBundletoolConfig(Input{BundletoolPath: jarPath, BundletoolDeviceSpecPath: filepath.Join(dir, "missing.json")}, nil)
+	assert.Error(t, err)
+
+	_, err = parseBundletoolConfig(Input{BundletoolPath: jarPath}, nil)
+	assert.Error(t, err)
+}
+
+func Test_formatBytes(t *testing.T) {
+	assert.Equal(t, "0 B", formatBytes(0))
+	assert.Equal(t, "1023 B", formatBytes(1023))
+	assert.Equal(t, "1.0 KiB", formatBytes(1024))
+	assert.Equal(t, "-1.5 MiB", formatBytes(-3*512*1024))
+	assert.Equal(t, "12.0 GiB", formatBytes(12*1024*1024*1024))
+}
+
+func Test_sizeReportTable(t *testing.T) {
+	report := sizereport.Report{
+		Artifact: "app-release.apk",
+		FileSize: 3 * 1024 * 1024,
+		Total:    sizereport.Size{Compressed: 3 * 1024 * 1024, Uncompressed: 5 * 1024 * 1024},
+		Categories: []sizereport.Category{
+			{Name: sizereport.CategoryDex, Size: sizereport.Size{Compressed: 2 * 1024 * 1024, Uncompressed: 4 * 1024 * 1024}},
+			{Name: "lib/arm64-v8a", Size: sizereport.Size{Compressed: 1024 * 1024, Uncompressed: 1024 * 1024}},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"  Category           Compressed   Uncompressed",
+		"  dex                   2.0 MiB        4.0 MiB",
+		"  lib/arm64-v8a         1.0 MiB        1.0 MiB",
+		"  Total                 3.0 MiB        5.0 MiB",
+		"  File size: 3.0 MiB",
+	}, sizeReportTable(report))
+}
+
+func Test_debugInfoTable(t *testing.T) {
+	libraries := []nativelib.Library{
+		{ABI: "x86_64", Path: "lib/x86_64/libapp.so", Size: 3 * 1024 * 1024, DebugInfoSize: 2 * 1024 * 1024, SymbolTableSize: 512},
+		{ABI: "arm64-v8a", Path: "lib/arm64-v8a/libapp.so", Size: 3 * 1024 * 1024, DebugInfoSize: 2 * 1024 * 1024, SymbolTableSize: 512},
+		{ABI: "arm64-v8a", Path: "lib/arm64-v8a/libcrypto.so", Size: 1024 * 1024, SymbolTableSize: 1536},
+	}
+
+	assert.Equal(t, []string{
+		"    ABI / library                              Debug info      Symbols         Size",
+		"    arm64-v8a                                     2.0 MiB      2.0 KiB      4.0 MiB",
+		"      libapp.so                                   2.0 MiB        512 B      3.0 MiB",
+		"      libcrypto.so                                    0 B      1.5 KiB      1.0 MiB",
+		"    x86_64                                        2.0 MiB        512 B      3.0 MiB",
+		"      libapp.so                                   2.0 MiB        512 B      3.0 MiB",
+	}, debugInfoTable(libraries))
+}
+
+// warningRecorder records the warnings logged by the step.
+type warningRecorder struct {
+	log.Logger
+	warnings []string
+}
+
+func (r *warningRecorder) Warnf(format string, v ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, v...))
+	r.Logger.Warnf(format, v...)
+}
+
+func Test_checkNativeDebugInfo_DefaultVariants(t *testing.T) {
+	apkPath := filepath.Join(t.TempDir(), "app-release.apk")
+	library := nativelibtest.Library([]uint64{16384}, []nativelibtest.Section{{Name: ".debug_info", Type: elf.SHT_PROGBITS, Size: 2048}})
+	ziptest.Write(t, apkPath, ziptest.Files(map[string]string{"lib/arm64-v8a/libapp.so": string(library)}))
+	recorder := &warningRecorder{Logger: log.NewLogger()}
+	step := createStep()
+	step.logger = recorder
+
+	step.checkNativeDebugInfo([]string{apkPath}, step.inspectNativeLibraries([]string{apkPath}), parseVariants(""))
+
+	if len(recorder.warnings) != 1 {
+		t.Fatalf("expected an unstripped release library warning, got: %v", recorder.warnings)
+	}
+	assert.Contains(t, recorder.warnings[0], "app-release.apk is a release artifact shipping 2.0 KiB of native debug info")
+}
+
+func Test_readManifests(t *testing.T) {
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "app-release.apk")
+	root := &axml.Element{Name: "manifest", Attributes: []axml.Attribute{{Name: "package", Type: axml.TypeString, Value: "io.bitrise.sample"}}}
+	ziptest.Write(t, apkPath, []ziptest.Entry{{Name: "AndroidManifest.xml", Content: axmltest.Encode(root)}})
+	missingPath := filepath.Join(dir, "missing.apk")
+
+	manifests := createStep().readManifests([]string{apkPath, missingPath})
+
+	assert.Len(t, manifests, 1)
+	assert.Equal(t, "io.bitrise.sample", manifests[apkPath].Package)
+}
+
+func Test_dexTable(t *testing.T) {
+	assert.Equal(t, []string{
+		"    DEX file            Methods     Fields    Classes",
+		"    classes.dex           65000      30000       9000",
+		"    classes2.dex           1200        300        150",
+		"    Total                 66200      30300       9150",
+	}, dexTable([]dex.File{
+		{Name: "classes.dex", Methods: 65000, Fields: 30000, Classes: 9000},
+		{Name: "classes2.dex", Methods: 1200, Fields: 300, Classes: 150},
+	}))
+}
+
+func Test_mappingVariant(t *testing.T) {
+	mappingFiles := []gradle.Artifact{
+		{Name: "app-mapping.txt", Path: "/project/app/build/outputs/mapping/release/mapping.txt"},
+		{Name: "app-mapping.txt", Path: "/project/app/build/outputs/mapping/demoRelease/mapping.txt"},
+	}
+
+	variant, ok := mappingVariant("app-demo-release.apk", mappingFiles)
+	assert.True(t, ok)
+	assert.Equal(t, "demoRelease", variant)
+
+	variant, ok = mappingVariant("app-release-unsigned.apk", mappingFiles)
+	assert.True(t, ok)
+	assert.Equal(t, "release", variant)
+
+	_, ok = mappingVariant("app-debug.apk", mappingFiles)
+	assert.False(t, ok)
+}
+
+func Test_parseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024":      1024,
+		"512B":      512,
+		"150MB":     150000000,
+		"1.5 MiB":   1572864,
+		"2GB":       2000000000,
+		" 10KiB ":   10240,
+		"0":         0,
+		"0.5KB":     500,
+		"100 bytes": -1,
+		"-1MB":      -1,
+		"MB":        -1,
+	}
+	for value, want := range tests {
+		size, err := parseSize(value)
+		if want < 0 {
+			assert.Error(t, err, value)
+			continue
+		}
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, size, value)
+	}
+}
+
+func Test_parseSizeBudget(t *testing.T) {
+	baselinePath := filepath.Join(t.TempDir(), "baseline-size-report.json")
+	if err := os.WriteFile(baselinePath, []byte(`{"artifact":"app-release.apk","file_size":1000}`), 0o600); err != nil {
+		t.Fatalf("failed to write baseline: %s", err)
+	}
+
+	budget, err := parseSizeBudget(Input{})
+	assert.NoError(t, err)
+	assert.Nil(t, budget)
+
+	budget, err = parseSizeBudget(Input{MaxArtifactSize: "100MB"})
+	assert.NoError(t, err)
+	assert.Equal(t, &SizeBudget{MaxArtifactSize: 100000000, MaxIncrease: -1}, budget)
+
+	budget, err = parseSizeBudget(Input{SizeBaselinePath: baselinePath, MaxSizeIncrease: "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, &SizeBudget{
+		Baseline:     &sizereport.Report{Artifact: "app-release.apk", FileSize: 1000},
+		BaselineName: "baseline-size-report.json",
+		MaxIncrease:  0,
+	}, budget)
+
+	_, err = parseSizeBudget(Input{MaxSizeIncrease: "1MB"})
+	assert.Error(t, err)
+
+	_, err = parseSizeBudget(Input{MaxArtifactSize: "100MB", MaxSizeIncrease: "1MB"})
+	assert.Error(t, err)
+
+	_, err = parseSizeBudget(Input{MaxArtifactSize: "0"})
+	assert.Error(t, err)
+
+	_, err = parseSizeBudget(Input{SizeBaselinePath: filepath.Join(t.TempDir(), "missing.apk")})
+	assert.Error(t, err)
+
+	budget, err = parseSizeBudget(Input{SizeBaselinePath: baselinePath, CategorySizeIncreases: "dex=1MB\n\n assets = 500KB\nlib/arm64-v8a=0\n"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"dex": 1000000, "assets": 500000, "lib/arm64-v8a": 0}, budget.CategoryMaxIncreases)
+	assert.Equal(t, int64(-1), budget.MaxIncrease)
+
+	_, err = parseSizeBudget(Input{CategorySizeIncreases: "dex=1MB"})
+	assert.Error(t, err)
+
+	_, err = parseSizeBudget(Input{SizeBaselinePath: baselinePath, CategorySizeIncreases: "dex"})
+	assert.Error(t, err)
+
+	_, err = parseSizeBudget(Input{SizeBaselinePath: baselinePath, CategorySizeIncreases: "images=1MB"})
+	assert.Error(t, err)
+
+	_, err = parseSizeBudget(Input{SizeBaselinePath: baselinePath, CategorySizeIncreases: "dex=much"})
+	assert.Error(t, err)
+}
+
+func Test_sizeBudgetViolations(t *testing.T) {
+	baseline := sizereport.Report{
+		FileSize:   10 * 1024 * 1024,
+		Categories: []sizereport.Category{{Name: sizereport.CategoryAssets, Size: sizereport.Size{Compressed: 1024 * 1024}}},
+	}
+	report := sizereport.Report{
+		Artifact: "app-release.apk",
+		FileSize: 22 * 1024 * 1024,
+		Categories: []sizereport.Category{
+			{Name: sizereport.CategoryDex, Size: sizereport.Size{Compressed: 512 * 1024}},
+			{Name: sizereport.CategoryAssets, Size: sizereport.Size{Compressed: 13 * 1024 * 1024}},
+		},
+	}
+
+	assert.Empty(t, sizeBudgetViolations(report, SizeBudget{MaxIncrease: -1}))
+	assert.Empty(t, sizeBudgetViolations(report, SizeBudget{MaxArtifactSize: 50 * 1024 * 1024, Baseline: &baseline, MaxIncrease: -1}))
+
+	assert.Equal(t, []string{
+		"app-release.apk is 22.0 MiB, larger than the max_artifact_size of 20.0 MiB",
+		"assets of app-release.apk grew by 12.0 MiB, more than the max_size_increase of 1.0 MiB",
+		"total of app-release.apk grew by 12.0 MiB, more than the max_size_increase of 1.0 MiB",
+	}, sizeBudgetViolations(report, SizeBudget{MaxArtifactSize: 20 * 1024 * 1024, Baseline: &baseline, MaxIncrease: 1024 * 1024}))
+
+	assert.Equal(t, []string{
+		"assets of app-release.apk grew by 12.0 MiB, more than the category_size_increases of 10.0 MiB",
+	}, sizeBudgetViolations(report, SizeBudget{
+		Baseline:             &baseline,
+		MaxIncrease:          -1,
+		CategoryMaxIncreases: map[string]int64{sizereport.CategoryAssets: 10 * 1024 * 1024, sizereport.CategoryDex: 1024 * 1024},
+	}))
+
+	assert.Equal(t, []string{
+		"dex of app-release.apk grew by 512.0 KiB, more than the category_size_increases of 0 B",
+		"total of app-release.apk grew by 12.0 MiB, more than the max_size_increase of 1.0 MiB",
+	}, sizeBudgetViolations(report, SizeBudget{
+		Baseline:             &baseline,
+		MaxIncrease:          1024 * 1024,
+		CategoryMaxIncreases: map[string]int64{sizereport.CategoryAssets: 20 * 1024 * 1024, sizereport.CategoryDex: 0},
+	}))
+}
+
+func Test_printSizeReports(t *testing.T) {
+	dir := t.TempDir()
+	apkPath := filepath.Join(dir, "app-release.apk")
+	ziptest.Write(t, apkPath, ziptest.Files(map[string]string{"classes.dex": "dex", "assets/data.bin": "data"}))
+
+	reports := createStep().printSizeReports([]gradle.Artifact{
+		{Name: "app-release.apk", Path: apkPath},
+		{Name: "missing.apk", Path: filepath.Join(dir, "missing.apk")},
+	})
+
+	assert.Len(t, reports, 1)
+	report := reports[apkPath]
+	assert.Equal(t, "app-release.apk", report.Artifact)
+	assert.Equal(t, int64(3), report.Category(sizereport.CategoryDex).Uncompressed)
+	assert.Equal(t, int64(4), report.Category(sizereport.CategoryAssets).Uncompressed)
+}
+
+func Test_sizeDeltaTable(t *testing.T) {
+	assert.Equal(t, []string{
+		"  Category             Baseline      Current       Change",
+		"  assets                1.0 MiB     13.0 MiB    +12.0 MiB",
+		"  lib/x86               2.0 KiB          0 B     -2.0 KiB",
+	}, sizeDeltaTable([]sizereport.Delta{
+		{Name: sizereport.CategoryAssets, Baseline: 1024 * 1024, Current: 13 * 1024 * 1024},
+		{Name: "lib/x86", Baseline: 2048},
+	}))
+}
+
+func Test_releaseVariant(t *testing.T) {
+	variants := []string{"demoDebug", "demoRelease", "release"}
+
+	variant, ok := releaseVariant("app-demo-release-unsigned.apk", variants)
+	assert.True(t, ok)
+	assert.Equal(t, "demoRelease", variant)
+
+	variant, ok = releaseVariant("app-release.apk", variants)
+	assert.True(t, ok)
+	assert.Equal(t, "release", variant)
+
+	_, ok = releaseVariant("app-demo-debug.apk", variants)
+	assert.False(t, ok)
+
+	_, ok = releaseVariant("app-release.apk", parseVariants(""))
+	assert.False(t, ok)
+}
+
+func Test_releaseArtifact(t *testing.T) {
+	// Without a selected variant every variant is built, the artifact name tells the build type.
+	defaultVariants := parseVariants("")
+	assert.True(t, releaseArtifact("app-release.apk", defaultVariants))
+	assert.True(t, releaseArtifact("app-demo-release-unsigned.apk", defaultVariants))
+	assert.False(t, releaseArtifact("app-debug.apk", defaultVariants))
+
+	assert.True(t, releaseArtifact("app-demo-release.aab", parseVariants("demoRelease")))
+	assert.False(t, releaseArtifact("app-release.apk", parseVariants("debug")))
+}
+
+func Test_releaseArtifact_DebuggableReleaseAudit(t *testing.T) {
+	m := manifest.Manifest{TargetSDK: 34, Application: manifest.Application{Debuggable: "true", AllowBackup: "false", UsesCleartextTraffic: "false"}}
+
+	findings := manifest.Audit(m, releaseArtifact("app-release.apk", parseVariants("")))
+
+	if len(findings) != 1 {
+		t.Fatalf("expected a finding, got: %v", findings)
+	}
+	assert.Equal(t, manifest.RuleDebuggableRelease.ID, findings[0].Rule.ID)
+}
+
+func Test_signedAPKPath(t *testing.T) {
+	assert.Equal(t, "/deploy/app-release-signed.apk", signedAPKPath("/deploy/app-release-unsigned.apk"))
+	assert.Equal(t, "/deploy/app-release-signed.apk", signedAPKPath("/deploy/app-release.apk"))
+}
+
+func Test_signArtifacts(t *testing.T) {
+	deployDir := t.TempDir()
+	unsignedPath := filepath.Join(deployDir, "app-release-unsigned.apk")
+	ziptest.Write(t, unsignedPath, ziptest.Files(map[string]string{"classes.dex": "dex"}))
+	signing := SigningConfig{
+		KeystorePath:     "keystore/testdata/rsa-aes.p12",
+		KeystorePassword: "store-pass",
+		KeyAlias:         "upload",
+		KeyPassword:      "store-pass",
+	}
+
+	paths, err := createStep().signArtifacts([]string{unsignedPath}, apkAppType, signing)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(deployDir, "app-release-signed.apk")}, paths)
+	report, err := apksig.Verify(paths[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []apksig.Scheme{apksig.SchemeV1, apksig.SchemeV2, apksig.SchemeV3}, report.Schemes)
+
+	signing.KeystorePassword = "wrong-pass"
+	_, err = createStep().signArtifacts([]string{unsignedPath}, apkAppType, signing)
+	assert.EqualError(t, err, "failed to open keystore: keystore password is incorrect")
+}
+
+func Test_checkSigningKeystore(t *testing.T) {
+	valid := SigningConfig{
+		KeystorePath:     "keystore/testdata/rsa-aes.p12",
+		KeystorePassword: "store-pass",
+		KeyAlias:         "upload",
+		KeyPassword:      "store-pass",
+	}
+	step := createStep()
+	now := time.Now()
+
+	assert.NoError(t, step.checkSigningKeystore(valid, 30, now))
+
+	wrongPassword := valid
+	wrongPassword.KeystorePassword = "wrong-pass"
+	assert.EqualError(t, step.checkSigningKeystore(wrongPassword, 30, now), "keystore_password is incorrect for the keystore: keystore/testdata/rsa-aes.p12")
+
+	wrongAlias := valid
+	wrongAlias.KeyAlias = "release"
+	assert.EqualError(t, step.checkSigningKeystore(wrongAlias, 30, now), "key_alias (release) not found in the keystore, available aliases: upload")
+
+	expired := now.AddDate(100, 0, 0)
+	assert.Regexp(t, "^signing certificate of the key upload expired on ", step.checkSigningKeystore(valid, 30, expired).Error())
+}
+
+func Test_checkCertificateValidity(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	certificate := &x509.Certificate{
+		NotBefore: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:  time.Date(2026, time.January, 21, 0, 0, 0, 0, time.UTC),
+	}
+
+	warning, err := checkCertificateValidity(certificate, now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, warning)
+
+	warning, err = checkCertificateValidity(certificate, now, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, "expires on 2026-01-21 (in 20 days)", warning)
+
+	_, err = checkCertificateValidity(certificate, now.AddDate(0, 1, 0), 30)
+	assert.EqualError(t, err, "expired on 2026-01-21")
+
+	_, err = checkCertificateValidity(certificate, now.AddDate(-2, 0, 0), 30)
+	assert.EqualError(t, err, "is not valid before 2025-01-01")
+}
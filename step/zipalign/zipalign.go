@@ -0,0 +1,59 @@
+// Package zipalign verifies the alignment of the APK entries, like zipalign -c -p 4: the content of uncompressed
+// entries has to start at a 4-byte boundary, and the content of uncompressed native libraries at a page boundary,
+// so that they can be memory-mapped directly from the APK.
+package zipalign
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Alignment is the alignment of the uncompressed entries.
+	Alignment = 4
+	// PageAlignment is the alignment of the uncompressed native libraries.
+	PageAlignment = 4096
+)
+
+// Misalignment is an uncompressed entry not starting at its alignment boundary.
+type Misalignment struct {
+	Name      string
+	Offset    int64
+	Alignment int64
+}
+
+func (m Misalignment) String() string {
+	return fmt.Sprintf("%s (offset %d, expected %d-byte alignment)", m.Name, m.Offset, m.Alignment)
+}
+
+// Verify returns the misaligned entries of the APK.
+func Verify(pth string) ([]Misalignment, error) {
+	r, err := zip.OpenReader(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open APK: %s", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	var misalignments []Misalignment
+	for _, file := range r.File {
+		if file.Method != zip.Store {
+			continue
+		}
+
+		offset, err := file.DataOffset()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate %s: %s", file.Name, err)
+		}
+
+		alignment := int64(Alignment)
+		if strings.HasSuffix(file.Name, ".so") {
+			alignment = PageAlignment
+		}
+		if offset%alignment != 0 {
+			misalignments = append(misalignments, Misalignment{Name: file.Name, Offset: offset, Alignment: alignment})
+		}
+	}
+
+	return misalignments, nil
+}
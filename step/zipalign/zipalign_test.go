@@ -0,0 +1,47 @@
+package zipalign
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/bitrise-step-android-build/step/ziptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+
+	alignedPath := filepath.Join(dir, "aligned.apk")
+	ziptest.Write(t, alignedPath, []ziptest.Entry{
+		{Name: "AndroidManifest.xml", CompressedSize: 10},
+		{Name: "resources.arsc", Content: make([]byte, 101), Stored: true, Align: 4},
+		{Name: "lib/arm64-v8a/libapp.so", Content: make([]byte, 4000), Stored: true, Align: 4096},
+		{Name: "lib/x86_64/libapp.so", Content: make([]byte, 5000), Stored: true, Align: 16384},
+		{Name: "res/raw/sound.ogg", Content: make([]byte, 7), Stored: true, Align: 4},
+	})
+	misalignments, err := Verify(alignedPath)
+	assert.NoError(t, err)
+	assert.Empty(t, misalignments)
+
+	misalignedPath := filepath.Join(dir, "misaligned.apk")
+	ziptest.Write(t, misalignedPath, []ziptest.Entry{
+		{Name: "AndroidManifest.xml", CompressedSize: 10},
+		{Name: "resources.arsc", Content: make([]byte, 101), Stored: true, Align: 4},
+		{Name: "res/raw/sound.ogg", Content: make([]byte, 7), Stored: true, Align: 2},
+		{Name: "lib/arm64-v8a/libapp.so", Content: make([]byte, 4000), Stored: true, Align: 4},
+	})
+	misalignments, err = Verify(misalignedPath)
+	assert.NoError(t, err)
+	if len(misalignments) != 2 {
+		t.Fatalf("expected 2 misaligned entries, got: %v", misalignments)
+	}
+	assert.Equal(t, "res/raw/sound.ogg", misalignments[0].Name)
+	assert.Equal(t, int64(4), misalignments[0].Alignment)
+	assert.Equal(t, int64(2), misalignments[0].Offset%4)
+	assert.Equal(t, "lib/arm64-v8a/libapp.so", misalignments[1].Name)
+	assert.Equal(t, int64(4096), misalignments[1].Alignment)
+	assert.Contains(t, misalignments[1].String(), "expected 4096-byte alignment")
+
+	_, err = Verify(filepath.Join(dir, "missing.apk"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+// Package ziptest writes zip archives (APKs, AABs, APK sets) for tests.
+package ziptest
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"sort"
+	"testing"
+)
+
+// alignmentExtraID is the extra field zipalign pads the local file headers with.
+const alignmentExtraID = 0xd935
+
+// Entry is an entry of the test archive.
+type Entry struct {
+	Name    string
+	Content []byte
+	Stored  bool
+	// CompressedSize, if set, writes a deflated entry of this many zero bytes instead of the Content,
+	// with twice the size as the uncompressed size. The content can not be read, only the sizes are meaningful.
+	CompressedSize int
+	// Align is the alignment of the content offset: exactly aligned to it, but not to its double,
+	// with the padding in an alignment extra field, as zipalign writes it.
+	Align int
+}
+
+// Files returns the entries of the name to content map, deflated and sorted by name.
+func Files(files map[string]string) []Entry {
+	var entries []Entry
+	for name, content := range files {
+		entries = append(entries, Entry{Name: name, Content: []byte(content)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	return entries
+}
+
+// Write writes the archive of the entries to pth.
+func Write(t testing.TB, pth string, entries []Entry) {
+	t.Helper()
+	if err := os.WriteFile(pth, Zip(t, entries), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %s", pth, err)
+	}
+}
+
+// Zip returns the archive of the entries. The entries are written without data descriptors,
+// so that the content offsets are known while writing.
+func Zip(t testing.TB, entries []Entry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header, data := fileHeader(t, entry)
+
+		if entry.Align > 0 {
+			dataStart := buf.Len() + 30 + len(entry.Name) + 4
+			padding := 0
+			for (dataStart+padding)%entry.Align != 0 || (dataStart+padding)%(2*entry.Align) == 0 {
+				padding++
+			}
+			header.Extra = make([]byte, 4+padding)
+			binary.LittleEndian.PutUint16(header.Extra, alignmentExtraID)
+			binary.LittleEndian.PutUint16(header.Extra[2:], uint16(padding))
+		}
+
+		e, err := w.CreateRaw(header)
+		if err != nil {
+			t.Fatalf("failed to create %s: %s", entry.Name, err)
+		}
+		if _, err := e.Write(data); err != nil {
+			t.Fatalf("failed to write %s: %s", entry.Name, err)
+		}
+		// The writer is buffered, flushing makes buf.Len() the offset of the next local header.
+		if err := w.Flush(); err != nil {
+			t.Fatalf("failed to flush %s: %s", entry.Name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close archive: %s", err)
+	}
+
+	return buf.Bytes()
+}
+
+// fileHeader returns the header and the raw (possibly compressed) data of the entry.
+func fileHeader(t testing.TB, entry Entry) (*zip.FileHeader, []byte) {
+	header := &zip.FileHeader{Name: entry.Name, Method: zip.Deflate}
+
+	if entry.CompressedSize > 0 {
+		header.CompressedSize64 = uint64(entry.CompressedSize)
+		header.UncompressedSize64 = uint64(2 * entry.CompressedSize)
+		return header, make([]byte, entry.CompressedSize)
+	}
+
+	header.CRC32 = crc32.ChecksumIEEE(entry.Content)
+	header.UncompressedSize64 = uint64(len(entry.Content))
+	if entry.Stored {
+		header.Method = zip.Store
+		header.CompressedSize64 = uint64(len(entry.Content))
+		return header, entry.Content
+	}
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		t.Fatalf("failed to compress %s: %s", entry.Name, err)
+	}
+	if _, err := fw.Write(entry.Content); err != nil {
+		t.Fatalf("failed to compress %s: %s", entry.Name, err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("failed to compress %s: %s", entry.Name, err)
+	}
+	header.CompressedSize64 = uint64(compressed.Len())
+
+	return header, compressed.Bytes()
+}